@@ -105,39 +105,35 @@ func verifyStatusAbortMultipartUpload(respStatusCode, expectedStatusCode int) er
 // error AWS is said to return.
 
 // mainAbortMultipartUpload - abort multipart upload API test.
-func mainAbortMultipartUpload(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] Multipart (Abort Upload):", curTest, globalTotalNumTest)
-	scanBar(message)
+func mainAbortMultipartUpload(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] Multipart (Abort Upload):", curTest, run.totalNumTest)
+	run.scanBar(message)
 	// All multipart operations take place in the s3verify created buckets.
-	bucketName := s3verifyBuckets[0].Name
-	validObject := multipartObjects[1] // This multipart has not been completed and will instead be aborted.
+	bucketName := run.buckets[0].Name
+	validObject := run.multipartObjects[1] // This multipart has not been completed and will instead be aborted.
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new request.
 	req, err := newAbortMultipartUploadReq(bucketName, validObject.Key, validObject.UploadID)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("DELETE", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify that the response went through.
 	if err := abortMultipartUploadVerify(res, 204, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -0,0 +1,215 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/minio/s3verify/signv4"
+)
+
+// Header signed along with the requests whose signed headers are modified after signing.
+const badSignatureHeader = "X-Amz-Meta-S3verify"
+
+// badSignatureCheck - a ListBuckets request signed by sign and the error the server must reject it with.
+type badSignatureCheck struct {
+	name               string
+	sign               func(config ServerConfig, req *http.Request) *http.Request
+	expectedStatusCode int
+	expectedCode       string
+}
+
+// badSignatureRun - send the request of every check and verify that the server rejects it.
+func badSignatureRun(config ServerConfig, run *RunContext, message string, checks []badSignatureCheck) TestResult {
+	result := newTestResult(message, nil)
+	// The requests are not signed for the reference server, they are only sent to the tested server.
+	config.reference = nil
+	// Anonymous requests are not signed by newRequest, sign signs them instead.
+	unsigned := config
+	unsigned.Access, unsigned.Secret = "", ""
+	for _, check := range checks {
+		// Spin scanBar
+		run.scanBar(message)
+		listBucketsReq, err := newListBucketsReq()
+		if err != nil {
+			result.addCheck(config, check.name, err)
+			continue
+		}
+		req, err := unsigned.newRequest("GET", listBucketsReq)
+		if err != nil {
+			result.addCheck(config, check.name, err)
+			continue
+		}
+		res, err := config.doSignedRequest(check.sign(config, req), "", "")
+		if err != nil {
+			result.addCheck(config, check.name, err)
+			continue
+		}
+		err = verifyErrorCode(res, check.expectedStatusCode, check.expectedCode)
+		closeResponse(res)
+		result.addCheck(config, check.name, err)
+	}
+	return result
+}
+
+// signBadSignature - sign req with the credentials of config, as modified by the caller.
+func signBadSignature(config ServerConfig, req *http.Request) *http.Request {
+	return signv4.SignV4(*req, config.Access, config.Secret, config.SessionToken, config.Region)
+}
+
+// replaceAuthorization - replace the first match of pattern in the Authorization header of req.
+func replaceAuthorization(req *http.Request, pattern, replacement string) *http.Request {
+	auth := req.Header.Get("Authorization")
+	loc := regexp.MustCompile(pattern).FindStringIndex(auth)
+	if loc != nil {
+		req.Header.Set("Authorization", auth[:loc[0]]+replacement+auth[loc[1]:])
+	}
+	return req
+}
+
+// otherRegion - a region other than region.
+func otherRegion(region string) string {
+	if region == "us-west-2" {
+		return "eu-west-1"
+	}
+	return "us-west-2"
+}
+
+// Test that requests signed with the wrong secret key are rejected.
+func mainBadSignatureWrongSecret(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] BadSignature/WrongSecret:", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+	return badSignatureRun(config, run, message, []badSignatureCheck{
+		{"WrongSecret", func(config ServerConfig, req *http.Request) *http.Request {
+			config.Secret = "s3verify-wrong-" + config.Secret
+			return signBadSignature(config, req)
+		}, http.StatusForbidden, "SignatureDoesNotMatch"},
+	})
+}
+
+// Test that requests signed with an access key that does not exist are rejected.
+func mainBadSignatureUnknownAccessKey(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] BadSignature/UnknownAccessKey:", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+	return badSignatureRun(config, run, message, []badSignatureCheck{
+		{"UnknownAccessKey", func(config ServerConfig, req *http.Request) *http.Request {
+			config.Access = "S3VERIFYUNKNOWNKEY00"
+			return signBadSignature(config, req)
+		}, http.StatusForbidden, "InvalidAccessKeyId"},
+	})
+}
+
+// Test that requests signed for another region are rejected.
+func mainBadSignatureWrongRegion(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] BadSignature/WrongRegion:", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+	return badSignatureRun(config, run, message, []badSignatureCheck{
+		{"WrongRegion", func(config ServerConfig, req *http.Request) *http.Request {
+			config.Region = otherRegion(config.Region)
+			return signBadSignature(config, req)
+		}, http.StatusBadRequest, "AuthorizationHeaderMalformed"},
+	})
+}
+
+// Test that requests whose credential scope names another service than s3 are rejected.
+func mainBadSignatureWrongService(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] BadSignature/WrongService:", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+	return badSignatureRun(config, run, message, []badSignatureCheck{
+		{"WrongService", func(config ServerConfig, req *http.Request) *http.Request {
+			return replaceAuthorization(signBadSignature(config, req), "/s3/aws4_request", "/iam/aws4_request")
+		}, http.StatusBadRequest, "AuthorizationHeaderMalformed"},
+	})
+}
+
+// Test that requests whose signed headers were modified after signing are rejected.
+func mainBadSignatureModifiedHeader(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] BadSignature/ModifiedHeader:", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+	return badSignatureRun(config, run, message, []badSignatureCheck{
+		{"ModifiedHeader", func(config ServerConfig, req *http.Request) *http.Request {
+			req.Header.Set(badSignatureHeader, "signed")
+			req = signBadSignature(config, req)
+			req.Header.Set(badSignatureHeader, "modified")
+			return req
+		}, http.StatusForbidden, "SignatureDoesNotMatch"},
+		{"ModifiedDate", func(config ServerConfig, req *http.Request) *http.Request {
+			req = signBadSignature(config, req)
+			t, _ := time.Parse(iso8601DateFormat, req.Header.Get("X-Amz-Date"))
+			req.Header.Set("X-Amz-Date", t.Add(time.Second).Format(iso8601DateFormat))
+			return req
+		}, http.StatusForbidden, "SignatureDoesNotMatch"},
+	})
+}
+
+// Test that requests with x-amz- headers that were not signed are rejected.
+func mainBadSignatureUnsignedHeader(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] BadSignature/UnsignedHeader:", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+	return badSignatureRun(config, run, message, []badSignatureCheck{
+		{"AddedHeader", func(config ServerConfig, req *http.Request) *http.Request {
+			req = signBadSignature(config, req)
+			req.Header.Set(badSignatureHeader, "unsigned")
+			return req
+		}, http.StatusForbidden, "AccessDenied"},
+		{"UnsignedDate", func(config ServerConfig, req *http.Request) *http.Request {
+			// X-Amz-Date is still sent, but left out of the signed headers.
+			return replaceAuthorization(signBadSignature(config, req), ";x-amz-date", "")
+		}, http.StatusForbidden, "AccessDenied"},
+	})
+}
+
+// Test that requests with an Authorization header that can not be parsed are rejected.
+func mainBadSignatureMalformedAuthorization(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] BadSignature/MalformedAuthorization:", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+	return badSignatureRun(config, run, message, []badSignatureCheck{
+		{"MissingSignature", func(config ServerConfig, req *http.Request) *http.Request {
+			return replaceAuthorization(signBadSignature(config, req), ", Signature=[0-9a-f]+$", "")
+		}, http.StatusBadRequest, "AuthorizationHeaderMalformed"},
+		{"MalformedCredential", func(config ServerConfig, req *http.Request) *http.Request {
+			// Leave the region out of the credential scope.
+			return replaceAuthorization(signBadSignature(config, req), "/"+regexp.QuoteMeta(config.Region)+"/s3/", "/s3/")
+		}, http.StatusBadRequest, "AuthorizationHeaderMalformed"},
+	})
+}
+
+// Test that requests signed too long ago are rejected.
+func mainBadSignatureSkewedDate(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] BadSignature/SkewedDate:", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+	return badSignatureRun(config, run, message, []badSignatureCheck{
+		{"Past", func(config ServerConfig, req *http.Request) *http.Request {
+			// AWS S3 accepts requests signed up to 15 minutes away from its own time.
+			return signv4.SignV4At(*req, config.Access, config.Secret, config.SessionToken, config.Region, time.Now().Add(-time.Hour))
+		}, http.StatusForbidden, "RequestTimeTooSkewed"},
+		{"Future", func(config ServerConfig, req *http.Request) *http.Request {
+			return signv4.SignV4At(*req, config.Access, config.Secret, config.SessionToken, config.Region, time.Now().Add(time.Hour))
+		}, http.StatusForbidden, "RequestTimeTooSkewed"},
+	})
+}
@@ -0,0 +1,87 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// TestBadSignatureAgainstBrokenServers - every bad signature test fails against a server
+// that does not reject the request, or rejects it with another error.
+func TestBadSignatureAgainstBrokenServers(t *testing.T) {
+	handlers := map[string]brokenServer{
+		// The server does not check signatures at all.
+		"accepts": {
+			next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+			mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+				if rec.Code == http.StatusForbidden || rec.Code == http.StatusBadRequest {
+					replaceResponse(rec, http.StatusOK)
+				}
+			},
+		},
+		// The server rejects every bad signature with the same generic error.
+		"generic": {
+			next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+			mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+				if rec.Code == http.StatusForbidden || rec.Code == http.StatusBadRequest {
+					rec.Code = http.StatusForbidden
+					for _, code := range []string{"SignatureDoesNotMatch", "AuthorizationHeaderMalformed", "RequestTimeTooSkewed", "InvalidAccessKeyId"} {
+						replaceBody(rec, "<Code>"+code+"</Code>", "<Code>AccessDenied</Code>")
+					}
+				}
+			},
+		},
+	}
+	for name, handler := range handlers {
+		results := runSuite(t, handler, testSelection{run: regexp.MustCompile("^BadSignature/")})
+		for _, test := range unpreparedTests {
+			if !strings.HasPrefix(test.Name, "BadSignature/") {
+				continue
+			}
+			expected := TestFail
+			// The generic error happens to be the right one for unsigned headers.
+			if name == "generic" && test.Name == "BadSignature/UnsignedHeader" {
+				expected = TestPass
+			}
+			if result := results[test.Name]; result.Status != expected {
+				t.Errorf("%s: %s: expected %s, got %s", name, test.Name, expected, result.Status)
+			}
+		}
+	}
+}
+
+// TestBadSignatureRequestsCounted - the requests changed after signing are counted like any other request.
+func TestBadSignatureRequestsCounted(t *testing.T) {
+	results := runSuite(t, s3mem.New(testAccessKey, testSecretKey, testRegion), testSelection{run: regexp.MustCompile("^BadSignature/")})
+	for _, test := range unpreparedTests {
+		if !strings.HasPrefix(test.Name, "BadSignature/") {
+			continue
+		}
+		for _, check := range results[test.Name].Checks {
+			if check.Requests == 0 || check.Response.StatusCode == 0 {
+				t.Errorf("%s/%s: expected the request and its response to be recorded, got %d requests and status %d",
+					test.Name, check.Name, check.Requests, check.Response.StatusCode)
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/minio/mc/pkg/console"
+)
+
+// baselineChange - a test or scenario whose outcome differs from the baseline.
+type baselineChange struct {
+	Name       string     `json:"name"`
+	Before     TestStatus `json:"before,omitempty"` // Empty if the test was not part of the baseline.
+	After      TestStatus `json:"after"`
+	BeforeCode string     `json:"beforeCode,omitempty"`
+	AfterCode  string     `json:"afterCode,omitempty"`
+}
+
+// baselineComparison - the differences between the current run and a baseline run.
+type baselineComparison struct {
+	File         string           `json:"file"`
+	NewFailures  []baselineChange `json:"newFailures"`  // Tests failing now that did not fail in the baseline.
+	NewPasses    []baselineChange `json:"newPasses"`    // Tests passing now that failed in the baseline.
+	ChangedCodes []baselineChange `json:"changedCodes"` // Tests failing in both runs with a different S3 error code.
+}
+
+// loadBaseline - read a baseline, the report printed by a previous run with --format json.
+func loadBaseline(fileName string) (jsonReport, error) {
+	baselineFile, err := os.Open(fileName)
+	if err != nil {
+		return jsonReport{}, err
+	}
+	defer baselineFile.Close()
+	var baseline jsonReport
+	if err := jsonDecoder(baselineFile, &baseline); err != nil {
+		return jsonReport{}, fmt.Errorf("Unable to parse baseline %s: %v", fileName, err)
+	}
+	return baseline, nil
+}
+
+// loadTargetsBaseline - read a baseline of several targets, the report printed by a previous run with
+// --target and --format json. Returns the report of every target by name.
+func loadTargetsBaseline(fileName string) (map[string]jsonReport, error) {
+	baselineFile, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer baselineFile.Close()
+	var baseline jsonTargetsReport
+	if err := jsonDecoder(baselineFile, &baseline); err != nil {
+		return nil, fmt.Errorf("Unable to parse baseline %s: %v", fileName, err)
+	}
+	reports := make(map[string]jsonReport)
+	for _, report := range baseline.Targets {
+		reports[report.Target] = report
+	}
+	return reports, nil
+}
+
+// flattenResults - list every test and scenario in the order they were run, scenarios are named after their test.
+func flattenResults(prefix string, results []jsonTestResult) []jsonTestResult {
+	flattened := []jsonTestResult{}
+	for _, result := range results {
+		result.Name = prefix + result.Name
+		flattened = append(flattened, result)
+		flattened = append(flattened, flattenResults(result.Name+"/", result.Checks)...)
+	}
+	return flattened
+}
+
+// isFailure - check whether a status means the server did not behave like AWS S3.
+func isFailure(status TestStatus) bool {
+	return status == TestFail || status == TestXFail
+}
+
+// compareBaseline - compare the current report against the baseline stored in fileName.
+func compareBaseline(fileName string, baseline, current jsonReport) baselineComparison {
+	comparison := baselineComparison{
+		File:         fileName,
+		NewFailures:  []baselineChange{},
+		NewPasses:    []baselineChange{},
+		ChangedCodes: []baselineChange{},
+	}
+	before := make(map[string]jsonTestResult)
+	for _, result := range flattenResults("", baseline.Tests) {
+		before[result.Name] = result
+	}
+	for _, result := range flattenResults("", current.Tests) {
+		old, ok := before[result.Name]
+		change := baselineChange{
+			Name:       result.Name,
+			Before:     old.Status,
+			After:      result.Status,
+			BeforeCode: old.Code,
+			AfterCode:  result.Code,
+		}
+		switch {
+		// Tests unknown to the baseline count as new failures, nothing is known about them.
+		case result.Status == TestFail && (!ok || old.Status != TestFail):
+			comparison.NewFailures = append(comparison.NewFailures, change)
+		case result.Status == TestPass && ok && isFailure(old.Status):
+			comparison.NewPasses = append(comparison.NewPasses, change)
+		case isFailure(result.Status) && ok && isFailure(old.Status) && result.Code != old.Code:
+			comparison.ChangedCodes = append(comparison.ChangedCodes, change)
+		}
+	}
+	return comparison
+}
+
+// printBaselineComparison - print the differences with the baseline to the console.
+func printBaselineComparison(comparison baselineComparison) {
+	console.Printf("Compared with baseline %s:\n", comparison.File)
+	console.Printf("  New failures (%d):\n", len(comparison.NewFailures))
+	for _, change := range comparison.NewFailures {
+		before := string(change.Before)
+		if before == "" {
+			before = "not in baseline"
+		}
+		console.Printf("    %s: %s -> %s\n", change.Name, before, change.After)
+	}
+	console.Printf("  New passes (%d):\n", len(comparison.NewPasses))
+	for _, change := range comparison.NewPasses {
+		console.Printf("    %s: %s -> %s\n", change.Name, change.Before, change.After)
+	}
+	console.Printf("  Changed error codes (%d):\n", len(comparison.ChangedCodes))
+	for _, change := range comparison.ChangedCodes {
+		console.Printf("    %s: %q -> %q\n", change.Name, change.BeforeCode, change.AfterCode)
+	}
+}
@@ -0,0 +1,287 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// runBaselineSuite - run the selected tests against a server altered by mutate and return their JSON report.
+func runBaselineSuite(t *testing.T, mutate func(r *http.Request, rec *httptest.ResponseRecorder), selection testSelection) jsonReport {
+	setGlobals(false)
+	server := httptest.NewServer(brokenServer{
+		next:   s3mem.New(testAccessKey, testSecretKey, testRegion),
+		mutate: mutate,
+	})
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started := time.Now()
+	return newJSONReport(*config, "", started, runTests(*config, newQuietRunContext("baseline", 1), unpreparedTests, selection))
+}
+
+// replaceNoSuchBucket - answer requests for missing buckets with 400 Bad Request and code instead of NoSuchBucket.
+func replaceNoSuchBucket(rec *httptest.ResponseRecorder, code string) {
+	if strings.Contains(rec.Body.String(), "<Code>NoSuchBucket</Code>") {
+		rec.Code = http.StatusBadRequest
+		replaceBody(rec, "<Code>NoSuchBucket</Code>", "<Code>"+code+"</Code>")
+	}
+}
+
+// changeNames - the names of the tests and scenarios of changes.
+func changeNames(changes []baselineChange) map[string]baselineChange {
+	names := make(map[string]baselineChange)
+	for _, change := range changes {
+		names[change.Name] = change
+	}
+	return names
+}
+
+// TestCompareBaseline - a run is compared with a baseline saved by a previous run, tests failing
+// only now, passing only now and failing with another error code are reported apart.
+func TestCompareBaseline(t *testing.T) {
+	// The baseline server answers HEAD on buckets with the wrong status and rejects
+	// bad signatures with another error code than AWS S3.
+	baseline := jsonReport{Tests: []jsonTestResult{
+		{Name: "PutBucket", Status: TestFail, Code: "InvalidBucketName"},
+		{Name: "HeadBucket", Status: TestFail},
+		{Name: "ListBuckets", Status: TestPass},
+		{Name: "BadSignature/WrongSecret", Status: TestFail, Code: "InvalidRequest", Checks: []jsonTestResult{
+			{Name: "WrongSecret", Status: TestFail, Code: "InvalidRequest"},
+		}},
+	}}
+	// The current server lists no buckets instead, and rejects bad signatures with yet another error code.
+	current := jsonReport{Tests: []jsonTestResult{
+		{Name: "PutBucket", Status: TestFail, Code: "InvalidBucketName"},
+		{Name: "HeadBucket", Status: TestPass},
+		{Name: "ListBuckets", Status: TestFail},
+		{Name: "BadSignature/WrongSecret", Status: TestFail, Code: "InvalidArgument", Checks: []jsonTestResult{
+			{Name: "WrongSecret", Status: TestFail, Code: "InvalidArgument"},
+		}},
+		{Name: "GetObject", Status: TestFail},
+	}}
+
+	// Save the baseline the way --format json prints it.
+	dir, err := ioutil.TempDir("", "s3verify-baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "baseline.json")
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fileName, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadBaseline(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comparison := compareBaseline(fileName, loaded, current)
+	if change, ok := changeNames(comparison.NewFailures)["ListBuckets"]; !ok || change.Before != TestPass || change.After != TestFail {
+		t.Errorf("Expected ListBuckets to be a new failure, got %v", comparison.NewFailures)
+	}
+	// Tests unknown to the baseline count as new failures.
+	if change, ok := changeNames(comparison.NewFailures)["GetObject"]; !ok || change.Before != "" {
+		t.Errorf("Expected GetObject to be a new failure, got %v", comparison.NewFailures)
+	}
+	if change, ok := changeNames(comparison.NewPasses)["HeadBucket"]; !ok || change.Before != TestFail || change.After != TestPass {
+		t.Errorf("Expected HeadBucket to be a new pass, got %v", comparison.NewPasses)
+	}
+	// The scenario and the test it is part of.
+	for _, name := range []string{"BadSignature/WrongSecret", "BadSignature/WrongSecret/WrongSecret"} {
+		if change, ok := changeNames(comparison.ChangedCodes)[name]; !ok || change.BeforeCode != "InvalidRequest" || change.AfterCode != "InvalidArgument" {
+			t.Errorf("Expected the error code of %s to change, got %v", name, comparison.ChangedCodes)
+		}
+	}
+	// Tests failing in both runs for the same reason are not reported.
+	for _, changes := range [][]baselineChange{comparison.NewFailures, comparison.NewPasses, comparison.ChangedCodes} {
+		if _, ok := changeNames(changes)["PutBucket"]; ok {
+			t.Errorf("Unexpected change of PutBucket: %v", changes)
+		}
+	}
+
+	// Comparing a run with itself finds no differences.
+	comparison = compareBaseline(fileName, loaded, loaded)
+	if len(comparison.NewFailures)+len(comparison.NewPasses)+len(comparison.ChangedCodes) != 0 {
+		t.Errorf("Expected no differences with the baseline itself, got %v", comparison)
+	}
+}
+
+// TestCompareBaselineRuns - runs against the in-memory server are compared with a baseline saved
+// by a previous run the same way.
+func TestCompareBaselineRuns(t *testing.T) {
+	selection := testSelection{run: regexp.MustCompile("^(HeadBucket|ListBuckets|RemoveBucket/DNE)$")}
+	// Both servers answer requests for missing buckets with another status and error code than AWS S3,
+	// the baseline server answers HEAD on buckets with the wrong status as well.
+	baseline := runBaselineSuite(t, func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "HEAD" && !isObjectRequest(r) {
+			replaceResponse(rec, http.StatusNoContent)
+		}
+		replaceNoSuchBucket(rec, "InvalidRequest")
+	}, selection)
+	// The current server lists no buckets instead.
+	current := runBaselineSuite(t, func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && r.URL.Path == "/" {
+			replaceBody(rec, "<Bucket>", "<Unknown>")
+			replaceBody(rec, "</Bucket>", "</Unknown>")
+		}
+		replaceNoSuchBucket(rec, "InvalidArgument")
+	}, selection)
+
+	// Save the baseline the way --format json prints it.
+	dir, err := ioutil.TempDir("", "s3verify-baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "baseline.json")
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fileName, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadBaseline(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	comparison := compareBaseline(fileName, loaded, current)
+	if change, ok := changeNames(comparison.NewFailures)["ListBuckets"]; !ok || change.Before != TestPass || change.After != TestFail {
+		t.Errorf("Expected ListBuckets to be a new failure, got %v", comparison.NewFailures)
+	}
+	if change, ok := changeNames(comparison.NewPasses)["HeadBucket"]; !ok || change.Before != TestFail || change.After != TestPass {
+		t.Errorf("Expected HeadBucket to be a new pass, got %v", comparison.NewPasses)
+	}
+	if change, ok := changeNames(comparison.ChangedCodes)["RemoveBucket/DNE"]; !ok || change.BeforeCode != "InvalidRequest" || change.AfterCode != "InvalidArgument" {
+		t.Errorf("Expected the error code of RemoveBucket/DNE to change, got %v", comparison.ChangedCodes)
+	}
+	// Tests failing in both runs for the same reason are not reported.
+	for _, changes := range [][]baselineChange{comparison.NewFailures, comparison.NewPasses, comparison.ChangedCodes} {
+		if _, ok := changeNames(changes)["PutBucket"]; ok {
+			t.Errorf("Unexpected change of PutBucket: %v", changes)
+		}
+	}
+
+	// Comparing a run with itself finds no differences.
+	comparison = compareBaseline(fileName, loaded, loaded)
+	if len(comparison.NewFailures)+len(comparison.NewPasses)+len(comparison.ChangedCodes) != 0 {
+		t.Errorf("Expected no differences with the baseline itself, got %v", comparison)
+	}
+}
+
+// TestLoadBaseline - files that are not JSON reports are rejected.
+func TestLoadBaseline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "s3verify-baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "baseline.json")
+	if err := ioutil.WriteFile(fileName, []byte("PASS"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadBaseline(fileName); err == nil {
+		t.Error("Expected an error for a file that is not a JSON report")
+	}
+	if _, err := loadBaseline(filepath.Join(dir, "missing.json")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+// TestLoadTargetsBaseline - every target of a run with --target is compared with the report of the same target.
+func TestLoadTargetsBaseline(t *testing.T) {
+	selection := testSelection{run: regexp.MustCompile("^ListBuckets$")}
+	unchanged := func(r *http.Request, rec *httptest.ResponseRecorder) {}
+	noBuckets := func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && r.URL.Path == "/" {
+			replaceBody(rec, "<Bucket>", "<Unknown>")
+			replaceBody(rec, "</Bucket>", "</Unknown>")
+		}
+	}
+	// Save the baseline the way --target with --format json prints it.
+	baseline := jsonTargetsReport{Passed: true}
+	for _, name := range []string{"one", "two"} {
+		report := runBaselineSuite(t, unchanged, selection)
+		report.Target = name
+		baseline.Targets = append(baseline.Targets, report)
+	}
+	dir, err := ioutil.TempDir("", "s3verify-baseline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fileName := filepath.Join(dir, "baseline.json")
+	data, err := json.Marshal(baseline)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(fileName, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	baselines, err := loadTargetsBaseline(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(baselines) != 2 || baselines["one"].Target != "one" || baselines["two"].Target != "two" {
+		t.Fatalf("Expected the baselines of one and two, got %v", baselines)
+	}
+
+	testCases := []struct {
+		target     string
+		mutate     func(r *http.Request, rec *httptest.ResponseRecorder)
+		newFailure bool
+	}{
+		{"one", unchanged, false},
+		{"two", noBuckets, true},
+		// Every failure of a target missing from the baseline is new.
+		{"three", noBuckets, true},
+	}
+	for i, testCase := range testCases {
+		current := runBaselineSuite(t, testCase.mutate, selection)
+		comparison := compareBaseline(fileName, baselines[testCase.target], current)
+		if _, ok := changeNames(comparison.NewFailures)["ListBuckets"]; ok != testCase.newFailure {
+			t.Errorf("Test %d: %s: expected a new failure %v, got %v", i+1, testCase.target, testCase.newFailure, comparison.NewFailures)
+		}
+	}
+
+	// Files that are not JSON reports are rejected.
+	if err := ioutil.WriteFile(fileName, []byte("[]"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTargetsBaseline(fileName); err == nil {
+		t.Error("Expected an error for a file that is not a JSON report of targets")
+	}
+}
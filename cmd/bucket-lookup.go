@@ -0,0 +1,64 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Styles buckets are addressed with, see --lookup. Requests are addressed in
+// path style, like s3verify always did, unless ServerConfig.lookup says otherwise.
+const (
+	lookupAuto    = "auto"    // Virtual-hosted style for AWS S3 if the bucket name allows it, path style otherwise.
+	lookupPath    = "path"    // Path style, http://host/bucket/object.
+	lookupVirtual = "virtual" // Virtual-hosted style, http://bucket.host/object.
+)
+
+// Bucket names that can be put in front of the host name of the endpoint.
+var dnsCompatibleBucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{1,61}[a-z0-9]$`)
+
+// parseBucketLookup - check the style of --lookup.
+func parseBucketLookup(lookup string) (string, error) {
+	switch lookup {
+	case "":
+		return lookupPath, nil
+	case lookupAuto, lookupPath, lookupVirtual:
+		return lookup, nil
+	}
+	return "", fmt.Errorf("Unknown lookup style %s, use virtual, path or auto.", lookup)
+}
+
+// isVirtualHostStyle - check whether requests on bucketName sent to endpointURL are addressed
+// in virtual-hosted style for the lookup style, requests without a bucket never are.
+func isVirtualHostStyle(lookup string, endpointURL *url.URL, bucketName string) bool {
+	// Names that can not be part of a host name, like those of PutBucket/InvalidNames,
+	// are always sent in path style, they would not reach the server otherwise.
+	if bucketName == "" || !dnsCompatibleBucketName.MatchString(bucketName) {
+		return false
+	}
+	if lookup == lookupVirtual {
+		return true
+	}
+	if lookup != lookupAuto || !isAmazonEndpoint(endpointURL) {
+		return false
+	}
+	// The certificates of AWS S3 only match a single label in front of the endpoint.
+	return endpointURL.Scheme != "https" || !strings.Contains(bucketName, ".")
+}
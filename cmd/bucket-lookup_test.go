@@ -0,0 +1,176 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// hostRecorder - a handler that remembers the hosts requests were sent to.
+type hostRecorder struct {
+	next http.Handler
+
+	mu    sync.Mutex
+	hosts map[string]bool
+}
+
+// ServeHTTP - record the host of r and pass it on.
+func (h *hostRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.hosts[r.Host] = true
+	h.mu.Unlock()
+	h.next.ServeHTTP(w, r)
+}
+
+// runLookupSuite - run the selected tests, along with their reruns signed with Signature Version 2,
+// against the server at serverURL with buckets addressed in the lookup style, and return their results by name.
+func runLookupSuite(t *testing.T, serverURL, lookup string, selection testSelection) map[string]TestResult {
+	setGlobals(false)
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, serverURL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.lookup = lookup
+	// Send the requests to the server whatever their host, the bucket hosts are not in the DNS.
+	addr := strings.TrimPrefix(serverURL, "http://")
+	config.Client.Transport = &http.Transport{
+		Dial: func(network, _ string) (net.Conn, error) {
+			return net.Dial(network, addr)
+		},
+	}
+	tests := insertSignatureTests(unpreparedTests, signatureV2)
+	results := make(map[string]TestResult)
+	for _, result := range runTests(*config, newQuietRunContext("test-bkt", 1), tests, selection) {
+		results[result.Name] = result
+	}
+	return results
+}
+
+// TestBucketLookup - the tests return identical results whether buckets are
+// addressed in path style or in virtual-hosted style.
+func TestBucketLookup(t *testing.T) {
+	// Skip the presigned GET tests which wait for their URL to expire.
+	selection := testSelection{extended: true, skip: regexp.MustCompile("GetObject/Presigned$")}
+	results := make(map[string]map[string]TestResult)
+	for _, lookup := range []string{lookupPath, lookupVirtual} {
+		mem := s3mem.New(testAccessKey, testSecretKey, testRegion)
+		recorder := &hostRecorder{next: mem, hosts: make(map[string]bool)}
+		server := httptest.NewServer(recorder)
+		host := strings.TrimPrefix(server.URL, "http://")
+		mem.SetDomain(host)
+		results[lookup] = runLookupSuite(t, server.URL, lookup, selection)
+		server.Close()
+		// Only the virtual-hosted-style requests are sent to bucket hosts.
+		virtualHosts := 0
+		for requestHost := range recorder.hosts {
+			if strings.HasSuffix(requestHost, "."+host) {
+				virtualHosts++
+			}
+		}
+		if lookup == lookupPath && virtualHosts > 0 {
+			t.Errorf("%s: expected every request to be sent to %s, got %v", lookup, host, recorder.hosts)
+		}
+		if lookup == lookupVirtual && virtualHosts == 0 {
+			t.Errorf("%s: expected requests to be sent to bucket hosts, got %v", lookup, recorder.hosts)
+		}
+	}
+	for _, test := range selection.filter(insertSignatureTests(unpreparedTests, signatureV2)) {
+		path, virtual := results[lookupPath][test.Name], results[lookupVirtual][test.Name]
+		if path.Status != virtual.Status {
+			t.Errorf("%s: %s in path style, %s in virtual-hosted style: %v", test.Name, path.Status, virtual.Status, virtual.Err)
+		}
+		// The tests of temporary credentials need a session token.
+		if path.Status != TestPass && !strings.HasPrefix(test.Name, "SessionToken/") {
+			t.Errorf("%s: expected %s, got %s: %v", test.Name, TestPass, path.Status, path.Err)
+		}
+	}
+}
+
+// TestBucketLookupAgainstPathOnlyServer - virtual-hosted-style requests fail
+// against a server that only accepts path style requests.
+func TestBucketLookupAgainstPathOnlyServer(t *testing.T) {
+	server := httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+	defer server.Close()
+	results := runLookupSuite(t, server.URL, lookupVirtual, testSelection{run: regexp.MustCompile("^PutBucket$")})
+	if result := results["PutBucket"]; result.Status != TestFail {
+		t.Errorf("PutBucket: expected %s, got %s", TestFail, result.Status)
+	}
+}
+
+// TestMakeTargetURL - buckets are addressed in the lookup style, and in path style
+// whenever their name can not be part of a host name.
+func TestMakeTargetURL(t *testing.T) {
+	testCases := []struct {
+		endpoint   string
+		bucketName string
+		objectName string
+		lookup     string
+		expected   string
+	}{
+		{"http://localhost:9000", "", "", lookupVirtual, "http://localhost:9000/"},
+		{"http://localhost:9000", "bucket", "", lookupPath, "http://localhost:9000/bucket/"},
+		{"http://localhost:9000", "bucket", "object", lookupPath, "http://localhost:9000/bucket/object"},
+		{"http://localhost:9000", "bucket", "", lookupVirtual, "http://bucket.localhost:9000/"},
+		{"http://localhost:9000", "bucket", "dir/object", lookupVirtual, "http://bucket.localhost:9000/dir/object"},
+		{"http://localhost:9000", "S3verify", "", lookupVirtual, "http://localhost:9000/S3verify/"},
+		{"http://localhost:9000", "bucket", "object", lookupAuto, "http://localhost:9000/bucket/object"},
+		{"https://s3.amazonaws.com", "bucket", "object", lookupAuto, "https://bucket.s3.amazonaws.com/object"},
+		{"https://s3.amazonaws.com", "my.bucket", "object", lookupAuto, "https://s3.amazonaws.com/my.bucket/object"},
+		{"http://s3.amazonaws.com", "my.bucket", "object", lookupAuto, "http://my.bucket.s3.amazonaws.com/object"},
+		// Path style unless another style was asked for, AWS S3 included.
+		{"https://s3.amazonaws.com", "bucket", "object", "", "https://s3.amazonaws.com/bucket/object"},
+		{"http://localhost:9000", "bucket", "object", "", "http://localhost:9000/bucket/object"},
+	}
+	for i, testCase := range testCases {
+		targetURL, err := makeTargetURL(testCase.endpoint, testCase.bucketName, testCase.objectName, "us-east-1", testCase.lookup, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if targetURL.String() != testCase.expected {
+			t.Errorf("Test %d: expected %s, got %s", i+1, testCase.expected, targetURL)
+		}
+	}
+}
+
+// TestParseBucketLookup - only known lookup styles are accepted by --lookup.
+func TestParseBucketLookup(t *testing.T) {
+	testCases := []struct {
+		lookup   string
+		expected string
+		valid    bool
+	}{
+		{"", lookupPath, true},
+		{"auto", lookupAuto, true},
+		{"path", lookupPath, true},
+		{"virtual", lookupVirtual, true},
+		{"dns", "", false},
+	}
+	for i, testCase := range testCases {
+		lookup, err := parseBucketLookup(testCase.lookup)
+		if lookup != testCase.expected || (err == nil) != testCase.valid {
+			t.Errorf("Test %d: expected %q and valid %v, got %q and %v", i+1, testCase.expected, testCase.valid, lookup, err)
+		}
+	}
+}
@@ -0,0 +1,316 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// A cassette is a directory holding a session.json file describing the recorded run
+// and one numbered JSON file per HTTP exchange, in the order they happened.
+const cassetteSessionFile = "session.json"
+
+// Credentials sent inside of request bodies: the signature, policy and session token fields of
+// POST policy forms, the policy embedding the credential and session token, and streaming chunks.
+var (
+	formCredentialRegexp = regexp.MustCompile(`(name="(?:x-amz-signature|signature|policy|x-amz-security-token)"\r\n\r\n)[^\r\n]+`)
+	chunkSignatureRegexp = regexp.MustCompile(`chunk-signature=[0-9a-f]+`)
+)
+
+// Query parameters of presigned URLs that must not end up in a recording.
+var redactedQueryParams = []string{"X-Amz-Credential", "X-Amz-Signature", "X-Amz-Security-Token"}
+
+// cassetteSession - everything besides the HTTP exchanges needed to replay a run.
+type cassetteSession struct {
+	Version       string `json:"version"`       // s3verify version that made the recording.
+	Seed          int64  `json:"seed"`          // Seed of the run, names and data are generated from it.
+	Suffix        string `json:"suffix"`        // Suffix of the s3verify created buckets.
+	Endpoint      string `json:"endpoint"`      // URL of the recorded server.
+	Region        string `json:"region"`        // Region used to sign requests.
+	ServerVersion string `json:"serverVersion"` // Server header sent back by the recorded server.
+}
+
+// cassetteBody - a request or response body, base64 encoded unless it is valid UTF-8.
+type cassetteBody struct {
+	Data   string `json:"data"`
+	Base64 bool   `json:"base64,omitempty"`
+}
+
+// newCassetteBody - encode a body to be stored in a cassette.
+func newCassetteBody(body []byte) cassetteBody {
+	if utf8.Valid(body) {
+		return cassetteBody{Data: string(body)}
+	}
+	return cassetteBody{Data: base64.StdEncoding.EncodeToString(body), Base64: true}
+}
+
+// bytes - decode a body stored in a cassette.
+func (b cassetteBody) bytes() ([]byte, error) {
+	if b.Base64 {
+		return base64.StdEncoding.DecodeString(b.Data)
+	}
+	return []byte(b.Data), nil
+}
+
+// cassetteExchange - a single recorded request and the response or error it got.
+type cassetteExchange struct {
+	Method         string       `json:"method"`
+	URL            string       `json:"url"`
+	RequestHeader  http.Header  `json:"requestHeader"`
+	RequestBody    cassetteBody `json:"requestBody"`
+	StatusCode     int          `json:"statusCode,omitempty"`
+	ResponseHeader http.Header  `json:"responseHeader,omitempty"`
+	ResponseBody   cassetteBody `json:"responseBody"`
+	Error          string       `json:"error,omitempty"` // Set if no response was received.
+}
+
+// cassetteFileName - the name of the file holding the n-th exchange of a cassette.
+func cassetteFileName(dir string, n int) string {
+	return filepath.Join(dir, fmt.Sprintf("%06d.json", n))
+}
+
+// writeJSONFile - write v to fileName as indented JSON.
+func writeJSONFile(fileName string, v interface{}) error {
+	jsonBytes, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fileName, append(jsonBytes, '\n'), 0644)
+}
+
+// readJSONFile - read fileName as JSON into v.
+func readJSONFile(fileName string, v interface{}) error {
+	jsonFile, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer jsonFile.Close()
+	if err := jsonDecoder(jsonFile, v); err != nil {
+		return fmt.Errorf("Unable to parse %s: %v", fileName, err)
+	}
+	return nil
+}
+
+// loadCassetteSession - read the description of the run recorded in dir.
+func loadCassetteSession(dir string) (cassetteSession, error) {
+	var session cassetteSession
+	if err := readJSONFile(filepath.Join(dir, cassetteSessionFile), &session); err != nil {
+		return cassetteSession{}, err
+	}
+	return session, nil
+}
+
+// saveCassetteSession - write the description of the run being recorded to dir.
+func saveCassetteSession(dir string, session cassetteSession) error {
+	return writeJSONFile(filepath.Join(dir, cassetteSessionFile), session)
+}
+
+// cassetteRecorder - an http.RoundTripper storing every exchange with credentials redacted.
+type cassetteRecorder struct {
+	dir       string
+	secrets   []string // Access keys and session tokens to remove from everything that is recorded.
+	transport http.RoundTripper
+
+	mutex sync.Mutex
+	count int // Number of exchanges recorded so far.
+}
+
+// newCassetteRecorder - record every exchange sent through transport to dir which must not hold a recording yet,
+// without any of secrets.
+func newCassetteRecorder(dir string, secrets []string, transport http.RoundTripper) (*cassetteRecorder, error) {
+	if _, err := os.Stat(filepath.Join(dir, cassetteSessionFile)); err == nil {
+		return nil, fmt.Errorf("%s already holds a recording, please use another directory.", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &cassetteRecorder{
+		dir:       dir,
+		secrets:   secrets,
+		transport: transport,
+	}, nil
+}
+
+// redact - remove the access keys, session tokens and signatures from text.
+func (r *cassetteRecorder) redact(text string) string {
+	for _, secret := range r.secrets {
+		if secret != "" {
+			text = strings.Replace(text, secret, "**REDACTED**", -1)
+		}
+	}
+	text = signatureRegexp.ReplaceAllString(text, "Signature=**REDACTED**")
+	text = formCredentialRegexp.ReplaceAllString(text, "${1}**REDACTED**")
+	return chunkSignatureRegexp.ReplaceAllString(text, "chunk-signature=**REDACTED**")
+}
+
+// redactURL - remove credentials from the query of a presigned URL.
+func (r *cassetteRecorder) redactURL(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for _, param := range redactedQueryParams {
+		if query.Get(param) != "" {
+			query.Set(param, "**REDACTED**")
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return r.redact(redacted.String())
+}
+
+// redactHeader - copy header without credentials.
+func (r *cassetteRecorder) redactHeader(header http.Header) http.Header {
+	redacted := http.Header{}
+	for name, values := range header {
+		for _, value := range values {
+			if http.CanonicalHeaderKey(name) == "Authorization" {
+				value = redactAuthorization(value)
+			} else if http.CanonicalHeaderKey(name) == "X-Amz-Security-Token" {
+				value = "**REDACTED**"
+			}
+			redacted.Add(name, r.redact(value))
+		}
+	}
+	return redacted
+}
+
+// redactBody - encode a body without credentials. Binary bodies hold no credentials.
+func (r *cassetteRecorder) redactBody(body []byte) cassetteBody {
+	encoded := newCassetteBody(body)
+	if !encoded.Base64 {
+		encoded.Data = r.redact(encoded.Data)
+	}
+	return encoded
+}
+
+// RoundTrip - send the request and record it along with the response.
+func (r *cassetteRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// Send a copy of the request, a RoundTripper must not modify the original.
+		outReq := *req
+		outReq.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+		req = &outReq
+	}
+	exchange := cassetteExchange{
+		Method:        req.Method,
+		URL:           r.redactURL(req.URL),
+		RequestHeader: r.redactHeader(req.Header),
+		RequestBody:   r.redactBody(reqBody),
+	}
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		exchange.Error = err.Error()
+	} else {
+		respBody, err := readBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		exchange.StatusCode = resp.StatusCode
+		exchange.ResponseHeader = r.redactHeader(resp.Header)
+		exchange.ResponseBody = newCassetteBody(respBody)
+	}
+	r.mutex.Lock()
+	r.count++
+	fileName := cassetteFileName(r.dir, r.count)
+	r.mutex.Unlock()
+	if writeErr := writeJSONFile(fileName, exchange); writeErr != nil {
+		return nil, writeErr
+	}
+	return resp, err
+}
+
+// cassettePlayer - an http.RoundTripper answering every request with the next recorded response.
+type cassettePlayer struct {
+	dir string
+
+	mutex sync.Mutex
+	count int // Number of exchanges replayed so far.
+}
+
+// newCassettePlayer - replay the exchanges recorded in dir.
+func newCassettePlayer(dir string) *cassettePlayer {
+	return &cassettePlayer{
+		dir: dir,
+	}
+}
+
+// RoundTrip - answer the request with the next recorded response if it matches the recorded request.
+func (p *cassettePlayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	p.mutex.Lock()
+	p.count++
+	n := p.count
+	p.mutex.Unlock()
+
+	var exchange cassetteExchange
+	if err := readJSONFile(cassetteFileName(p.dir, n), &exchange); err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("Request %d %s %s was never recorded, the run no longer matches the recording.", n, req.Method, req.URL.Path)
+		}
+		return nil, err
+	}
+	// The same names are generated from the recorded seed so the paths must match.
+	recordedURL, err := url.Parse(exchange.URL)
+	if err != nil {
+		return nil, err
+	}
+	if exchange.Method != req.Method || recordedURL.Path != req.URL.Path {
+		return nil, fmt.Errorf("Request %d is %s %s but %s %s was recorded, the run no longer matches the recording.",
+			n, req.Method, req.URL.Path, exchange.Method, recordedURL.Path)
+	}
+	if exchange.Error != "" {
+		return nil, errors.New(exchange.Error)
+	}
+	body, err := exchange.ResponseBody.bytes()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.StatusCode, http.StatusText(exchange.StatusCode)),
+		StatusCode:    exchange.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        exchange.ResponseHeader,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
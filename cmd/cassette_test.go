@@ -0,0 +1,278 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// Credentials sent by the cassette tests, none of them may be recorded.
+const (
+	cassetteAccessKey = "s3verify-access"
+	cassetteSignature = "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+)
+
+// sendCassetteRequests - send a signed and a presigned request to serverURL through transport
+// and return the bodies of their responses.
+func sendCassetteRequests(t *testing.T, serverURL string, transport http.RoundTripper) []string {
+	client := &http.Client{Transport: transport}
+	signed, err := http.NewRequest("PUT", serverURL+"/s3verify/object", strings.NewReader("s3verify data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+cassetteAccessKey+"/20160101/us-east-1/s3/aws4_request, SignedHeaders=host, Signature="+cassetteSignature)
+	presigned, err := http.NewRequest("GET", serverURL+"/s3verify/object?X-Amz-Credential="+cassetteAccessKey+"&X-Amz-Signature="+cassetteSignature, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bodies := []string{}
+	for _, req := range []*http.Request{signed, presigned} {
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, res.Status+" "+string(data))
+	}
+	return bodies
+}
+
+// TestCassetteRecordReplay - recorded exchanges are replayed offline with the same responses,
+// and no credentials end up in the recording.
+func TestCassetteRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "s3verify-cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		w.Write([]byte("<Result><Method>" + r.Method + "</Method></Result>"))
+	}))
+	recorder, err := newCassetteRecorder(dir, []string{cassetteAccessKey}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := sendCassetteRequests(t, server.URL, recorder)
+	if err := saveCassetteSession(dir, cassetteSession{Seed: 1, Suffix: "cassette", Endpoint: server.URL, Region: "us-east-1"}); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is left to answer the replayed requests but the recording.
+	server.Close()
+
+	// Every request was recorded without the access key and signatures.
+	fileNames, err := filepath.Glob(filepath.Join(dir, "0*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fileNames) != len(recorded) {
+		t.Fatalf("Expected %d recorded exchanges, got %d", len(recorded), len(fileNames))
+	}
+	for _, fileName := range fileNames {
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{cassetteAccessKey, cassetteSignature} {
+			if strings.Contains(string(data), secret) {
+				t.Errorf("%s: credentials were recorded", fileName)
+			}
+		}
+		if regexp.MustCompile(`Signature=[0-9a-f]{64}`).Match(data) {
+			t.Errorf("%s: a signature was recorded", fileName)
+		}
+	}
+	// A recording can not be overwritten.
+	if _, err := newCassetteRecorder(dir, []string{cassetteAccessKey}, nil); err == nil {
+		t.Error("Expected an error recording over an existing recording")
+	}
+
+	session, err := loadCassetteSession(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := sendCassetteRequests(t, session.Endpoint, newCassettePlayer(dir))
+	for i := range recorded {
+		if replayed[i] != recorded[i] {
+			t.Errorf("Response %d: recorded %q, replayed %q", i+1, recorded[i], replayed[i])
+		}
+	}
+
+	// Requests that were never recorded are not answered.
+	player := newCassettePlayer(dir)
+	req, err := http.NewRequest("DELETE", session.Endpoint+"/s3verify/object", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := player.RoundTrip(req); err == nil {
+		t.Error("Expected an error replaying a request that was not recorded")
+	}
+}
+
+// Tests recorded and replayed, none of them depends on the time it is run at.
+var cassetteSelection = testSelection{run: regexp.MustCompile("^(HeadObject|GetObject|ListObjectsV1|PutObject/Presigned|RemoveBucket)$")}
+
+// runCassetteSuite - run the cassette tests against serverURL through transport with the seed of the run.
+func runCassetteSuite(t *testing.T, serverURL string, transport http.RoundTripper, seed int64) []TestResult {
+	setGlobals(false)
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, serverURL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.Client.Transport = transport
+	return runTests(*config, newQuietRunContext("cassette", seed), unpreparedTests, cassetteSelection)
+}
+
+// TestCassetteRecordReplayRun - a run recorded against the in-memory server is replayed offline
+// with the same results, and no credentials end up in the recording.
+func TestCassetteRecordReplayRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "s3verify-cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+	recorder, err := newCassetteRecorder(dir, []string{testAccessKey}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded := runCassetteSuite(t, server.URL, recorder, 1)
+	if err := saveCassetteSession(dir, cassetteSession{Seed: 1, Suffix: "cassette", Endpoint: server.URL, Region: testRegion}); err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is left to answer the replayed requests but the recording.
+	server.Close()
+	if !testsPassed(recorded) {
+		t.Fatalf("Expected the recorded run to pass: %v", recorded)
+	}
+
+	// Every request was recorded without the access key and signatures.
+	verifyCassetteRedacted(t, dir, testAccessKey, testSecretKey)
+	// A recording can not be overwritten.
+	if _, err := newCassetteRecorder(dir, []string{testAccessKey}, nil); err == nil {
+		t.Error("Expected an error recording over an existing recording")
+	}
+
+	session, err := loadCassetteSession(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed := runCassetteSuite(t, session.Endpoint, newCassettePlayer(dir), session.Seed)
+	if len(replayed) != len(recorded) {
+		t.Fatalf("Expected %d replayed results, got %d", len(recorded), len(replayed))
+	}
+	for i, result := range replayed {
+		if result.Name != recorded[i].Name || result.Status != recorded[i].Status {
+			t.Errorf("Result %d: recorded %s %s, replayed %s %s: %v", i+1, recorded[i].Name, recorded[i].Status, result.Name, result.Status, result.Err)
+		}
+	}
+
+	// Another seed generates other names, which were never recorded.
+	mismatched := runCassetteSuite(t, session.Endpoint, newCassettePlayer(dir), session.Seed+1)
+	if testsPassed(mismatched) {
+		t.Error("Expected a run with another seed not to match the recording")
+	}
+}
+
+// Base64 strings long enough to hide credentials, such as POST policies.
+var cassetteBase64Regexp = regexp.MustCompile(`[A-Za-z0-9+/]{32,}={0,2}`)
+
+// verifyCassetteRedacted - verify that no exchange recorded in dir holds any of secrets or a signature,
+// not even base64 encoded.
+func verifyCassetteRedacted(t *testing.T, dir string, secrets ...string) {
+	fileNames, err := filepath.Glob(filepath.Join(dir, "0*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fileNames) == 0 {
+		t.Fatal("Expected recorded exchanges")
+	}
+	for _, fileName := range fileNames {
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var exchange cassetteExchange
+		if err := readJSONFile(fileName, &exchange); err != nil {
+			t.Fatal(err)
+		}
+		texts := []string{string(data)}
+		for _, encoded := range cassetteBase64Regexp.FindAllString(exchange.RequestBody.Data, -1) {
+			if decoded, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+				texts = append(texts, string(decoded))
+			}
+		}
+		for _, text := range texts {
+			for _, secret := range secrets {
+				if strings.Contains(text, secret) {
+					t.Errorf("%s: %s was recorded", fileName, secret)
+				}
+			}
+			if regexp.MustCompile(`Signature=[0-9a-f]{64}`).MatchString(text) {
+				t.Errorf("%s: a signature was recorded", fileName)
+			}
+		}
+	}
+}
+
+// TestCassetteRecordSessionToken - the session tokens of temporary credentials are redacted from
+// headers, presigned URLs, POST forms and the policies of POST forms.
+func TestCassetteRecordSessionToken(t *testing.T) {
+	dir, err := ioutil.TempDir("", "s3verify-cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	server := httptest.NewServer(newSessionTokenServer())
+	defer server.Close()
+	config, err := newServerConfigFor(testTemporaryAccessKey, testTemporarySecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.SessionToken = testSessionToken
+	config.expired = &credentials{access: "s3verify-expired-access", secret: "s3verify-expired-secret", sessionToken: "s3verify-expired-token"}
+	secrets := []string{config.Access, config.SessionToken, config.expired.access, config.expired.sessionToken}
+	if config.Client.Transport, err = newCassetteRecorder(dir, secrets, nil); err != nil {
+		t.Fatal(err)
+	}
+	selection := testSelection{extended: true, run: regexp.MustCompile("^(PostObject|PutObject/Streaming|GetObject/Presigned/Tampered|SessionToken/.*)$")}
+	results := runTests(*config, newQuietRunContext("cassette-token", 1), unpreparedTests, selection)
+	if !testsPassed(results) {
+		t.Fatalf("Expected the recorded run to pass: %v", results)
+	}
+	// Tampered tokens only differ from the session token in their last character.
+	tamperedPrefix := testSessionToken[:len(testSessionToken)-1]
+	verifyCassetteRedacted(t, dir, testTemporaryAccessKey, testTemporarySecretKey, tamperedPrefix,
+		"s3verify-expired-access", "s3verify-expired-secret", "s3verify-expired-token")
+}
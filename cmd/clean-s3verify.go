@@ -25,10 +25,10 @@ import (
 )
 
 // cleanObjects - use minio-go to remove any s3verify created objects.
-func cleanObjects(client *minio.Client, bucketName string) error {
+func cleanObjects(client *minio.Client, run *RunContext, bucketName string) error {
 	message := fmt.Sprintf("CleanUp %s (Removing Objects):", bucketName)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	doneCh := make(chan struct{})
 	defer close(doneCh)
@@ -37,31 +37,31 @@ func cleanObjects(client *minio.Client, bucketName string) error {
 	objectCh := client.ListObjects(bucketName, "s3verify/", true, doneCh)
 	for object := range objectCh {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		err := client.RemoveObject(bucketName, object.Key)
 		if err != nil {
 			// Do not stop on errors.
 			continue
 		}
 	}
-	printMessage(message, nil)
+	run.printMessage(message, nil)
 	return nil
 }
 
 // cleanBucket - use minio-go to cleanup any s3verify created buckets.
-func cleanBucket(client *minio.Client, bucketName string) error {
+func cleanBucket(client *minio.Client, run *RunContext, bucketName string) error {
 	message := fmt.Sprintf("CleanUp %s (Removing Bucket):", bucketName)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	if err := client.RemoveBucket(bucketName); err != nil {
 		return err
 	}
-	printMessage(message, nil)
+	run.printMessage(message, nil)
 	return nil
 }
 
 // cleanS3verify - purges the given bucketName of objects then removes the bucket.
-func cleanS3verify(config ServerConfig, bucketPrefix string) error {
+func cleanS3verify(config ServerConfig, run *RunContext, bucketPrefix string) error {
 	hostURL, err := url.Parse(config.Endpoint)
 	if err != nil {
 		return err
@@ -82,10 +82,10 @@ func cleanS3verify(config ServerConfig, bucketPrefix string) error {
 	// Delete all s3verify objects and buckets.
 	for _, bucket := range buckets {
 		if strings.HasPrefix(bucket.Name, bucketPrefix) {
-			if err := cleanObjects(client, bucket.Name); err != nil {
+			if err := cleanObjects(client, run, bucket.Name); err != nil {
 				return err
 			}
-			if err := cleanBucket(client, bucket.Name); err != nil {
+			if err := cleanBucket(client, run, bucket.Name); err != nil {
 				return err
 			}
 		}
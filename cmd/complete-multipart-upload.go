@@ -113,36 +113,32 @@ func verifyHeaderCompleteMultipartUpload(header http.Header) error {
 }
 
 // mainCompleteMultipartUpload - Complete Multipart Upload API test.
-func mainCompleteMultipartUpload(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] Multipart (Complete-Upload):", curTest, globalTotalNumTest)
+func mainCompleteMultipartUpload(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] Multipart (Complete-Upload):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
-	bucketName := s3verifyBuckets[0].Name
-	object := multipartObjects[0]
+	run.scanBar(message)
+	bucketName := run.buckets[0].Name
+	object := run.multipartObjects[0]
 	// Create a new completeMultipartUpload request.
-	req, err := newCompleteMultipartUploadReq(bucketName, object.Key, object.UploadID, complMultipartUploads[0])
+	req, err := newCompleteMultipartUploadReq(bucketName, object.Key, object.UploadID, run.complMultipartUploads[0])
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("POST", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
 	if err := completeMultipartUploadVerify(res, http.StatusOK, bucketName, object.Key); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return newTestResult(message, nil)
 }
@@ -0,0 +1,316 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minio/cli"
+	"gopkg.in/yaml.v2"
+)
+
+// configFile - the named servers to test, selected with --target. For example:
+//
+//	targets:
+//	  minio:
+//	    url: https://play.minio.io:9000
+//	    credentials:
+//	      accessEnv: MINIO_ACCESS
+//	      secretEnv: MINIO_SECRET
+//	    profile: minio-profile.json
+//	    extended: true
+//	  local:
+//	    url: https://localhost:9000
+//	    lookup: virtual
+//	    tls:
+//	      caCert: certs/ca.pem
+type configFile struct {
+	Targets map[string]targetConfig `yaml:"targets"`
+
+	dir string // Directory of the file, relative paths of the file are relative to it.
+}
+
+// targetConfig - a server to test and how to test it.
+type targetConfig struct {
+	URL         string            `yaml:"url"`         // URL of the server, see --url.
+	Region      string            `yaml:"region"`      // Region to sign requests for, see --region.
+	Credentials targetCredentials `yaml:"credentials"` // Keys to sign requests with.
+	TLS         targetTLS         `yaml:"tls"`         // How to verify the certificate of the server.
+	Profile     string            `yaml:"profile"`     // Vendor profile of the server, see --profile.
+	Run         string            `yaml:"run"`         // Tests run by default, see --run.
+	Skip        string            `yaml:"skip"`        // Tests skipped by default, see --skip.
+	Extended    bool              `yaml:"extended"`    // Run the extended tests by default, see --extended.
+	Lookup      string            `yaml:"lookup"`      // Style buckets are addressed with, see --lookup.
+}
+
+// targetCredentials - where the keys of a target come from. Keys given directly take
+// precedence over environment variables, then over the AWS profile and the mc alias.
+// Without any of them the credentials are looked for like for --url.
+type targetCredentials struct {
+	Access     string `yaml:"access"`
+	Secret     string `yaml:"secret"`
+	AccessEnv  string `yaml:"accessEnv"`  // Name of the environment variable holding the access key.
+	SecretEnv  string `yaml:"secretEnv"`  // Name of the environment variable holding the secret key.
+	AWSProfile string `yaml:"awsProfile"` // AWS profile of the shared credentials files, see --aws-profile.
+	MCAlias    string `yaml:"mcAlias"`    // Alias of the mc config file, see --mc-alias.
+}
+
+// targetTLS - TLS settings of a target.
+type targetTLS struct {
+	Insecure bool   `yaml:"insecure"` // Accept any certificate, only meant for test servers.
+	CACert   string `yaml:"caCert"`   // PEM file of the certificate authorities trusted on top of the system ones.
+}
+
+// testTarget - a server to test along with the tests to run against it.
+type testTarget struct {
+	name      string
+	config    *ServerConfig
+	selection testSelection
+	recordDir string // Directory the exchanges with the target are recorded to, empty unless --record was used.
+	replayDir string // Directory the exchanges with the target are replayed from, empty unless --replay was used.
+}
+
+// homeDir - the home directory of the user running s3verify.
+func homeDir() string {
+	if u, err := user.Current(); err == nil && u.HomeDir != "" {
+		return u.HomeDir
+	}
+	return os.Getenv("HOME")
+}
+
+// defaultConfigFile - the config file used when --config is not set.
+func defaultConfigFile() string {
+	return filepath.Join(homeDir(), ".s3verify", "config.yaml")
+}
+
+// loadConfigFile - read and parse the config file stored in fileName.
+func loadConfigFile(fileName string) (configFile, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return configFile{}, err
+	}
+	file, err := parseConfigFile(data)
+	if err != nil {
+		return configFile{}, fmt.Errorf("Unable to parse config file %s: %v", fileName, err)
+	}
+	file.dir = filepath.Dir(fileName)
+	return file, nil
+}
+
+// parseConfigFile - parse the YAML of a config file.
+func parseConfigFile(data []byte) (configFile, error) {
+	var file configFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return configFile{}, err
+	}
+	for name, target := range file.Targets {
+		if target.URL == "" {
+			return configFile{}, fmt.Errorf("Target %s has no url", name)
+		}
+	}
+	return file, nil
+}
+
+// path - resolve a path of the config file.
+func (f configFile) path(name string) string {
+	if name == "" || filepath.IsAbs(name) {
+		return name
+	}
+	return filepath.Join(f.dir, name)
+}
+
+// targetNames - the names of every target of the file in order.
+func (f configFile) targetNames() []string {
+	names := []string{}
+	for name := range f.Targets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// resolve - the credentials of the target at endpoint, empty if the target has none.
+func (c targetCredentials) resolve(endpoint string) (credentials, error) {
+	access, secret := c.Access, c.Secret
+	if access == "" && c.AccessEnv != "" {
+		access = os.Getenv(c.AccessEnv)
+	}
+	if secret == "" && c.SecretEnv != "" {
+		secret = os.Getenv(c.SecretEnv)
+	}
+	if access != "" || secret != "" || (c.AWSProfile == "" && c.MCAlias == "") {
+		return credentials{access: access, secret: secret}, nil
+	}
+	chain := defaultCredentialChain(endpoint)
+	chain.awsProfile, chain.mcAlias = c.AWSProfile, c.MCAlias
+	return chain.resolve()
+}
+
+// newTLSConfig - the TLS settings of a target, nil for the default settings.
+func (f configFile) newTLSConfig(settings targetTLS) (*tls.Config, error) {
+	if !settings.Insecure && settings.CACert == "" {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: settings.Insecure,
+	}
+	if settings.CACert != "" {
+		pemBytes, err := ioutil.ReadFile(f.path(settings.CACert))
+		if err != nil {
+			return nil, err
+		}
+		// Keep trusting the system certificate authorities as well.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("No certificate found in %s", f.path(settings.CACert))
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return tlsConfig, nil
+}
+
+// newTestTarget - create the target called name from its settings.
+func (f configFile) newTestTarget(name string, settings targetConfig, verbose bool) (testTarget, error) {
+	tlsConfig, err := f.newTLSConfig(settings.TLS)
+	if err != nil {
+		return testTarget{}, fmt.Errorf("Invalid TLS settings for target %s: %v", name, err)
+	}
+	creds, err := settings.Credentials.resolve(settings.URL)
+	if err != nil {
+		return testTarget{}, fmt.Errorf("Target %s: %v", name, err)
+	}
+	config, err := newServerConfigFor(creds.access, creds.secret, settings.URL, settings.Region, tlsConfig, verbose)
+	if err != nil {
+		return testTarget{}, err
+	}
+	config.SessionToken = creds.sessionToken
+	config.lookup, err = parseBucketLookup(settings.Lookup)
+	if err != nil {
+		return testTarget{}, fmt.Errorf("Target %s: %v", name, err)
+	}
+	selection, err := newTestSelectionFor(settings.Run, settings.Skip, settings.Extended)
+	if err != nil {
+		return testTarget{}, fmt.Errorf("Target %s: %v", name, err)
+	}
+	target := testTarget{
+		name:      name,
+		config:    config,
+		selection: selection,
+	}
+	if settings.Profile != "" {
+		target.config.profile, err = loadProfile(f.path(settings.Profile))
+		if err != nil {
+			return testTarget{}, err
+		}
+	}
+	return target, nil
+}
+
+// targetCassetteDir - the directory of the target called name within the directory of --record or --replay,
+// every target is recorded on its own. Empty if dir is empty.
+func targetCassetteDir(dir, name string) string {
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, name)
+}
+
+// newTestTargets - create the targets selected with --target from the config file.
+// --run, --skip, --extended, --lookup and --profile override the settings of every target,
+// the targets without credentials use the credentials of the command line. The reference server,
+// the expired credentials and the recording or replaying of the command line apply to every target.
+func newTestTargets(ctx *cli.Context) ([]testTarget, error) {
+	fileName := ctx.GlobalString("config")
+	if fileName == "" {
+		fileName = defaultConfigFile()
+	}
+	file, err := loadConfigFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	verbose := ctx.Bool("verbose") || ctx.GlobalBool("verbose")
+	targets := []testTarget{}
+	for _, name := range ctx.GlobalStringSlice("target") {
+		settings, ok := file.Targets[name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown target %s, %s defines: %s", name, fileName, strings.Join(file.targetNames(), ", "))
+		}
+		if ctx.GlobalIsSet("run") {
+			settings.Run = ctx.GlobalString("run")
+		}
+		if ctx.GlobalIsSet("skip") {
+			settings.Skip = ctx.GlobalString("skip")
+		}
+		if ctx.GlobalIsSet("extended") {
+			settings.Extended = ctx.GlobalBool("extended")
+		}
+		if ctx.GlobalIsSet("lookup") {
+			settings.Lookup = ctx.GlobalString("lookup")
+		}
+		if ctx.GlobalIsSet("profile") {
+			// Loaded below, relative to the working directory rather than the config file.
+			settings.Profile = ""
+		}
+		target, err := file.newTestTarget(name, settings, verbose)
+		if err != nil {
+			return nil, err
+		}
+		target.recordDir, target.replayDir = targetCassetteDir(ctx.GlobalString("record"), name), targetCassetteDir(ctx.GlobalString("replay"), name)
+		if target.replayDir != "" {
+			// A replayed target is the recorded server, whose credentials are not part of the recording.
+			session, err := loadCassetteSession(target.replayDir)
+			if err != nil {
+				return nil, fmt.Errorf("Target %s: %v", name, err)
+			}
+			target.config.Endpoint, target.config.Region = session.Endpoint, session.Region
+			if target.config.Access == "" && target.config.Secret == "" {
+				target.config.Access, target.config.Secret = "REPLAY", "REPLAY"
+			}
+		}
+		if target.config.Access == "" && target.config.Secret == "" {
+			creds, err := newCredentialChain(ctx, target.config.Endpoint).resolve()
+			if err != nil {
+				return nil, fmt.Errorf("Target %s: %v", name, err)
+			}
+			target.config.Access, target.config.Secret, target.config.SessionToken = creds.access, creds.secret, creds.sessionToken
+		}
+		if target.config.Access == "" || target.config.Secret == "" {
+			return nil, fmt.Errorf("Target %s has no credentials, set them in %s or see 's3verify --help'", name, fileName)
+		}
+		if ctx.GlobalIsSet("profile") {
+			if target.config.profile, err = loadProfile(ctx.GlobalString("profile")); err != nil {
+				return nil, err
+			}
+		}
+		if err := configureRun(ctx, target.config, target.recordDir, target.replayDir, verbose); err != nil {
+			return nil, fmt.Errorf("Target %s: %v", name, err)
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
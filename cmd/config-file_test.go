@@ -0,0 +1,174 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// writeConfigFile - write a config file and the files it refers to into a new directory.
+func writeConfigFile(t *testing.T, files map[string]string) (string, func()) {
+	dir, err := ioutil.TempDir("", "s3verify-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			os.RemoveAll(dir)
+			t.Fatal(err)
+		}
+	}
+	return filepath.Join(dir, "config.yaml"), func() { os.RemoveAll(dir) }
+}
+
+// TestConfigFileTargets - the settings of a target are taken from the config file.
+func TestConfigFileTargets(t *testing.T) {
+	fileName, cleanup := writeConfigFile(t, map[string]string{
+		"config.yaml": `
+targets:
+  mem:
+    url: http://localhost:9000
+    credentials:
+      access: access
+      secretEnv: S3VERIFY_TEST_SECRET
+    tls:
+      insecure: true
+    profile: mem-profile.json
+    run: ^ListBuckets$
+    extended: true
+  aws:
+    url: https://s3.amazonaws.com
+    region: eu-west-1
+`,
+		"mem-profile.json": `{"name": "mem"}`,
+	})
+	defer cleanup()
+	os.Setenv("S3VERIFY_TEST_SECRET", "secret")
+	defer os.Unsetenv("S3VERIFY_TEST_SECRET")
+	file, err := loadConfigFile(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem, err := file.newTestTarget("mem", file.Targets["mem"], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mem.config.Access != "access" || mem.config.Secret != "secret" {
+		t.Errorf("Expected the keys access and secret, got %s and %s", mem.config.Access, mem.config.Secret)
+	}
+	if mem.config.Region != globalDefaultRegion {
+		t.Errorf("Expected region %s, got %s", globalDefaultRegion, mem.config.Region)
+	}
+	if mem.config.tlsConfig == nil || !mem.config.tlsConfig.InsecureSkipVerify {
+		t.Error("Expected the certificate of the server not to be verified")
+	}
+	// The profile is found next to the config file.
+	if mem.config.profile == nil || mem.config.profile.Name != "mem" {
+		t.Errorf("Expected the mem profile, got %v", mem.config.profile)
+	}
+	if !mem.selection.extended || mem.selection.run == nil || mem.selection.run.String() != "^ListBuckets$" {
+		t.Errorf("Unexpected selection %+v", mem.selection)
+	}
+	aws, err := file.newTestTarget("aws", file.Targets["aws"], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aws.config.Region != "eu-west-1" || aws.config.tlsConfig != nil || aws.config.profile != nil {
+		t.Errorf("Unexpected aws target %+v", aws.config)
+	}
+	if names := file.targetNames(); len(names) != 2 || names[0] != "aws" || names[1] != "mem" {
+		t.Errorf("Expected the targets aws and mem, got %v", names)
+	}
+}
+
+// TestInvalidConfigFile - mistakes in the config file are reported before testing starts.
+func TestInvalidConfigFile(t *testing.T) {
+	testCases := []string{
+		// Not YAML.
+		"targets: [",
+		// Missing url.
+		"targets:\n  mem:\n    region: us-east-1\n",
+	}
+	for i, testCase := range testCases {
+		if _, err := parseConfigFile([]byte(testCase)); err == nil {
+			t.Errorf("Test %d: expected an error", i+1)
+		}
+	}
+	// Settings that can only be checked when the target is created.
+	file, err := parseConfigFile([]byte(`
+targets:
+  cert:
+    url: https://localhost:9000
+    tls:
+      caCert: missing.pem
+  pattern:
+    url: https://localhost:9000
+    run: "("
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range file.targetNames() {
+		if _, err := file.newTestTarget(name, file.Targets[name], false); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// TestCombinedReport - every target is tested and gets its own suite in the JUnit report.
+func TestCombinedReport(t *testing.T) {
+	setGlobals(false)
+	file := configFile{Targets: map[string]targetConfig{}}
+	for _, name := range []string{"one", "two"} {
+		server := httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+		defer server.Close()
+		file.Targets[name] = targetConfig{
+			URL:         server.URL,
+			Region:      testRegion,
+			Credentials: targetCredentials{Access: testAccessKey, Secret: testSecretKey},
+			Run:         "^ListBuckets$",
+		}
+	}
+	junit := junitTestSuites{}
+	for _, name := range file.targetNames() {
+		target, err := file.newTestTarget(name, file.Targets[name], false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results := runTests(*target.config, newQuietRunContext(name, 1), unpreparedTests, target.selection)
+		if !testsPassed(results) {
+			t.Errorf("%s: expected every test to pass", name)
+		}
+		junit.Suites = append(junit.Suites, newJUnitTestSuite("s3verify."+name, target.config.Endpoint, time.Now(), results))
+	}
+	if len(junit.Suites) != 2 {
+		t.Fatalf("Expected 2 suites, got %d", len(junit.Suites))
+	}
+	for i, name := range []string{"s3verify.one", "s3verify.two"} {
+		suite := junit.Suites[i]
+		if suite.Name != name || suite.Failures != 0 || len(suite.Cases) == 0 || suite.Cases[0].ClassName != name {
+			t.Errorf("Unexpected suite %+v", suite)
+		}
+	}
+}
@@ -115,15 +115,15 @@ func verifyStatusCopyObjectIfMatch(respStatusCode, expectedStatusCode int) error
 }
 
 // Test the PUT Object Copy with If-Match header is set.
-func mainCopyObjectIfMatch(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] CopyObject (If-Match)", curTest, globalTotalNumTest)
+func mainCopyObjectIfMatch(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] CopyObject (If-Match)", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// All copy-object-if-match tests take place in
 	// s3verify created buckets on s3verify created objects.
-	sourceBucketName := s3verifyBuckets[0].Name
-	destBucketName := s3verifyBuckets[1].Name
-	sourceObject := s3verifyObjects[0]
+	sourceBucketName := run.buckets[0].Name
+	destBucketName := run.buckets[1].Name
+	sourceObject := run.objects[0]
 
 	// Create bad ETag.
 	badETag := "1234567890"
@@ -136,47 +136,40 @@ func mainCopyObjectIfMatch(config ServerConfig, curTest int) bool {
 		Message: "At least one of the pre-conditions you specified did not hold",
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new valid PUT object copy request.
 	req, err := newCopyObjectIfMatchReq(sourceBucketName, sourceObject.Key, destBucketName, destObject.Key, sourceObject.ETag)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the response.
 	res, err := config.execRequest("PUT", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Verify the response.
 	if err := copyObjectIfMatchVerify(res, http.StatusOK, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 
 	// Create a new invalid PUT object copy request.
 	badReq, err := newCopyObjectIfMatchReq(sourceBucketName, sourceObject.Key, destBucketName, destObject.Key, badETag)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the request.
 	badRes, err := config.execRequest("PUT", badReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(badRes)
 	// Verify the request failed as expected.
 	if err := copyObjectIfMatchVerify(badRes, http.StatusPreconditionFailed, expectedError); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Save the copied object.
-	copyObjects = append(copyObjects, destObject)
+	run.addCopyObjects(destObject)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -113,80 +113,72 @@ func verifyHeaderCopyObjectIfModifiedSince(header http.Header) error {
 }
 
 // mainCopyObjectIfModifiedSince - test the CopyObject with if-modified-since header.
-func mainCopyObjectIfModifiedSince(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] CopyObject (If-Modified-Since):", curTest, globalTotalNumTest)
+func mainCopyObjectIfModifiedSince(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] CopyObject (If-Modified-Since):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// All copy-object-if-modified-since tests happen in s3verify created buckets
 	// on s3verify created objects.
-	sourceBucketName := s3verifyBuckets[0].Name
-	destBucketName := s3verifyBuckets[1].Name
-	sourceObject := s3verifyObjects[0]
+	sourceBucketName := run.buckets[0].Name
+	destBucketName := run.buckets[1].Name
+	sourceObject := run.objects[0]
 
 	// Set a date in the past.
 	pastDate, err := time.Parse(http.TimeFormat, "Thu, 01 Jan 1970 00:00:00 GMT")
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	destObject := &ObjectInfo{
 		Key: sourceObject.Key + "if-modified-since",
 	}
-	copyObjects = append(copyObjects, destObject)
+	run.addCopyObjects(destObject)
 	expectedError := ErrorResponse{
 		Code:    "PreconditionFailed",
 		Message: "At least one of the pre-conditions you specified did not hold",
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new request with a valid date.
 	req, err := newCopyObjectIfModifiedSinceReq(sourceBucketName, sourceObject.Key, destBucketName, destObject.Key, pastDate)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("PUT", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response is valid.
 	if err := copyObjectIfModifiedSinceVerify(res, http.StatusOK, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new request with an invalid date.
 	badReq, err := newCopyObjectIfModifiedSinceReq(sourceBucketName, sourceObject.Key, destBucketName, destObject.Key, time.Now().UTC().Add(2*time.Hour))
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	badRes, err := config.execRequest("PUT", badReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(badRes)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the bad request fails the right way.
 	if err := copyObjectIfModifiedSinceVerify(badRes, http.StatusPreconditionFailed, expectedError); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
-	printMessage(message, err)
-	return true
+	run.scanBar(message)
+	return newTestResult(message, nil)
 }
@@ -103,16 +103,16 @@ func verifyHeaderCopyObjectIfNoneMatch(header http.Header) error {
 }
 
 // Test the CopyObject API with the if-none-match header set.
-func mainCopyObjectIfNoneMatch(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] CopyObject (If-None-Match):", curTest, globalTotalNumTest)
+func mainCopyObjectIfNoneMatch(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] CopyObject (If-None-Match):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// All copy-object-if-none-match tests happen in s3verify created buckets
 	// on s3verify created objects.
-	sourceBucketName := s3verifyBuckets[0].Name
-	destBucketName := s3verifyBuckets[1].Name
-	sourceObject := s3verifyObjects[0]
+	sourceBucketName := run.buckets[0].Name
+	destBucketName := run.buckets[1].Name
+	sourceObject := run.objects[0]
 
 	// Create unmatchable ETag.
 	goodETag := "1234567890"
@@ -120,7 +120,7 @@ func mainCopyObjectIfNoneMatch(config ServerConfig, curTest int) bool {
 	destObject := &ObjectInfo{
 		Key: sourceObject.Key + "if-none-match",
 	}
-	copyObjects = append(copyObjects, destObject)
+	run.addCopyObjects(destObject)
 	// Create an error for the case that is expected to fail.
 	expectedError := ErrorResponse{
 		Code:    "PreconditionFailed",
@@ -129,39 +129,32 @@ func mainCopyObjectIfNoneMatch(config ServerConfig, curTest int) bool {
 	// Create a successful copy request.
 	req, err := newCopyObjectIfNoneMatchReq(sourceBucketName, sourceObject.Key, destBucketName, destObject.Key, goodETag)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the response.
 	res, err := config.execRequest("PUT", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Verify the response.
 	if err = copyObjectIfNoneMatchVerify(res, http.StatusOK, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Create a bad copy request.
 	badReq, err := newCopyObjectIfNoneMatchReq(sourceBucketName, sourceObject.Key, destBucketName, destObject.Key, sourceObject.ETag)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the response.
 	badRes, err := config.execRequest("PUT", badReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(badRes)
 	// Verify the response errors out as it should.
 	if err = copyObjectIfNoneMatchVerify(badRes, http.StatusPreconditionFailed, expectedError); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
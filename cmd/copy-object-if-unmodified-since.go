@@ -113,21 +113,20 @@ func verifyHeaderCopyObjectIfUnModifiedSince(header http.Header) error {
 }
 
 // mainCopyObjectIfUnModifiedSince - Entry point for the CopyObject if-unmodified-since test.
-func mainCopyObjectIfUnModifiedSince(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] CopyObject (If-Unmodified-Since): ", curTest, globalTotalNumTest)
+func mainCopyObjectIfUnModifiedSince(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] CopyObject (If-Unmodified-Since): ", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// All copy-object-if-unmodified-since tests happen in s3verify created buckets
 	// on s3verify created objects.
-	sourceBucketName := s3verifyBuckets[0].Name
-	destBucketName := s3verifyBuckets[1].Name
-	sourceObject := s3verifyObjects[0]
+	sourceBucketName := run.buckets[0].Name
+	destBucketName := run.buckets[1].Name
+	sourceObject := run.objects[0]
 
 	// Set a date in the past.
 	pastDate, err := time.Parse(http.TimeFormat, "Thu, 01 Jan 1970 00:00:00 GMT")
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	destObject := &ObjectInfo{
 		Key: sourceObject.Key + "if-unmodified-since",
@@ -140,54 +139,47 @@ func mainCopyObjectIfUnModifiedSince(config ServerConfig, curTest int) bool {
 	// Create a new valid request.
 	req, err := newCopyObjectIfUnModifiedSinceReq(sourceBucketName, sourceObject.Key, destBucketName, destObject.Key, sourceObject.LastModified.Add(time.Hour*2))
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("PUT", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
 	if err := copyObjectIfUnModifiedSinceVerify(res, http.StatusOK, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
-	// Add the copied object to the copyObjects slice.
-	copyObjects = append(copyObjects, destObject)
+	// Remember the copied object so that RemoveObject removes it.
+	run.addCopyObjects(destObject)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new invalid request.
 	badReq, err := newCopyObjectIfUnModifiedSinceReq(sourceBucketName, sourceObject.Key, destBucketName, destObject.Key, pastDate)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the bad request.
 	badRes, err := config.execRequest("PUT", badReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(badRes)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the bad request fails with the proper error.
 	if err := copyObjectIfUnModifiedSinceVerify(badRes, http.StatusPreconditionFailed, expectedError); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
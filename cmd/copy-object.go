@@ -21,10 +21,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
 	"net/url"
-	"time"
 )
 
 // newCopyObjectReq - Create a new HTTP request for PUT object with copy-
@@ -110,175 +108,83 @@ func verifyStatusCopyObject(respStatusCode, expectedStatusCode int) error {
 	return nil
 }
 
-// Test a PUT object request with the copy header set.
-func mainCopyObject(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] CopyObject:", curTest, globalTotalNumTest)
-	// Spin scanBar
-	scanBar(message)
-	// All copy-object tests happen in s3verify created buckets
-	// on s3verify created objects.
-	sourceBucketName := s3verifyBuckets[0].Name
-	destBucketName := s3verifyBuckets[1].Name
-	sourceObject := s3verifyObjects[0]
-
-	// TODO: create tests designed to fail.
-
-	// Test same name source and dest objects.
-	destObject := &ObjectInfo{
-		Key: sourceObject.Key,
-	}
-	copyObjects = append(copyObjects, destObject)
-	// Spin scanBar
-	scanBar(message)
+// copyObjectCheck - copy sourceObject from sourceBucketName to destObject in destBucketName and verify the response.
+func copyObjectCheck(config ServerConfig, sourceBucketName, sourceObject, destBucketName, destObject string, expectedStatusCode int, expectedError ErrorResponse) error {
 	// Create a new request.
-	req, err := newCopyObjectReq(sourceBucketName, sourceObject.Key, destBucketName, destObject.Key)
+	req, err := newCopyObjectReq(sourceBucketName, sourceObject, destBucketName, destObject)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return err
 	}
-	// Spin scanBar
-	scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("PUT", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return err
 	}
 	defer closeResponse(res)
-	// Spin scanBar
-	scanBar(message)
 	// Verify the response.
-	if err := copyObjectVerify(res, http.StatusOK, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
-	}
+	return copyObjectVerify(res, expectedStatusCode, expectedError)
+}
 
-	// Test different named source and dest objects.
-	destObjectDifName := &ObjectInfo{
-		Key: sourceObject.Key + "-copy",
-	}
-	// Spin scanBar
-	scanBar(message)
-	copyObjects = append(copyObjects, destObjectDifName)
-	// Create a new request.
-	difNameReq, err := newCopyObjectReq(sourceBucketName, sourceObject.Key, destBucketName, destObjectDifName.Key)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
+// Test a PUT object request with the copy header set.
+func mainCopyObject(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] CopyObject:", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
-	// Execute request.
-	difNameRes, err := config.execRequest("PUT", difNameReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
+	run.scanBar(message)
+	// All copy-object tests happen in s3verify created buckets
+	// on s3verify created objects.
+	sourceBucketName := run.buckets[0].Name
+	destBucketName := run.buckets[1].Name
+	sourceObject := run.objects[0]
+
+	// Same name source and dest objects.
+	destObject := &ObjectInfo{
+		Key: sourceObject.Key,
 	}
-	// Spin scanBar
-	scanBar(message)
-	// Verify the response.
-	if err := copyObjectVerify(difNameRes, http.StatusOK, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+	// Different named source and dest objects.
+	destObjectDifName := &ObjectInfo{
+		Key: sourceObject.Key + "-copy",
 	}
+	run.addCopyObjects(destObject, destObjectDifName)
 
-	// Test a failed copy. The source will not exist.
 	invalidKeyError := ErrorResponse{
 		Code:    "NoSuchKey",
 		Message: "The specified key does not exist.",
 	}
-	destObjectFailed := &ObjectInfo{
-		Key: randString(60, rand.NewSource(time.Now().UnixNano()), "s3verify-DNE-"),
-	}
-	// Spin scanBar
-	scanBar(message)
-	// No need to append this object will not be uploaded on a compatible server.
-	// Create a new request.
-	invalidReq, err := newCopyObjectReq(sourceBucketName, randString(60, rand.NewSource(time.Now().UnixNano()), ""), destBucketName, destObjectFailed.Key)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute request.
-	invalidRes, err := config.execRequest("PUT", invalidReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Verify the response failed.
-	if err := copyObjectVerify(invalidRes, http.StatusNotFound, invalidKeyError); err != nil {
-		printMessage(message, err)
-		return false
-	}
-
-	// Test a failed copy. The source bucket will not exist.
-	invalidSourceBucketError := ErrorResponse{
+	invalidBucketError := ErrorResponse{
 		Code:    "NoSuchBucket",
 		Message: "The specified bucket does not exist",
 	}
-
-	destObjectNoSourceBucket := &ObjectInfo{
-		Key: sourceObject.Key + "DNE",
-	}
-	// This object will not be created.
-	invalidSourceBucketReq, err := newCopyObjectReq(sourceBucketName+"dne", sourceObject.Key, destBucketName, destObjectNoSourceBucket.Key)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the response.
-	invalidSourceBucketRes, err := config.execRequest("PUT", invalidSourceBucketReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Verify the request failed correctly.
-	if err := copyObjectVerify(invalidSourceBucketRes, http.StatusNotFound, invalidSourceBucketError); err != nil {
-		printMessage(message, err)
-		return false
-	}
-
-	// Test a failed copy. The dest bucket will not exist.
-	invalidDestBucketError := ErrorResponse{
-		Code:    "NoSuchBucket",
-		Message: "The specified bucket does not exist",
-	}
-
-	destObjectNoDestBucket := &ObjectInfo{
-		Key: sourceObject.Key + "DNE",
-	}
-	// This object will not be created.
-	invalidDestBucketReq, err := newCopyObjectReq(sourceBucketName, sourceObject.Key, destBucketName+"dne", destObjectNoDestBucket.Key)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the response.
-	invalidDestBucketRes, err := config.execRequest("PUT", invalidDestBucketReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Verify the request failed correctly.
-	if err := copyObjectVerify(invalidDestBucketRes, http.StatusNotFound, invalidDestBucketError); err != nil {
-		printMessage(message, err)
-		return false
+	// None of the failed copies create an object on a compatible server
+	// so there is no need to append them to run.copyObjects.
+	checks := []struct {
+		name               string
+		sourceBucketName   string
+		sourceObject       string
+		destBucketName     string
+		destObject         string
+		expectedStatusCode int
+		expectedError      ErrorResponse
+	}{
+		// Test same name source and dest objects.
+		{"SameName", sourceBucketName, sourceObject.Key, destBucketName, destObject.Key, http.StatusOK, ErrorResponse{}},
+		// Test different named source and dest objects.
+		{"DifferentName", sourceBucketName, sourceObject.Key, destBucketName, destObjectDifName.Key, http.StatusOK, ErrorResponse{}},
+		// Test a failed copy. The source will not exist.
+		{"SourceKeyDNE", sourceBucketName, randString(60, run.newRandSource(), ""), destBucketName,
+			randString(60, run.newRandSource(), "s3verify-DNE-"), http.StatusNotFound, invalidKeyError},
+		// Test a failed copy. The source bucket will not exist.
+		{"SourceBucketDNE", sourceBucketName + "dne", sourceObject.Key, destBucketName, sourceObject.Key + "DNE", http.StatusNotFound, invalidBucketError},
+		// Test a failed copy. The dest bucket will not exist.
+		{"DestBucketDNE", sourceBucketName, sourceObject.Key, destBucketName + "dne", sourceObject.Key + "DNE", http.StatusNotFound, invalidBucketError},
+	}
+	result := newTestResult(message, nil)
+	for _, check := range checks {
+		// Spin scanBar
+		run.scanBar(message)
+		result.addCheck(config, check.name, copyObjectCheck(config, check.sourceBucketName, check.sourceObject,
+			check.destBucketName, check.destObject, check.expectedStatusCode, check.expectedError))
 	}
 	// Spin scanBar
-	scanBar(message)
-	// Test passed.
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return result
 }
@@ -0,0 +1,244 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/minio/cli"
+)
+
+// credentials - the keys requests are signed with and where they were found.
+type credentials struct {
+	access       string
+	secret       string
+	sessionToken string // Only set for temporary credentials.
+	source       string // Where the keys were found, e.g. "AWS profile default in ~/.aws/credentials".
+	endpoint     string // URL of the server the keys belong to, only known for mc aliases.
+}
+
+// credentialChain - every place credentials are looked for, in the order they are looked in:
+//
+//  1. --access and --secret, or S3_ACCESS and S3_SECRET.
+//  2. The AWS profile of --aws-profile or the mc alias of --mc-alias if one was asked for.
+//  3. AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+//  4. The AWS profile of AWS_PROFILE, or the default profile, in the shared credentials and config files.
+//  5. The alias of the mc config file whose URL is the URL of the tested server.
+type credentialChain struct {
+	access     string // --access.
+	secret     string // --secret.
+	awsProfile string // --aws-profile.
+	mcAlias    string // --mc-alias.
+	endpoint   string // URL of the tested server.
+
+	awsCredentialsFile string // Shared AWS credentials file, ~/.aws/credentials unless AWS_SHARED_CREDENTIALS_FILE is set.
+	awsConfigFile      string // Shared AWS config file, ~/.aws/config unless AWS_CONFIG_FILE is set.
+	mcConfigFile       string // Config file of mc, ~/.mc/config.json.
+}
+
+// newCredentialChain - the credential chain of the command line for the server at endpoint.
+func newCredentialChain(ctx *cli.Context, endpoint string) credentialChain {
+	chain := defaultCredentialChain(endpoint)
+	chain.access, chain.secret = ctx.GlobalString("access"), ctx.GlobalString("secret")
+	chain.awsProfile, chain.mcAlias = ctx.GlobalString("aws-profile"), ctx.GlobalString("mc-alias")
+	return chain
+}
+
+// defaultCredentialChain - the credential chain of the server at endpoint without any flags.
+func defaultCredentialChain(endpoint string) credentialChain {
+	chain := credentialChain{
+		endpoint:           endpoint,
+		awsCredentialsFile: os.Getenv("AWS_SHARED_CREDENTIALS_FILE"),
+		awsConfigFile:      os.Getenv("AWS_CONFIG_FILE"),
+		mcConfigFile:       filepath.Join(homeDir(), ".mc", "config.json"),
+	}
+	if chain.awsCredentialsFile == "" {
+		chain.awsCredentialsFile = filepath.Join(homeDir(), ".aws", "credentials")
+	}
+	if chain.awsConfigFile == "" {
+		chain.awsConfigFile = filepath.Join(homeDir(), ".aws", "config")
+	}
+	return chain
+}
+
+// resolve - find the first credentials of the chain. Credentials are empty if none were found,
+// an error is only returned if credentials that were asked for could not be loaded.
+func (c credentialChain) resolve() (credentials, error) {
+	if c.access != "" || c.secret != "" {
+		return credentials{access: c.access, secret: c.secret, source: "--access and --secret"}, nil
+	}
+	// Credentials asked for by name must exist.
+	if c.awsProfile != "" {
+		creds, ok, err := c.fromAWSProfile(c.awsProfile)
+		if err == nil && !ok {
+			err = fmt.Errorf("AWS profile %s was not found in %s or %s", c.awsProfile, c.awsCredentialsFile, c.awsConfigFile)
+		}
+		return creds, err
+	}
+	if c.mcAlias != "" {
+		creds, ok, err := c.fromMcAlias(func(name string, alias mcAlias) bool { return name == c.mcAlias })
+		if err == nil && !ok {
+			err = fmt.Errorf("mc alias %s was not found in %s", c.mcAlias, c.mcConfigFile)
+		}
+		return creds, err
+	}
+	if access, secret := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"); access != "" || secret != "" {
+		return credentials{
+			access:       access,
+			secret:       secret,
+			sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+			source:       "AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY",
+		}, nil
+	}
+	profile := os.Getenv("AWS_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	if creds, ok, err := c.fromAWSProfile(profile); err != nil || ok {
+		return creds, err
+	}
+	creds, _, err := c.fromMcAlias(func(name string, alias mcAlias) bool {
+		return strings.TrimSuffix(alias.URL, "/") == strings.TrimSuffix(c.endpoint, "/")
+	})
+	return creds, err
+}
+
+// fromAWSProfile - the credentials of an AWS profile. The shared credentials file takes
+// precedence over the config file, where profiles other than default are called "profile <name>".
+func (c credentialChain) fromAWSProfile(profile string) (credentials, bool, error) {
+	files := []struct {
+		fileName string
+		section  string
+	}{
+		{c.awsCredentialsFile, profile},
+		{c.awsConfigFile, "profile " + profile},
+	}
+	if profile == "default" {
+		files[1].section = "default"
+	}
+	for _, file := range files {
+		sections, err := loadINIFile(file.fileName)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return credentials{}, false, err
+		}
+		values, ok := sections[file.section]
+		if !ok || values["aws_access_key_id"] == "" {
+			continue
+		}
+		return credentials{
+			access:       values["aws_access_key_id"],
+			secret:       values["aws_secret_access_key"],
+			sessionToken: values["aws_session_token"],
+			source:       fmt.Sprintf("AWS profile %s in %s", profile, file.fileName),
+		}, true, nil
+	}
+	return credentials{}, false, nil
+}
+
+// loadINIFile - read the sections of an INI file such as ~/.aws/credentials, keys are lower cased.
+// Lines that are not a section or a key = value pair of a section are skipped, the files are shared
+// with other tools and a line s3verify does not understand must not prevent finding credentials.
+func loadINIFile(fileName string) (map[string]map[string]string, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	sections := make(map[string]map[string]string)
+	var section map[string]string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			if sections[name] == nil {
+				sections[name] = make(map[string]string)
+			}
+			section = sections[name]
+			continue
+		}
+		i := strings.Index(line, "=")
+		if i < 0 || section == nil {
+			continue
+		}
+		section[strings.ToLower(strings.TrimSpace(line[:i]))] = strings.TrimSpace(line[i+1:])
+	}
+	return sections, scanner.Err()
+}
+
+// mcConfig - the parts of the mc config file s3verify uses. Aliases are called hosts before version 10.
+type mcConfig struct {
+	Aliases map[string]mcAlias `json:"aliases"`
+	Hosts   map[string]mcAlias `json:"hosts"`
+}
+
+// mcAlias - a server mc knows about.
+type mcAlias struct {
+	URL          string `json:"url"`
+	AccessKey    string `json:"accessKey"`
+	SecretKey    string `json:"secretKey"`
+	SessionToken string `json:"sessionToken"`
+}
+
+// fromMcAlias - the credentials of the first alias of the mc config file, in name order, that matches.
+func (c credentialChain) fromMcAlias(matches func(name string, alias mcAlias) bool) (credentials, bool, error) {
+	mcFile, err := os.Open(c.mcConfigFile)
+	if os.IsNotExist(err) {
+		return credentials{}, false, nil
+	}
+	if err != nil {
+		return credentials{}, false, err
+	}
+	defer mcFile.Close()
+	config := mcConfig{}
+	if err := jsonDecoder(mcFile, &config); err != nil {
+		return credentials{}, false, fmt.Errorf("Unable to parse mc config file %s: %v", c.mcConfigFile, err)
+	}
+	aliases := config.Aliases
+	if len(aliases) == 0 {
+		aliases = config.Hosts
+	}
+	names := []string{}
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if alias := aliases[name]; matches(name, alias) {
+			return credentials{
+				access:       alias.AccessKey,
+				secret:       alias.SecretKey,
+				sessionToken: alias.SessionToken,
+				source:       fmt.Sprintf("mc alias %s in %s", name, c.mcConfigFile),
+				endpoint:     alias.URL,
+			}, true, nil
+		}
+	}
+	return credentials{}, false, nil
+}
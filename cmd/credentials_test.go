@@ -0,0 +1,148 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Shared files the credential chain is tested against.
+var testCredentialFiles = map[string]string{
+	"config.yaml": "",
+	"credentials": `
+# Long term keys.
+[default]
+aws_access_key_id = default-access
+aws_secret_access_key = default-secret
+
+[temporary]
+aws_access_key_id=temporary-access
+aws_secret_access_key=temporary-secret
+aws_session_token=temporary-token
+`,
+	"config": `
+[default]
+region = us-east-1
+this line is skipped
+
+[profile staging]
+aws_access_key_id = staging-access
+aws_secret_access_key = staging-secret
+`,
+	"mc.json": `{
+  "version": "9",
+  "hosts": {
+    "local": {"url": "http://localhost:9000/", "accessKey": "local-access", "secretKey": "local-secret", "api": "S3v4"},
+    "play": {"url": "https://play.minio.io:9000", "accessKey": "play-access", "secretKey": "play-secret", "api": "S3v4"}
+  }
+}`,
+}
+
+// TestCredentialChain - credentials are looked for in order and the first ones found are used.
+func TestCredentialChain(t *testing.T) {
+	fileName, cleanup := writeConfigFile(t, testCredentialFiles)
+	defer cleanup()
+	dir := filepath.Dir(fileName)
+	// Keep the environment of the user out of the test.
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE"} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Unsetenv(name)
+	}
+	newChain := func() credentialChain {
+		return credentialChain{
+			endpoint:           "http://localhost:9000",
+			awsCredentialsFile: filepath.Join(dir, "credentials"),
+			awsConfigFile:      filepath.Join(dir, "config"),
+			mcConfigFile:       filepath.Join(dir, "mc.json"),
+		}
+	}
+	flags := newChain()
+	flags.access, flags.secret, flags.awsProfile = "flag-access", "flag-secret", "staging"
+	awsProfile := newChain()
+	awsProfile.awsProfile = "staging"
+	temporaryProfile := newChain()
+	temporaryProfile.awsProfile = "temporary"
+	alias := newChain()
+	alias.mcAlias = "play"
+	testCases := []struct {
+		setEnv   func()
+		chain    credentialChain
+		expected credentials
+	}{
+		// Flags take precedence over everything else.
+		{func() {}, flags, credentials{access: "flag-access", secret: "flag-secret"}},
+		{func() {}, awsProfile, credentials{access: "staging-access", secret: "staging-secret"}},
+		{func() {}, temporaryProfile, credentials{access: "temporary-access", secret: "temporary-secret", sessionToken: "temporary-token"}},
+		{func() {}, alias, credentials{access: "play-access", secret: "play-secret", endpoint: "https://play.minio.io:9000"}},
+		{func() {
+			os.Setenv("AWS_ACCESS_KEY_ID", "env-access")
+			os.Setenv("AWS_SECRET_ACCESS_KEY", "env-secret")
+			os.Setenv("AWS_SESSION_TOKEN", "env-token")
+		}, newChain(), credentials{access: "env-access", secret: "env-secret", sessionToken: "env-token"}},
+		{func() { os.Setenv("AWS_PROFILE", "staging") }, newChain(), credentials{access: "staging-access", secret: "staging-secret"}},
+		{func() {}, newChain(), credentials{access: "default-access", secret: "default-secret"}},
+		// Without any AWS credentials the mc alias of the server is used.
+		{func() { os.Remove(filepath.Join(dir, "credentials")) }, newChain(), credentials{access: "local-access", secret: "local-secret", endpoint: "http://localhost:9000/"}},
+		{func() { os.Remove(filepath.Join(dir, "mc.json")) }, newChain(), credentials{}},
+	}
+	for i, testCase := range testCases {
+		for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE"} {
+			os.Unsetenv(name)
+		}
+		testCase.setEnv()
+		creds, err := testCase.chain.resolve()
+		if err != nil {
+			t.Errorf("Test %d: unexpected error: %v", i+1, err)
+			continue
+		}
+		if creds.source == "" && creds.access != "" {
+			t.Errorf("Test %d: expected the source of the credentials", i+1)
+		}
+		creds.source = ""
+		if creds != testCase.expected {
+			t.Errorf("Test %d: expected %+v, got %+v", i+1, testCase.expected, creds)
+		}
+	}
+}
+
+// TestCredentialChainErrors - credentials asked for by name must exist.
+func TestCredentialChainErrors(t *testing.T) {
+	fileName, cleanup := writeConfigFile(t, map[string]string{
+		"config.yaml": "",
+		"credentials": "aws_access_key_id = outside-of-any-section\n",
+		"mc.json":     `{"version": "10", "aliases": {}}`,
+	})
+	defer cleanup()
+	dir := filepath.Dir(fileName)
+	chain := credentialChain{
+		awsCredentialsFile: filepath.Join(dir, "credentials"),
+		awsConfigFile:      filepath.Join(dir, "config"),
+		mcConfigFile:       filepath.Join(dir, "mc.json"),
+	}
+	// Keys outside of any section do not belong to the default profile.
+	outsideSection := chain
+	outsideSection.awsProfile = "default"
+	unknownAlias := chain
+	unknownAlias.mcAlias = "play"
+	for i, chain := range []credentialChain{outsideSection, unknownAlias} {
+		if _, err := chain.resolve(); err == nil {
+			t.Errorf("Test %d: expected an error", i+1)
+		}
+	}
+}
@@ -0,0 +1,483 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Declarative tests are described in YAML files loaded with --cases instead of being
+// written in Go. Every test is a list of steps, every step sends one request and
+// verifies the response:
+//
+//	tests:
+//	  - name: Vendor/PutObject/Metadata
+//	    depends: [PutBucket]
+//	    steps:
+//	      - name: Put
+//	        method: PUT
+//	        bucket: "{{.bucket}}"
+//	        key: "s3verify/cases/{{random}}"
+//	        headers:
+//	          x-amz-meta-color: blue
+//	        body: hello
+//	        expect:
+//	          status: 200
+//	          headers:
+//	            ETag: 're:^"[0-9a-f]{32}"$'
+//	        capture:
+//	          etag: header:ETag
+//
+// Bucket, key, query, header, body and expected values are text/template templates of
+// the variables of the test: bucket and bucket2 are the buckets made by PutBucket, suffix
+// is the suffix of the run and every captured value is available to the following steps.
+// {{random}} generates a random name. Expected values starting with "re:" are regular
+// expressions, others must match exactly. Objects stored in bucket or bucket2 are removed
+// by RemoveObject, a test does not need to remove them itself.
+
+// declarativeFile - the contents of a YAML file of declarative tests.
+type declarativeFile struct {
+	Tests []declarativeTest `yaml:"tests"`
+}
+
+// declarativeTest - a test made of steps run in order, the test fails at the first step that fails.
+type declarativeTest struct {
+	Name     string            `yaml:"name"`     // Name of the test, see APItest.
+	Extended bool              `yaml:"extended"` // Only run the test with --extended.
+	Depends  []string          `yaml:"depends"`  // Names of the tests that must pass first, e.g. PutBucket.
+	Steps    []declarativeStep `yaml:"steps"`
+}
+
+// declarativeStep - a single request and what the response to it must look like.
+type declarativeStep struct {
+	Name    string            `yaml:"name"`    // Name of the step in the results, "Step<N>" if empty.
+	Method  string            `yaml:"method"`  // HTTP method of the request.
+	Bucket  string            `yaml:"bucket"`  // Bucket of the request, none for service requests.
+	Key     string            `yaml:"key"`     // Object key of the request, none for bucket requests.
+	Query   map[string]string `yaml:"query"`   // Query parameters of the request.
+	Headers map[string]string `yaml:"headers"` // Headers of the request.
+	Body    string            `yaml:"body"`    // Body of the request.
+	Expect  declarativeExpect `yaml:"expect"`  // What the response must look like.
+	Capture map[string]string `yaml:"capture"` // Values of the response to store in variables, "header:<name>" or "xpath:<path>".
+}
+
+// declarativeExpect - the expected response to a step.
+type declarativeExpect struct {
+	Status  int               `yaml:"status"`  // HTTP status code, 200 if not set.
+	Headers map[string]string `yaml:"headers"` // Expected values of response headers.
+	XPath   map[string]string `yaml:"xpath"`   // Expected values of elements of the XML body, see evalXPath.
+}
+
+// loadDeclarativeTests - load the declarative tests of a YAML file, or of every .yaml
+// and .yml file of a directory in lexical order.
+func loadDeclarativeTests(path string) ([]APItest, error) {
+	fileNames := []string{path}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		fileNames = nil
+		for _, pattern := range []string{"*.yaml", "*.yml"} {
+			matches, err := filepath.Glob(filepath.Join(path, pattern))
+			if err != nil {
+				return nil, err
+			}
+			fileNames = append(fileNames, matches...)
+		}
+		sort.Strings(fileNames)
+	}
+	tests := []APItest{}
+	for _, fileName := range fileNames {
+		data, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		fileTests, err := parseDeclarativeTests(data)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to load test cases from %s: %v", fileName, err)
+		}
+		tests = append(tests, fileTests...)
+	}
+	return tests, nil
+}
+
+// parseDeclarativeTests - parse and validate YAML test definitions.
+func parseDeclarativeTests(data []byte) ([]APItest, error) {
+	var file declarativeFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	tests := []APItest{}
+	for _, test := range file.Tests {
+		if err := test.validate(); err != nil {
+			return nil, err
+		}
+		tests = append(tests, APItest{
+			Name:     test.Name,
+			Test:     test.run,
+			Extended: test.Extended,
+			Depends:  test.Depends,
+		})
+	}
+	return tests, nil
+}
+
+// validate - make sure the test can be run, so that mistakes are reported before testing starts.
+func (t declarativeTest) validate() error {
+	if t.Name == "" {
+		return errors.New("Every test needs a name.")
+	}
+	if len(t.Steps) == 0 {
+		return fmt.Errorf("Test %s has no steps.", t.Name)
+	}
+	for i, step := range t.Steps {
+		if step.Method == "" {
+			return fmt.Errorf("Step %d of test %s has no method.", i+1, t.Name)
+		}
+		templates := []string{step.Bucket, step.Key, step.Body}
+		for _, values := range []map[string]string{step.Query, step.Headers, step.Expect.Headers, step.Expect.XPath} {
+			for _, value := range values {
+				templates = append(templates, value)
+			}
+		}
+		for _, text := range templates {
+			if _, err := newDeclarativeTemplate(text, func() string { return "" }); err != nil {
+				return fmt.Errorf("Step %d of test %s: %v", i+1, t.Name, err)
+			}
+		}
+		for name, source := range step.Capture {
+			if !strings.HasPrefix(source, "header:") && !strings.HasPrefix(source, "xpath:") {
+				return fmt.Errorf("Step %d of test %s: capture %s must start with header: or xpath:", i+1, t.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// newDeclarativeTemplate - parse a template of a step, random generates names for the run.
+func newDeclarativeTemplate(text string, random func() string) (*template.Template, error) {
+	return template.New("").Option("missingkey=error").Funcs(template.FuncMap{
+		"random": random,
+	}).Parse(text)
+}
+
+// declarativeVars - the variables of a running declarative test.
+type declarativeVars struct {
+	values map[string]string
+	random func() string
+}
+
+// expand - execute the template text with the variables of the test.
+func (v declarativeVars) expand(text string) (string, error) {
+	tmpl, err := newDeclarativeTemplate(text, v.random)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, v.values); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// run - run the steps of the test in order.
+func (t declarativeTest) run(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] %s:", curTest, run.totalNumTest, t.Name)
+	// Spin scanBar
+	run.scanBar(message)
+	vars := declarativeVars{
+		values: map[string]string{"suffix": run.suffix},
+		random: func() string {
+			return randString(60, run.newRandSource(), "")
+		},
+	}
+	for i, bucket := range run.buckets {
+		if i == 0 {
+			vars.values["bucket"] = bucket.Name
+		} else {
+			vars.values["bucket"+strconv.Itoa(i+1)] = bucket.Name
+		}
+	}
+	result := newTestResult(message, nil)
+	for i, step := range t.Steps {
+		// Spin scanBar
+		run.scanBar(message)
+		name := step.Name
+		if name == "" {
+			name = "Step" + strconv.Itoa(i+1)
+		}
+		err := step.run(config, run, vars)
+		result.addCheck(config, name, err)
+		if err != nil {
+			// Later steps usually rely on what earlier steps did.
+			break
+		}
+	}
+	// Spin scanBar
+	run.scanBar(message)
+	return result
+}
+
+// run - send the request of the step, verify the response and capture its values.
+func (s declarativeStep) run(config ServerConfig, run *RunContext, vars declarativeVars) error {
+	req, err := s.newRequest(vars)
+	if err != nil {
+		return err
+	}
+	res, err := config.execRequest(s.Method, req)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+	// Whatever the step expects, an object the server stored must be removed before its bucket.
+	if (s.Method == "PUT" || s.Method == "POST") && req.objectName != "" && res.StatusCode/100 == 2 {
+		trackDeclarativeObject(run, req.bucketName, req.objectName)
+	}
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if err := s.Expect.verify(res, body, vars); err != nil {
+		return err
+	}
+	return s.capture(res, body, vars)
+}
+
+// trackDeclarativeObject - remember an object a step stored in a bucket of the run, so that
+// RemoveObject removes it even if the test does not.
+func trackDeclarativeObject(run *RunContext, bucketName, objectName string) {
+	object := &ObjectInfo{Key: objectName}
+	switch {
+	case len(run.buckets) > 0 && bucketName == run.buckets[0].Name:
+		run.addObjects(object)
+	case len(run.buckets) > 1 && bucketName == run.buckets[1].Name:
+		// RemoveObject removes the copied objects from the second bucket.
+		run.addCopyObjects(object)
+	}
+}
+
+// newRequest - create the request of the step.
+func (s declarativeStep) newRequest(vars declarativeVars) (Request, error) {
+	req := Request{
+		customHeader: http.Header{},
+		queryValues:  make(url.Values),
+	}
+	var err error
+	if req.bucketName, err = vars.expand(s.Bucket); err != nil {
+		return Request{}, err
+	}
+	if req.objectName, err = vars.expand(s.Key); err != nil {
+		return Request{}, err
+	}
+	for name, value := range s.Query {
+		if value, err = vars.expand(value); err != nil {
+			return Request{}, err
+		}
+		req.queryValues.Set(name, value)
+	}
+	body, err := vars.expand(s.Body)
+	if err != nil {
+		return Request{}, err
+	}
+	sha256Sum := sha256.Sum256([]byte(body))
+	req.customHeader.Set("User-Agent", appUserAgent)
+	req.customHeader.Set("X-Amz-Content-Sha256", hex.EncodeToString(sha256Sum[:]))
+	if body != "" {
+		md5Sum := md5.Sum([]byte(body))
+		req.customHeader.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5Sum[:]))
+		req.contentBody = bytes.NewReader([]byte(body))
+		req.contentLength = int64(len(body))
+	}
+	// Headers of the step replace the default ones.
+	for name, value := range s.Headers {
+		if value, err = vars.expand(value); err != nil {
+			return Request{}, err
+		}
+		req.customHeader.Set(name, value)
+	}
+	return req, nil
+}
+
+// verify - check the response against what the step expects.
+func (e declarativeExpect) verify(res *http.Response, body []byte, vars declarativeVars) error {
+	expectedStatus := e.Status
+	if expectedStatus == 0 {
+		expectedStatus = http.StatusOK
+	}
+	if res.StatusCode != expectedStatus {
+		return fmt.Errorf("Unexpected Response Status Code: wanted %v, got %v", expectedStatus, res.StatusCode)
+	}
+	for _, name := range sortedKeys(e.Headers) {
+		if err := matchExpected(vars, "header "+name, e.Headers[name], res.Header.Get(name)); err != nil {
+			return err
+		}
+	}
+	if len(e.XPath) == 0 {
+		return nil
+	}
+	var root xmlNode
+	if err := xmlDecoder(bytes.NewReader(body), &root); err != nil {
+		return fmt.Errorf("Unable to parse the XML body: %v", err)
+	}
+	for _, expr := range sortedKeys(e.XPath) {
+		value, err := evalXPath(root, expr)
+		if err != nil {
+			return err
+		}
+		if err := matchExpected(vars, expr, e.XPath[expr], value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// capture - store the values of the response asked for by the step.
+func (s declarativeStep) capture(res *http.Response, body []byte, vars declarativeVars) error {
+	for _, name := range sortedKeys(s.Capture) {
+		source := s.Capture[name]
+		if strings.HasPrefix(source, "header:") {
+			header := strings.TrimPrefix(source, "header:")
+			if _, ok := res.Header[http.CanonicalHeaderKey(header)]; !ok {
+				return fmt.Errorf("Unable to capture %s: the response has no %s header", name, header)
+			}
+			vars.values[name] = res.Header.Get(header)
+			continue
+		}
+		var root xmlNode
+		if err := xmlDecoder(bytes.NewReader(body), &root); err != nil {
+			return fmt.Errorf("Unable to capture %s: %v", name, err)
+		}
+		value, err := evalXPath(root, strings.TrimPrefix(source, "xpath:"))
+		if err != nil {
+			return fmt.Errorf("Unable to capture %s: %v", name, err)
+		}
+		vars.values[name] = value
+	}
+	return nil
+}
+
+// matchExpected - compare a value of the response with the expected template, expected
+// values starting with "re:" are regular expressions.
+func matchExpected(vars declarativeVars, what, expected, actual string) error {
+	expected, err := vars.expand(expected)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(expected, "re:") {
+		pattern, err := regexp.Compile(strings.TrimPrefix(expected, "re:"))
+		if err != nil {
+			return fmt.Errorf("Invalid pattern for %s: %v", what, err)
+		}
+		if !pattern.MatchString(actual) {
+			return fmt.Errorf("Unexpected %s: wanted a match of %s, got %q", what, pattern, actual)
+		}
+		return nil
+	}
+	if actual != expected {
+		return fmt.Errorf("Unexpected %s: wanted %q, got %q", what, expected, actual)
+	}
+	return nil
+}
+
+// evalXPath - evaluate the small part of XPath needed to check S3 responses against an XML
+// document: absolute paths of element names, each optionally followed by a 1-based [index],
+// e.g. /ListBucketResult/Contents[2]/Key, and count(path). The value of a path is the text
+// of the first element it selects.
+func evalXPath(root xmlNode, expr string) (string, error) {
+	count := strings.HasPrefix(expr, "count(") && strings.HasSuffix(expr, ")")
+	path := expr
+	if count {
+		path = strings.TrimSuffix(strings.TrimPrefix(expr, "count("), ")")
+	}
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("Invalid path %s: only absolute paths are supported", expr)
+	}
+	// Start from a parent of the document element.
+	selected := []xmlNode{{Nodes: []xmlNode{root}}}
+	for _, step := range strings.Split(path[1:], "/") {
+		name, index := step, 0
+		if i := strings.Index(step, "["); i >= 0 && strings.HasSuffix(step, "]") {
+			var err error
+			if index, err = strconv.Atoi(step[i+1 : len(step)-1]); err != nil || index < 1 {
+				return "", fmt.Errorf("Invalid index in path %s", expr)
+			}
+			name = step[:i]
+		}
+		var children []xmlNode
+		for _, node := range selected {
+			position := 0
+			for _, child := range node.Nodes {
+				if child.XMLName.Local != name {
+					continue
+				}
+				position++
+				if index == 0 || position == index {
+					children = append(children, child)
+				}
+			}
+		}
+		selected = children
+	}
+	if count {
+		return strconv.Itoa(len(selected)), nil
+	}
+	if len(selected) == 0 {
+		return "", fmt.Errorf("No element of the response matches %s", expr)
+	}
+	return strings.TrimSpace(selected[0].Content), nil
+}
+
+// sortedKeys - the keys of m in order, so that values are always verified in the same order.
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// insertDeclarativeTests - add declarative tests to a list of tests, before the tests
+// removing the objects and buckets the declarative tests may use.
+func insertDeclarativeTests(tests []APItest, declared []APItest) []APItest {
+	i := 0
+	for i < len(tests) && !strings.HasPrefix(tests[i].Name, "Remove") {
+		i++
+	}
+	merged := append([]APItest{}, tests[:i]...)
+	merged = append(merged, declared...)
+	return append(merged, tests[i:]...)
+}
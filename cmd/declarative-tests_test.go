@@ -0,0 +1,238 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// Declarative tests run against the in-memory server.
+const testDeclarativeTests = `
+tests:
+  - name: Cases/PutGetRemove
+    depends: [PutBucket]
+    steps:
+      - name: Put
+        method: PUT
+        bucket: "{{.bucket}}"
+        key: cases/object
+        headers:
+          Content-Type: text/plain
+        body: hello
+        expect:
+          status: 200
+          headers:
+            ETag: 're:^"[0-9a-f]{32}"$'
+        capture:
+          etag: header:ETag
+      - name: Head
+        method: HEAD
+        bucket: "{{.bucket}}"
+        key: cases/object
+        expect:
+          headers:
+            ETag: "{{.etag}}"
+            Content-Type: text/plain
+            Content-Length: "5"
+      - name: List
+        method: GET
+        bucket: "{{.bucket}}"
+        query:
+          prefix: cases/
+        expect:
+          xpath:
+            /ListBucketResult/Name: "{{.bucket}}"
+            count(/ListBucketResult/Contents): "1"
+            /ListBucketResult/Contents[1]/Key: cases/object
+      - name: Remove
+        method: DELETE
+        bucket: "{{.bucket}}"
+        key: cases/object
+        expect:
+          status: 204
+  - name: Cases/NoSuchKey
+    depends: [PutBucket]
+    steps:
+      - method: GET
+        bucket: "{{.bucket}}"
+        key: "cases/{{random}}"
+        expect:
+          status: 404
+          xpath:
+            /Error/Code: NoSuchKey
+        capture:
+          requestId: xpath:/Error/RequestId
+  - name: Cases/WrongExpectation
+    depends: [PutBucket]
+    steps:
+      - method: HEAD
+        bucket: "{{.bucket}}"
+        expect:
+          status: 404
+`
+
+// TestDeclarativeTests - declarative tests are run along with the built-in tests and fail on unexpected responses.
+func TestDeclarativeTests(t *testing.T) {
+	declared, err := parseDeclarativeTests([]byte(testDeclarativeTests))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := insertDeclarativeTests(unpreparedTests, declared)
+	if err := checkDependencies(tests); err != nil {
+		t.Fatal(err)
+	}
+	setGlobals(false)
+	server := httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RemoveBucket only passes if the declarative tests removed what they created.
+	selection := testSelection{run: regexp.MustCompile("^(Cases/|RemoveBucket$)")}
+	results := make(map[string]TestResult)
+	for _, result := range runTests(*config, newQuietRunContext("cases", 1), tests, selection) {
+		results[result.Name] = result
+	}
+	expected := map[string]TestStatus{
+		"Cases/PutGetRemove":     TestPass,
+		"Cases/NoSuchKey":        TestPass,
+		"Cases/WrongExpectation": TestFail,
+		"RemoveBucket":           TestPass,
+	}
+	for name, status := range expected {
+		if result := results[name]; result.Status != status {
+			t.Errorf("%s: expected %s, got %s: %v", name, status, result.Status, result.Err)
+		}
+	}
+	if checks := results["Cases/PutGetRemove"].Checks; len(checks) != 4 {
+		t.Errorf("Expected a check per step, got %d", len(checks))
+	}
+}
+
+// Declarative tests that leave their objects behind.
+const testDeclarativeUploads = `
+tests:
+  - name: Cases/PutOnly
+    depends: [PutBucket]
+    steps:
+      - method: PUT
+        bucket: "{{.bucket}}"
+        key: "s3verify/cases/{{random}}"
+        body: hello
+      - method: PUT
+        bucket: "{{.bucket2}}"
+        key: "s3verify/cases/{{random}}"
+        body: hello
+`
+
+// TestDeclarativeTestsCleanup - the objects declarative tests upload are removed along with
+// the other objects of the run, so that the whole suite still passes RemoveBucket.
+func TestDeclarativeTestsCleanup(t *testing.T) {
+	declared, err := parseDeclarativeTests([]byte(testDeclarativeUploads))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := insertDeclarativeTests(unpreparedTests, declared)
+	setGlobals(false)
+	server := httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Skip the presigned GET test which waits for its URL to expire, and the
+	// tests of temporary credentials.
+	selection := testSelection{skip: regexp.MustCompile("^(GetObject/Presigned|SessionToken/.*)$")}
+	results := runTests(*config, newQuietRunContext("cases", 1), tests, selection)
+	if len(results) != len(selection.filter(tests)) {
+		t.Fatalf("Expected %d results, got %d", len(selection.filter(tests)), len(results))
+	}
+	for _, result := range results {
+		if result.Status != TestPass {
+			t.Errorf("%s: expected %s, got %s: %v", result.Name, TestPass, result.Status, result.Err)
+		}
+	}
+}
+
+// TestInvalidDeclarativeTests - mistakes in the definitions are reported before testing starts.
+func TestInvalidDeclarativeTests(t *testing.T) {
+	testCases := []string{
+		// Not YAML.
+		"tests: [",
+		// Missing name.
+		"tests:\n  - steps:\n      - method: GET\n",
+		// Missing steps.
+		"tests:\n  - name: Cases/Empty\n",
+		// Missing method.
+		"tests:\n  - name: Cases/NoMethod\n    steps:\n      - bucket: b\n",
+		// Invalid template.
+		"tests:\n  - name: Cases/Template\n    steps:\n      - method: GET\n        bucket: '{{.bucket'\n",
+		// Invalid capture.
+		"tests:\n  - name: Cases/Capture\n    steps:\n      - method: GET\n        capture:\n          etag: ETag\n",
+	}
+	for i, testCase := range testCases {
+		if _, err := parseDeclarativeTests([]byte(testCase)); err == nil {
+			t.Errorf("Test %d: expected an error", i+1)
+		}
+	}
+}
+
+// TestEvalXPath - the supported subset of XPath.
+func TestEvalXPath(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+  <Name>bucket</Name>
+  <Contents><Key>a</Key></Contents>
+  <Contents><Key>b</Key></Contents>
+</ListBucketResult>`
+	var root xmlNode
+	if err := xmlDecoder(bytes.NewReader([]byte(body)), &root); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		expr     string
+		expected string
+		valid    bool
+	}{
+		{"/ListBucketResult/Name", "bucket", true},
+		{"/ListBucketResult/Contents/Key", "a", true},
+		{"/ListBucketResult/Contents[2]/Key", "b", true},
+		{"count(/ListBucketResult/Contents)", "2", true},
+		{"count(/ListBucketResult/Prefix)", "0", true},
+		{"/ListBucketResult/Prefix", "", false},
+		{"/ListBucketResult/Contents[0]/Key", "", false},
+		{"ListBucketResult/Name", "", false},
+	}
+	for _, testCase := range testCases {
+		value, err := evalXPath(root, testCase.expr)
+		if testCase.valid && err != nil {
+			t.Errorf("%s: unexpected error: %v", testCase.expr, err)
+		}
+		if !testCase.valid && err == nil {
+			t.Errorf("%s: expected an error", testCase.expr)
+		}
+		if value != testCase.expected {
+			t.Errorf("%s: expected %q, got %q", testCase.expr, testCase.expected, value)
+		}
+	}
+}
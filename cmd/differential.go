@@ -0,0 +1,265 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/minio/mc/pkg/console"
+)
+
+// Divergence - a difference between the responses of the tested and the reference server to the same request.
+type Divergence struct {
+	Request   string `json:"request"`   // Method and path of the request, e.g. "GET /bucket/object".
+	Field     string `json:"field"`     // What differs: status, headers or body.
+	Target    string `json:"target"`    // What the tested server sent back.
+	Reference string `json:"reference"` // What the reference server sent back.
+}
+
+// Headers that differ from one response to the next or from one server to the next
+// and are therefore not compared.
+var volatileHeaders = map[string]struct{}{
+	"connection":        struct{}{},
+	"content-length":    struct{}{},
+	"date":              struct{}{},
+	"keep-alive":        struct{}{},
+	"server":            struct{}{},
+	"transfer-encoding": struct{}{},
+	"x-amz-id-2":        struct{}{},
+	"x-amz-request-id":  struct{}{},
+}
+
+// XML elements whose values differ from one response to the next or from one server to
+// the next and are therefore not compared.
+var volatileElements = map[string]struct{}{
+	"ContinuationToken":     struct{}{},
+	"CreationDate":          struct{}{},
+	"ETag":                  struct{}{},
+	"HostId":                struct{}{},
+	"Initiated":             struct{}{},
+	"Initiator":             struct{}{},
+	"LastModified":          struct{}{},
+	"Location":              struct{}{},
+	"NextContinuationToken": struct{}{},
+	"NextUploadIdMarker":    struct{}{},
+	"Owner":                 struct{}{},
+	"RequestId":             struct{}{},
+	"UploadId":              struct{}{},
+	"UploadIdMarker":        struct{}{},
+}
+
+// xmlNode - any XML element, used to compare XML bodies without knowing their type.
+type xmlNode struct {
+	XMLName xml.Name
+	Content string    `xml:",chardata"`
+	Nodes   []xmlNode `xml:",any"`
+}
+
+// flattenXML - list the values of every non volatile element as Path/To/Element=value in document order.
+func flattenXML(prefix string, node xmlNode) []string {
+	if _, ok := volatileElements[node.XMLName.Local]; ok {
+		return nil
+	}
+	path := prefix + node.XMLName.Local
+	if len(node.Nodes) == 0 {
+		return []string{path + "=" + strings.TrimSpace(node.Content)}
+	}
+	lines := []string{}
+	for _, child := range node.Nodes {
+		lines = append(lines, flattenXML(path+"/", child)...)
+	}
+	return lines
+}
+
+// normalizeXML - parse an XML body and flatten it, dropping the volatile elements.
+func normalizeXML(body []byte) ([]string, error) {
+	var root xmlNode
+	if err := xmlDecoder(bytes.NewReader(body), &root); err != nil {
+		return nil, err
+	}
+	return flattenXML("", root), nil
+}
+
+// diffLines - return the lines only found in target and the lines only found in reference.
+func diffLines(target, reference []string) (onlyTarget, onlyReference []string) {
+	remaining := make(map[string]int)
+	for _, line := range reference {
+		remaining[line]++
+	}
+	for _, line := range target {
+		if remaining[line] > 0 {
+			remaining[line]--
+			continue
+		}
+		onlyTarget = append(onlyTarget, line)
+	}
+	for _, line := range reference {
+		if remaining[line] > 0 {
+			remaining[line]--
+			onlyReference = append(onlyReference, line)
+		}
+	}
+	return onlyTarget, onlyReference
+}
+
+// headerNames - the sorted names of the non volatile headers of a response.
+func headerNames(header http.Header) []string {
+	names := []string{}
+	for name := range header {
+		name = strings.ToLower(name)
+		if _, ok := volatileHeaders[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// isXMLBody - check whether a body should be compared as XML.
+func isXMLBody(header http.Header, body []byte) bool {
+	return strings.Contains(header.Get("Content-Type"), "xml") || bytes.HasPrefix(bytes.TrimSpace(body), []byte("<"))
+}
+
+// describeBody - summarize a body that is not XML.
+func describeBody(body []byte) string {
+	return fmt.Sprintf("%d bytes, md5 %x", len(body), md5.Sum(body))
+}
+
+// readBody - read the whole body of a response and put it back so it can be read again.
+func readBody(resp *http.Response) ([]byte, error) {
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// compareResponses - list every difference between the target and reference responses to the same request.
+func compareResponses(request string, target *http.Response, targetBody []byte, reference *http.Response, referenceBody []byte) []Divergence {
+	divergences := []Divergence{}
+	if target.StatusCode != reference.StatusCode {
+		divergences = append(divergences, Divergence{
+			Request:   request,
+			Field:     "status",
+			Target:    strconv.Itoa(target.StatusCode),
+			Reference: strconv.Itoa(reference.StatusCode),
+		})
+	}
+	onlyTarget, onlyReference := diffLines(headerNames(target.Header), headerNames(reference.Header))
+	if len(onlyTarget) > 0 || len(onlyReference) > 0 {
+		divergences = append(divergences, Divergence{
+			Request:   request,
+			Field:     "headers",
+			Target:    strings.Join(onlyTarget, ", "),
+			Reference: strings.Join(onlyReference, ", "),
+		})
+	}
+	if isXMLBody(target.Header, targetBody) && isXMLBody(reference.Header, referenceBody) {
+		targetLines, targetErr := normalizeXML(targetBody)
+		referenceLines, referenceErr := normalizeXML(referenceBody)
+		if targetErr == nil && referenceErr == nil {
+			onlyTarget, onlyReference = diffLines(targetLines, referenceLines)
+			if len(onlyTarget) > 0 || len(onlyReference) > 0 {
+				divergences = append(divergences, Divergence{
+					Request:   request,
+					Field:     "body",
+					Target:    strings.Join(onlyTarget, "\n"),
+					Reference: strings.Join(onlyReference, "\n"),
+				})
+			}
+			return divergences
+		}
+		// Compare whatever could not be parsed byte by byte.
+	}
+	if !bytes.Equal(targetBody, referenceBody) {
+		divergences = append(divergences, Divergence{
+			Request:   request,
+			Field:     "body",
+			Target:    describeBody(targetBody),
+			Reference: describeBody(referenceBody),
+		})
+	}
+	return divergences
+}
+
+// describeRequest - the method and path of a request, used to identify it in divergences.
+func describeRequest(method string, customReq Request) string {
+	path := "/" + customReq.bucketName
+	if customReq.objectName != "" {
+		path += "/" + customReq.objectName
+	}
+	if len(customReq.queryValues) > 0 {
+		path += "?" + customReq.queryValues.Encode()
+	}
+	return method + " " + path
+}
+
+// diffWithReference - send the request to the reference server and record how its response differs from resp.
+// The body of resp is read in full and put back for the test to verify.
+func (c ServerConfig) diffWithReference(method string, referenceReq Request, resp *http.Response) error {
+	targetBody, err := readBody(resp)
+	if err != nil {
+		return err
+	}
+	request := describeRequest(method, referenceReq)
+	if referenceReq.contentBody != nil {
+		if _, ok := referenceReq.contentBody.(io.Seeker); !ok {
+			// The body was consumed by the tested server and cannot be sent again.
+			c.recordDivergences([]Divergence{{Request: request, Field: "request", Target: "sent", Reference: "not sent, the body cannot be replayed"}})
+			return nil
+		}
+	}
+	referenceResp, err := c.reference.doRequest(method, referenceReq)
+	if err != nil {
+		c.recordDivergences([]Divergence{{Request: request, Field: "request", Target: "sent", Reference: err.Error()}})
+		return nil
+	}
+	defer closeResponse(referenceResp)
+	referenceBody, err := ioutil.ReadAll(referenceResp.Body)
+	if err != nil {
+		c.recordDivergences([]Divergence{{Request: request, Field: "body", Target: describeBody(targetBody), Reference: err.Error()}})
+		return nil
+	}
+	c.recordDivergences(compareResponses(request, resp, targetBody, referenceResp, referenceBody))
+	return nil
+}
+
+// recordDivergences - remember the divergences found for the test being run, if any.
+func (c ServerConfig) recordDivergences(divergences []Divergence) {
+	if c.stats != nil {
+		c.stats.addDivergences(divergences)
+	}
+}
+
+// printDivergences - print the differences with the reference server found by a test.
+func printDivergences(divergences []Divergence) {
+	for _, divergence := range divergences {
+		console.Printf("    [DIFF] %s %s:\n      target:    %s\n      reference: %s\n", divergence.Request, divergence.Field,
+			strings.Replace(divergence.Target, "\n", "\n                 ", -1), strings.Replace(divergence.Reference, "\n", "\n                 ", -1))
+	}
+}
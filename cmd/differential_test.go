@@ -0,0 +1,181 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// newDifferentialResponse - a response of the given status with the given headers.
+func newDifferentialResponse(statusCode int, headers map[string]string) *http.Response {
+	res := &http.Response{StatusCode: statusCode, Header: make(http.Header)}
+	for name, value := range headers {
+		res.Header.Set(name, value)
+	}
+	return res
+}
+
+// TestCompareResponsesSame - responses differing only in what differs from one response to the next do not diverge.
+func TestCompareResponsesSame(t *testing.T) {
+	target := newDifferentialResponse(http.StatusOK, map[string]string{"Content-Type": "application/xml", "Date": "Mon, 01 Jan 2001 00:00:00 GMT", "X-Amz-Request-Id": "target"})
+	reference := newDifferentialResponse(http.StatusOK, map[string]string{"Content-Type": "application/xml", "Date": "Tue, 02 Jan 2001 00:00:00 GMT", "X-Amz-Request-Id": "reference"})
+	targetBody := []byte(`<ListAllMyBucketsResult><Buckets><Bucket><Name>s3verify</Name><CreationDate>2001-01-01T00:00:00Z</CreationDate></Bucket></Buckets></ListAllMyBucketsResult>`)
+	referenceBody := []byte(`<?xml version="1.0" encoding="UTF-8"?>
+<ListAllMyBucketsResult><Buckets><Bucket><Name>s3verify</Name><CreationDate>2001-01-02T00:00:00Z</CreationDate></Bucket></Buckets></ListAllMyBucketsResult>`)
+	if divergences := compareResponses("GET /", target, targetBody, reference, referenceBody); len(divergences) != 0 {
+		t.Errorf("Expected no divergences, got %v", divergences)
+	}
+}
+
+// TestCompareResponsesDifferent - the status, headers and bodies in which responses differ are reported.
+func TestCompareResponsesDifferent(t *testing.T) {
+	target := newDifferentialResponse(http.StatusNoContent, map[string]string{"Content-Type": "application/xml", "X-Vendor-Header": "s3verify"})
+	reference := newDifferentialResponse(http.StatusOK, map[string]string{"Content-Type": "application/xml"})
+	targetBody := []byte(`<ListAllMyBucketsResult><Buckets><Bucket><Label>s3verify</Label></Bucket></Buckets></ListAllMyBucketsResult>`)
+	referenceBody := []byte(`<ListAllMyBucketsResult><Buckets><Bucket><Name>s3verify</Name></Bucket></Buckets></ListAllMyBucketsResult>`)
+	expected := map[string]string{
+		"status":  "204",
+		"headers": "x-vendor-header",
+		"body":    "ListAllMyBucketsResult/Buckets/Bucket/Label=",
+	}
+	found := make(map[string]Divergence)
+	for _, divergence := range compareResponses("GET /", target, targetBody, reference, referenceBody) {
+		if divergence.Request != "GET /" {
+			t.Errorf("Expected the divergence to name its request, got %q", divergence.Request)
+		}
+		found[divergence.Field] = divergence
+	}
+	for field, value := range expected {
+		divergence, ok := found[field]
+		if !ok {
+			t.Errorf("Expected a divergence of the %s, got %v", field, found)
+			continue
+		}
+		if !strings.Contains(divergence.Target, value) {
+			t.Errorf("Expected %s in the %s of the target, got %q", value, field, divergence.Target)
+		}
+	}
+}
+
+// TestCompareResponsesNotXML - bodies that are not XML are compared byte by byte.
+func TestCompareResponsesNotXML(t *testing.T) {
+	res := newDifferentialResponse(http.StatusOK, map[string]string{"Content-Type": "application/octet-stream"})
+	if divergences := compareResponses("GET /bucket/object", res, []byte("s3verify"), res, []byte("s3verify")); len(divergences) != 0 {
+		t.Errorf("Expected no divergences, got %v", divergences)
+	}
+	divergences := compareResponses("GET /bucket/object", res, []byte("s3verify"), res, []byte("minio"))
+	if len(divergences) != 1 || divergences[0].Field != "body" {
+		t.Errorf("Expected a divergence of the body, got %v", divergences)
+	}
+}
+
+// runDifferentialSuite - run the selected tests against target, with every request sent to reference as well.
+func runDifferentialSuite(t *testing.T, target, reference http.Handler, selection testSelection) map[string]TestResult {
+	setGlobals(false)
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+	referenceServer := httptest.NewServer(reference)
+	defer referenceServer.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, targetServer.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.reference, err = newServerConfigFor(testAccessKey, testSecretKey, referenceServer.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make(map[string]TestResult)
+	for _, result := range runTests(*config, newQuietRunContext("differential", 1), unpreparedTests, selection) {
+		results[result.Name] = result
+	}
+	return results
+}
+
+// TestDifferentialSameServer - two servers behaving the same way do not diverge.
+func TestDifferentialSameServer(t *testing.T) {
+	selection := testSelection{run: regexp.MustCompile("^(HeadBucket|ListBuckets|HeadObject|GetObject)$")}
+	results := runDifferentialSuite(t, s3mem.New(testAccessKey, testSecretKey, testRegion), s3mem.New(testAccessKey, testSecretKey, testRegion), selection)
+	if len(results) == 0 {
+		t.Fatal("Expected results")
+	}
+	for name, result := range results {
+		if result.Status != TestPass {
+			t.Errorf("%s: expected %s, got %s: %v", name, TestPass, result.Status, result.Err)
+		}
+		if len(result.Divergences) != 0 {
+			t.Errorf("%s: expected no divergences, got %v", name, result.Divergences)
+		}
+	}
+}
+
+// TestDifferentialBrokenServer - the status, headers and bodies in which a broken server differs
+// from the reference server are reported with the tests that received them.
+func TestDifferentialBrokenServer(t *testing.T) {
+	target := brokenServer{
+		next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+		mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+			switch {
+			case r.Method == "HEAD" && !isObjectRequest(r):
+				replaceResponse(rec, http.StatusNoContent)
+				rec.HeaderMap.Set("X-Vendor-Header", "s3verify")
+			case r.Method == "GET" && r.URL.Path == "/":
+				replaceBody(rec, "<Name>", "<Label>")
+				replaceBody(rec, "</Name>", "</Label>")
+			}
+		},
+	}
+	selection := testSelection{run: regexp.MustCompile("^(HeadBucket|ListBuckets)$")}
+	results := runDifferentialSuite(t, target, s3mem.New(testAccessKey, testSecretKey, testRegion), selection)
+	if divergences := results["PutBucket"].Divergences; len(divergences) != 0 {
+		t.Errorf("PutBucket: expected no divergences, got %v", divergences)
+	}
+	expected := map[string]map[string]string{
+		"HeadBucket": {
+			"status":  "204",
+			"headers": "x-vendor-header",
+		},
+		"ListBuckets": {
+			"body": "ListAllMyBucketsResult/Buckets/Bucket/Label=",
+		},
+	}
+	for name, fields := range expected {
+		result := results[name]
+		if result.Status != TestFail {
+			t.Errorf("%s: expected %s, got %s", name, TestFail, result.Status)
+		}
+		found := make(map[string]Divergence)
+		for _, divergence := range result.Divergences {
+			found[divergence.Field] = divergence
+		}
+		for field, target := range fields {
+			divergence, ok := found[field]
+			if !ok {
+				t.Errorf("%s: expected a divergence of the %s, got %v", name, field, result.Divergences)
+				continue
+			}
+			if !strings.Contains(divergence.Target, target) {
+				t.Errorf("%s: expected %s in the %s of the target, got %q", name, target, field, divergence.Target)
+			}
+		}
+	}
+}
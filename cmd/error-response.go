@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"encoding/xml"
+	"fmt"
 	"net/http"
 )
 
@@ -141,3 +142,18 @@ func ErrInvalidArgument(message string) error {
 		RequestID: "minio",
 	}
 }
+
+// verifyErrorCode - verify that the server rejected a request with the expected status and error code.
+func verifyErrorCode(res *http.Response, expectedStatusCode int, expectedCode string) error {
+	if res.StatusCode != expectedStatusCode {
+		return fmt.Errorf("Unexpected Response Status Code: wanted %v, got %v", expectedStatusCode, res.StatusCode)
+	}
+	errResp := ErrorResponse{}
+	if err := xmlDecoder(res.Body, &errResp); err != nil {
+		return err
+	}
+	if errResp.Code != expectedCode {
+		return fmt.Errorf("Unexpected Error Code Received: wanted %s, got %s", expectedCode, errResp.Code)
+	}
+	return nil
+}
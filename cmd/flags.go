@@ -32,6 +32,18 @@ var globalFlags = []cli.Flag{
 		// Allow env. variables to be used as well as flags.
 		EnvVar: "S3_SECRET",
 	},
+	cli.StringFlag{
+		Name:  "aws-profile",
+		Usage: "Use the credentials of this profile of ~/.aws/credentials or ~/.aws/config",
+	},
+	cli.StringFlag{
+		Name:  "expired-aws-profile",
+		Usage: "Test that the expired temporary credentials of this AWS profile are rejected",
+	},
+	cli.StringFlag{
+		Name:  "mc-alias",
+		Usage: "Use the credentials, and unless --url is set the URL, of this alias of ~/.mc/config.json",
+	},
 	cli.StringFlag{
 		Name:  "region, r",
 		Usage: `Set AWS S3 region`,
@@ -44,6 +56,35 @@ var globalFlags = []cli.Flag{
 		Value:  "https://s3.amazonaws.com",
 		EnvVar: "S3_URL",
 	},
+	cli.StringFlag{
+		Name:   "reference-url",
+		Usage:  "URL to a trusted S3 server, every request is sent to it as well and any difference in the responses is reported",
+		EnvVar: "S3_REFERENCE_URL",
+	},
+	cli.StringFlag{
+		Name:   "reference-access",
+		Usage:  "Set the access key of the reference server",
+		EnvVar: "S3_REFERENCE_ACCESS",
+	},
+	cli.StringFlag{
+		Name:   "reference-secret",
+		Usage:  "Set the secret key of the reference server",
+		EnvVar: "S3_REFERENCE_SECRET",
+	},
+	cli.StringFlag{
+		Name:   "reference-region",
+		Usage:  "Set the region of the reference server",
+		EnvVar: "S3_REFERENCE_REGION",
+	},
+	cli.StringFlag{
+		Name:   "config",
+		Usage:  "Load the targets from this YAML file instead of ~/.s3verify/config.yaml",
+		EnvVar: "S3VERIFY_CONFIG",
+	},
+	cli.StringSliceFlag{
+		Name:  "target",
+		Usage: "Test this target of the config file instead of --url, repeat to test several targets",
+	},
 	cli.BoolFlag{
 		Name:  "verbose, v",
 		Usage: "Enable verbose output",
@@ -52,6 +93,62 @@ var globalFlags = []cli.Flag{
 		Name:  "extended",
 		Usage: "Enable testing of extra S3 APIs",
 	},
+	cli.StringFlag{
+		Name:  "run",
+		Usage: "Only run tests whose name matches this regular expression, prerequisite tests are run automatically",
+	},
+	cli.StringFlag{
+		Name:  "skip",
+		Usage: "Do not run tests whose name matches this regular expression",
+	},
+	cli.StringFlag{
+		Name:  "cases",
+		Usage: "Run the declarative tests of this YAML file, or of every YAML file of this directory, as well",
+	},
+	cli.StringFlag{
+		Name:  "signature",
+		Usage: "Run the tests of header, presigned and POST policy requests again signed with this signature version as well, v2, v4a or v4",
+		Value: "v4",
+	},
+	cli.StringFlag{
+		Name:  "lookup",
+		Usage: "Address buckets in virtual-hosted style (bucket.host/object), path style (host/bucket/object) or auto, which uses virtual-hosted style for AWS S3 only",
+		Value: "path",
+	},
+	cli.IntFlag{
+		Name:  "parallel",
+		Usage: "Run up to this many independent tests, and object uploads, at the same time",
+		Value: 1,
+	},
+	cli.BoolFlag{
+		Name:  "list",
+		Usage: "List the names of the selected tests without running them",
+	},
+	cli.StringFlag{
+		Name:  "format",
+		Usage: "Print the test results as text or json",
+		Value: "text",
+	},
+	cli.StringFlag{
+		Name:  "profile",
+		Usage: "Load the expected failures, skipped APIs and tolerated headers of the server from this JSON file",
+	},
+	cli.StringFlag{
+		Name:  "baseline",
+		Usage: "Compare the results with those of a previous --format json run and only fail on new failures",
+	},
+	cli.StringFlag{
+		Name:  "report-junit",
+		Usage: "Write the test results to this file as JUnit XML",
+	},
+	cli.StringFlag{
+		Name:  "record",
+		Usage: "Record every HTTP exchange with the server to this directory, credentials are redacted",
+	},
+	cli.StringFlag{
+		Name:  "replay",
+		Usage: "Run the tests offline against the HTTP exchanges recorded to this directory with --record",
+	},
 	cli.StringFlag{
 		Name:  "reuse",
 		Usage: `Prepare or reuse a testing environment`,
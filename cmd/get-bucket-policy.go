@@ -114,114 +114,101 @@ func verifyBodyGetBucketPolicy(resBody io.Reader, expectedPolicy BucketAccessPol
 }
 
 // mainGetBucketPolicy - Entry point for the get-bucket-policy test.
-func mainGetBucketPolicy(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] GetBucketPolicy:", curTest, globalTotalNumTest)
+func mainGetBucketPolicy(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetBucketPolicy:", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// Test missing bucket
 	expectedError := ErrorResponse{
 		Message: "The bucket policy does not exist",
 		Code:    "NoSuchBucketPolicy",
 	}
-	bucketName := s3verifyBuckets[3].Name
+	bucketName := run.buckets[3].Name
 	// Create a new request.
 	req, err := newGetBucketPolicyReq(bucketName)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the request.
 	res, err := config.execRequest("GET", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Verify the response.
 	if err := getBucketPolicyVerify(res, http.StatusNotFound, BucketAccessPolicy{}, expectedError); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 
 	// Test readwrite policy is set.
-	bucketName = s3verifyBuckets[0].Name
+	bucketName = run.buckets[0].Name
 	// Create a new request.
 	readWriteReq, err := newGetBucketPolicyReq(bucketName)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	readWriteRes, err := config.execRequest("GET", readWriteReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
-	if err := getBucketPolicyVerify(readWriteRes, http.StatusOK, s3verifyPolicies[0], ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+	if err := getBucketPolicyVerify(readWriteRes, http.StatusOK, run.policies[0], ErrorResponse{}); err != nil {
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// Test readonly policy is set.
-	bucketName = s3verifyBuckets[1].Name
+	bucketName = run.buckets[1].Name
 	// Create a new request.
 	readOnlyReq, err := newGetBucketPolicyReq(bucketName)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	readOnlyRes, err := config.execRequest("GET", readOnlyReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
-	if err := getBucketPolicyVerify(readOnlyRes, http.StatusOK, s3verifyPolicies[1], ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+	if err := getBucketPolicyVerify(readOnlyRes, http.StatusOK, run.policies[1], ErrorResponse{}); err != nil {
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// Test writeonly policy is set.
-	bucketName = s3verifyBuckets[2].Name
+	bucketName = run.buckets[2].Name
 	// Create a new request.
 	writeOnlyReq, err := newGetBucketPolicyReq(bucketName)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	writeOnlyRes, err := config.execRequest("GET", writeOnlyReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
-	if err := getBucketPolicyVerify(writeOnlyRes, http.StatusOK, s3verifyPolicies[2], ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+	if err := getBucketPolicyVerify(writeOnlyRes, http.StatusOK, run.policies[2], ErrorResponse{}); err != nil {
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 
 }
@@ -109,65 +109,58 @@ func verifyStatusGetObjectIfMatch(respStatusCode, expectedStatusCode int) error
 }
 
 // Test the compatibility of the GET object API when using the If-Match header.
-func mainGetObjectIfMatch(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] GetObject (If-Match):", curTest, globalTotalNumTest)
+func mainGetObjectIfMatch(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (If-Match):", curTest, run.totalNumTest)
 	// Set up an invalid ETag to test failed requests responses.
 	invalidETag := "1234567890"
 	// All getobject tests happen in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	for _, object := range s3verifyObjects {
+	bucketName := run.buckets[0].Name
+	for _, object := range run.headedObjects() {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create new GET object If-Match request.
 		req, err := newGetObjectIfMatchReq(bucketName, object.Key, object.ETag)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Execute the request.
 		res, err := config.execRequest("GET", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		defer closeResponse(res)
 		// Verify the response...these checks do not check the headers yet.
 		if err := getObjectIfMatchVerify(res, object.Body, http.StatusOK, false); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create a bad GET object If-Match request.
 		badReq, err := newGetObjectIfMatchReq(bucketName, object.Key, invalidETag)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Execute the request.
 		badRes, err := config.execRequest("GET", badReq)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		defer closeResponse(badRes)
 		// Verify the request fails as expected.
 		if err := getObjectIfMatchVerify(badRes, []byte(""), http.StatusPreconditionFailed, true); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 	}
 	// Spin scanBar
-	scanBar(message)
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return newTestResult(message, nil)
 }
@@ -95,62 +95,54 @@ func verifyHeaderGetObjectIfModifiedSince(header http.Header) error {
 }
 
 // Test the compatibility of the GET object API when using the If-Modified-Since header.
-func mainGetObjectIfModifiedSince(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] GetObject (If-Modified-Since):", curTest, globalTotalNumTest)
+func mainGetObjectIfModifiedSince(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (If-Modified-Since):", curTest, run.totalNumTest)
 	// Set a date in the past.
 	pastDate, err := time.Parse(http.TimeFormat, "Thu, 01 Jan 1970 00:00:00 GMT")
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// All getobject if-modified-since tests happen in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	for _, object := range s3verifyObjects {
+	bucketName := run.buckets[0].Name
+	for _, object := range run.headedObjects() {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create new GET object request.
 		req, err := newGetObjectIfModifiedSinceReq(bucketName, object.Key, object.LastModified)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Perform the request.
 		res, err := config.execRequest("GET", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Verify the response...these checks do not check the headers yet.
 		if err := verifyGetObjectIfModifiedSince(res, []byte(""), http.StatusNotModified); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Create an acceptable request.
 		goodReq, err := newGetObjectIfModifiedSinceReq(bucketName, object.Key, pastDate)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the response that should give back a body.
 		goodRes, err := config.execRequest("GET", goodReq)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(goodRes)
 		// Verify that the past date gives back the data.
 		if err := verifyGetObjectIfModifiedSince(goodRes, object.Body, http.StatusOK); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
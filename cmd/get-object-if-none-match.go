@@ -95,60 +95,53 @@ func verifyBodyGetObjectIfNoneMatch(resBody io.Reader, expectedBody []byte) erro
 }
 
 // Test the compatibility of the GetObject API when using the If-None-Match header.
-func mainGetObjectIfNoneMatch(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] GetObject (If-None-Match):", curTest, globalTotalNumTest)
+func mainGetObjectIfNoneMatch(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (If-None-Match):", curTest, run.totalNumTest)
 	// Set up an invalid ETag to test failed requests responses.
 	invalidETag := "1234567890"
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// All getobject if-none-match tests are run in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	for _, object := range s3verifyObjects {
+	bucketName := run.buckets[0].Name
+	for _, object := range run.headedObjects() {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create new GET object If-None-Match request.
 		req, err := newGetObjectIfNoneMatchReq(bucketName, object.Key, object.ETag)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the request.
 		res, err := config.execRequest("GET", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Verify the response...these checks do not check the headers yet.
 		if err := getObjectIfNoneMatchVerify(res, []byte(""), http.StatusNotModified); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Create a bad GET object If-None-Match request with invalid ETag.
 		badReq, err := newGetObjectIfNoneMatchReq(bucketName, object.Key, invalidETag)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the request.
 		badRes, err := config.execRequest("GET", badReq)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(badRes)
 		// Verify the response returns the object since ETag != invalidETag
 		if err := getObjectIfNoneMatchVerify(badRes, object.Body, http.StatusOK); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
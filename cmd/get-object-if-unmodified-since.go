@@ -111,62 +111,54 @@ func verifyHeaderGetObjectIfUnModifiedSince(header http.Header) error {
 }
 
 // Test the GET object API with the If-Unmodified-Since header set.
-func mainGetObjectIfUnModifiedSince(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] GetObject (If-Unmodified-Since):", curTest, globalTotalNumTest)
+func mainGetObjectIfUnModifiedSince(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (If-Unmodified-Since):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Set up past date.
 	pastDate, err := time.Parse(http.TimeFormat, "Thu, 01 Jan 1970 00:00:00 GMT")
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// All getobject if-unmodified-since tests run in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	for _, object := range s3verifyObjects {
+	bucketName := run.buckets[0].Name
+	for _, object := range run.headedObjects() {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Form a request with a pastDate to make sure the object is not returned.
 		req, err := newGetObjectIfUnModifiedSinceReq(bucketName, object.Key, pastDate)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the request.
 		res, err := config.execRequest("GET", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Verify that the response returns an error.
 		if err := verifyGetObjectIfUnModifiedSince(res, []byte(""), http.StatusPreconditionFailed, true); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Form a request with a date in the past.
 		goodReq, err := newGetObjectIfUnModifiedSinceReq(bucketName, object.Key, object.LastModified)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute current request.
 		goodRes, err := config.execRequest("GET", goodReq)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(goodRes)
 		// Verify that the lastModified date in a request returns the object.
 		if err := verifyGetObjectIfUnModifiedSince(goodRes, object.Body, http.StatusOK, false); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
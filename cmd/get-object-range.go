@@ -23,7 +23,6 @@ import (
 	"math/rand"
 	"net/http"
 	"strconv"
-	"time"
 )
 
 // newGetObjectRangeReq - Create a new GET object range request.
@@ -51,42 +50,39 @@ func newGetObjectRangeReq(bucketName, objectName, startRange, endRange string) (
 }
 
 // Test a GET object request with a range header set.
-func mainGetObjectRange(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] GetObject (Range):", curTest, globalTotalNumTest)
+func mainGetObjectRange(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (Range):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
-	rand.Seed(time.Now().UnixNano())
+	run.scanBar(message)
+	random := rand.New(run.newRandSource())
 	// All getobject tests happen in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	testObject := s3verifyObjects[0]
+	bucketName := run.buckets[0].Name
+	testObject := run.objects[0]
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// Test a random range.
-	startRange := rand.Int63n(testObject.Size)
-	endRange := rand.Int63n(int64(testObject.Size-startRange)) + startRange
+	startRange := random.Int63n(testObject.Size)
+	endRange := random.Int63n(int64(testObject.Size-startRange)) + startRange
 	// Create new GET object range request...testing range.
 	req, err := newGetObjectRangeReq(bucketName, testObject.Key, strconv.FormatInt(startRange, 10), strconv.FormatInt(endRange, 10))
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the request.
 	res, err := config.execRequest("GET", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	bufRange := testObject.Body[startRange : endRange+1]
 	// Verify the response...these checks do not check the headers yet.
 	if err := getObjectVerify(res, bufRange, http.StatusPartialContent, nil, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// Test an openended range. Expecting whole object back.
 	startOpenRange := ""
@@ -94,19 +90,16 @@ func mainGetObjectRange(config ServerConfig, curTest int) bool {
 	// Create a new open range req.
 	openRangeReq, err := newGetObjectRangeReq(bucketName, testObject.Key, startOpenRange, endOpenRange)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the open request.
 	openRangeRes, err := config.execRequest("GET", openRangeReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Verify that the request failed as expected.
 	if err := getObjectVerify(openRangeRes, testObject.Body, http.StatusPartialContent, nil, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Test a negative range request. Should give back whole object.
 	startNegativeRange := "-5"
@@ -115,19 +108,16 @@ func mainGetObjectRange(config ServerConfig, curTest int) bool {
 	// Creatge a new negative range req.
 	negativeRangeReq, err := newGetObjectRangeReq(bucketName, testObject.Key, startNegativeRange, endNegativeRange)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the negative request.
 	negativeRangeRes, err := config.execRequest("GET", negativeRangeReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Verify that the request failed as expected.
 	if err := getObjectVerify(negativeRangeRes, testObject.Body, http.StatusOK, nil, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 
 	// Test an unended range. Expecting full object.
@@ -137,24 +127,20 @@ func mainGetObjectRange(config ServerConfig, curTest int) bool {
 	// Creatge a new UnEnded range req.
 	unEndedRangeReq, err := newGetObjectRangeReq(bucketName, testObject.Key, startUnEndedRange, endUnEndedRange)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the UnEnded request.
 	unEndedRangeRes, err := config.execRequest("GET", unEndedRangeReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Verify that the request failed as expected.
 	if err := getObjectVerify(unEndedRangeRes, testObject.Body, http.StatusPartialContent, nil, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
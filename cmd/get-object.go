@@ -145,9 +145,26 @@ func verifyStatusGetObject(respStatusCode, expectedStatusCode int) error {
 	return nil
 }
 
+// getObjectCheck - get objectName from bucketName and verify the response.
+func getObjectCheck(config ServerConfig, bucketName, objectName string, reqHeaders map[string]string, expectedBody []byte, expectedStatusCode int, expectedError ErrorResponse) error {
+	// Create a new request.
+	req, err := newGetObjectReq(bucketName, objectName, reqHeaders)
+	if err != nil {
+		return err
+	}
+	// Execute the request.
+	res, err := config.execRequest("GET", req)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+	// Verify the response.
+	return getObjectVerify(res, expectedBody, expectedStatusCode, reqHeaders, expectedError)
+}
+
 // mainGetObject - test a get object request.
-func mainGetObject(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] GetObject:", curTest, globalTotalNumTest)
+func mainGetObject(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject:", curTest, run.totalNumTest)
 	// Use the bucket created in the mainPutBucketPrepared Test.
 	// Set the response headers to be overwritten.
 	expectedHeaders := map[string]string{
@@ -160,63 +177,32 @@ func mainGetObject(config ServerConfig, curTest int) bool {
 	}
 	// All getobject tests happen in s3verify created buckets
 	// on s3verify objects.
-	bucketName := s3verifyBuckets[0].Name
-	testObject := s3verifyObjects[0]
+	bucketName := run.buckets[0].Name
+	testObject := run.objects[0]
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
-	// Create new valid GET object request.
-	req, err := newGetObjectReq(bucketName, testObject.Key, expectedHeaders)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Execute the request.
-	res, err := config.execRequest("GET", req)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(res)
-	// Verify the response.
-	if err := getObjectVerify(res, testObject.Body, http.StatusOK, expectedHeaders, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
-	}
+	result := newTestResult(message, nil)
+	// Test a valid GET object request.
+	result.addCheck(config, "Valid", getObjectCheck(config, bucketName, testObject.Key, expectedHeaders, testObject.Body, http.StatusOK, ErrorResponse{}))
+	// Spin scanBar
+	run.scanBar(message)
 
 	// Test getobject on an object that DNE.
 	invalidKeyError := ErrorResponse{
 		Code:    "NoSuchKey",
 		Message: "The specified key does not exist.",
 	}
-	invalidKeyReq, err := newGetObjectReq(bucketName, testObject.Key+"-DNE", nil)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Execute the request.
-	invalidKeyRes, err := config.execRequest("GET", invalidKeyReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(invalidKeyRes)
-	// Verify the request failed.
-	if err := getObjectVerify(invalidKeyRes, []byte{}, http.StatusNotFound, nil, invalidKeyError); err != nil {
-		printMessage(message, err)
-		return false
-	}
+	result.addCheck(config, "KeyDNE", getObjectCheck(config, bucketName, testObject.Key+"-DNE", nil, []byte{}, http.StatusNotFound, invalidKeyError))
 
 	// Spin scanBar
-	scanBar(message)
-	// Test passed.
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return result
 }
 
 // mainGetObjectMultipart - test a get object request of a object uploaded via multipart operation
-func mainGetObjectMultipart(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] GetObject (Multipart):", curTest, globalTotalNumTest)
+func mainGetObjectMultipart(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (Multipart):", curTest, run.totalNumTest)
 	// Use the bucket created in the mainPutBucketPrepared Test.
 	// Set the response headers to be overwritten.
 	expectedHeaders := map[string]string{
@@ -229,40 +215,36 @@ func mainGetObjectMultipart(config ServerConfig, curTest int) bool {
 	}
 	// All getobject tests happen in s3verify created buckets
 	// on s3verify objects.
-	bucketName := s3verifyBuckets[0].Name
-	testObject := multipartObjects[0]
+	bucketName := run.buckets[0].Name
+	testObject := run.multipartObjects[0]
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// Create new valid GET object request.
 	req, err := newGetObjectReq(bucketName, testObject.Key, expectedHeaders)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the request.
 	res, err := config.execRequest("GET", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 
 	// Join parts to check the uploaded data
 	var data []byte
-	for _, obj := range objectParts[0] {
+	for _, obj := range run.objectParts[0] {
 		data = append(data, obj.Data...)
 	}
 
 	// Verify the response.
 	if err := getObjectVerify(res, data, http.StatusOK, expectedHeaders, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
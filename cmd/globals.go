@@ -17,9 +17,10 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"math/rand"
 	"sync"
-	"time"
 
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/console"
@@ -28,9 +29,6 @@ import (
 var (
 	globalVerbose       bool          // Used to decide whether or not http traces will be printed.
 	globalDefaultRegion = "us-east-1" // Default all aws requests to us-east-1 unless told otherwise.
-	globalTotalNumTest  int           // The total number of tests being run.
-	globalRandom        *rand.Rand    // A global random seed used by retry code.
-	globalSuffix        string        // The suffix to append to all s3verify created objects and buckets.
 )
 
 const (
@@ -62,38 +60,52 @@ func (r *lockedRandSource) Seed(seed int64) {
 }
 
 // Separate out context.
-func setGlobals(verbose bool, numTests int, suffix string) {
-	globalTotalNumTest = numTests
+func setGlobals(verbose bool) {
 	globalVerbose = verbose
 	if globalVerbose {
 		// Allow printing of traces.
 		console.DebugPrint = true
 	}
-	globalRandom = rand.New(&lockedRandSource{src: rand.NewSource(time.Now().UTC().UnixNano())})
-	globalSuffix = suffix
 }
 
 // Set any global flags here.
 func setGlobalsFromContext(ctx *cli.Context) error {
 	verbose := ctx.Bool("verbose") || ctx.GlobalBool("verbose")
-	numTests := 0
-	// Calculate the total number of tests being run.
-	if ctx.Bool("extended") || ctx.GlobalBool("extended") {
-		numTests = len(unpreparedTests)
-	} else {
-		// The length of unpreparedTests == preparedTests.
-		for _, test := range unpreparedTests {
-			if !test.Extended {
-				numTests++
+	// A replayed run must generate the same names and data as the recorded one.
+	if ctx.GlobalString("replay") != "" && (ctx.GlobalString("record") != "" || ctx.GlobalString("reuse") != "") {
+		return errors.New("--replay cannot be combined with --record or --reuse.")
+	}
+	// Prepared environments are created with minio-go which cannot be recorded.
+	if ctx.GlobalString("record") != "" && ctx.GlobalString("reuse") != "" {
+		return errors.New("--record cannot be combined with --reuse.")
+	}
+	if ctx.GlobalInt("parallel") < 1 {
+		return errors.New("--parallel must be at least 1.")
+	}
+	// Concurrent requests are sent in no particular order, so they can not be replayed.
+	if ctx.GlobalInt("parallel") > 1 && (ctx.GlobalString("record") != "" || ctx.GlobalString("replay") != "") {
+		return errors.New("--parallel cannot be combined with --record or --replay.")
+	}
+	// Targets are tested one after the other, each with a fresh run rather than a prepared environment.
+	if len(ctx.GlobalStringSlice("target")) > 0 {
+		for _, name := range []string{"reuse", "clean"} {
+			if ctx.GlobalString(name) != "" {
+				return fmt.Errorf("--target cannot be combined with --%s.", name)
 			}
 		}
 	}
-	// Standard suffix.
-	suffix := "tmp-bkt"
-	if ctx.GlobalString("reuse") != "" {
-		suffix = ctx.GlobalString("reuse")
+	setGlobals(verbose)
+	switch format := ctx.GlobalString("format"); format {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("Unknown --format %q, must be one of text or json.", format)
 	}
-	setGlobals(verbose, numTests, suffix)
 
 	return nil
 }
+
+// isQuiet - check whether progress output must be suppressed. Only the results may be written to
+// stdout when they are meant for other programs.
+func isQuiet(ctx *cli.Context) bool {
+	return ctx.GlobalString("format") == "json"
+}
@@ -93,30 +93,26 @@ func verifyStatusHeadBucket(respStatusCode, expectedStatusCode int) error {
 }
 
 // mainHeadBucket - test the HeadBucket API.
-func mainHeadBucket(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] HeadBucket:", curTest, globalTotalNumTest)
+func mainHeadBucket(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] HeadBucket:", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
-	bucketName := s3verifyBuckets[0].Name
+	run.scanBar(message)
+	bucketName := run.buckets[0].Name
 	// Create a new HeadBucket request.
 	req, err := newHeadBucketReq(bucketName)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the request.
 	res, err := config.execRequest("HEAD", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Verify the response.
 	if err := headBucketVerify(res, http.StatusOK); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -94,64 +94,57 @@ func verifyHeaderHeadObjectIfMatch(header http.Header) error {
 }
 
 // mainHeadObjectIfMatch - tests the HeadObject API with the If-Match header set.
-func mainHeadObjectIfMatch(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] HeadObject (If-Match):", curTest, globalTotalNumTest)
+func mainHeadObjectIfMatch(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] HeadObject (If-Match):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a bad ETag.
 	invalidETag := "1234567890"
 	// All headObject if-match tests are run in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	object := s3verifyObjects[0]
+	bucketName := run.buckets[0].Name
+	object := run.objects[0]
 	// Create a new valid request for HEAD object with if-match header set.
 	req, err := newHeadObjectIfMatchReq(bucketName, object.Key, object.ETag)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("HEAD", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
 	if err := headObjectIfMatchVerify(res, http.StatusOK); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new invalid request for HEAD object with if-match header set.
 	badReq, err := newHeadObjectIfMatchReq(bucketName, object.Key, invalidETag)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the invalid request.
 	badRes, err := config.execRequest("HEAD", badReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(badRes)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the request sends back the right error.
 	if err := headObjectIfMatchVerify(badRes, http.StatusPreconditionFailed); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return newTestResult(message, nil)
 }
@@ -95,66 +95,58 @@ func verifyHeaderHeadObjectIfModifiedSince(header http.Header) error {
 }
 
 // mainHeadObjectIfModifiedSince - test the HeadObject with the If-Modified-Since.
-func mainHeadObjectIfModifiedSince(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] HeadObject (If-Modified-Since):", curTest, globalTotalNumTest)
+func mainHeadObjectIfModifiedSince(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] HeadObject (If-Modified-Since):", curTest, run.totalNumTest)
 	lastModified, err := time.Parse(http.TimeFormat, "Thu, 01 Jan 1970 00:00:00 GMT")
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// All headobject if-modified-since tests happen in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	object := s3verifyObjects[0]
+	bucketName := run.buckets[0].Name
+	object := run.objects[0]
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new request.
 	req, err := newHeadObjectIfModifiedSinceReq(bucketName, object.Key, lastModified)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("HEAD", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
 	if err := headObjectIfModifiedSinceVerify(res, http.StatusOK); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a bad request.
 	badReq, err := newHeadObjectIfModifiedSinceReq(bucketName, object.Key, object.LastModified.Add(time.Hour*2))
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the bad request.
 	badRes, err := config.execRequest("HEAD", badReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(badRes)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the bad request failed as expected.
 	if err := headObjectIfModifiedSinceVerify(badRes, 304); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
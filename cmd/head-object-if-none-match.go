@@ -95,64 +95,57 @@ func verifyHeaderHeadObjectIfNoneMatch(header http.Header) error {
 }
 
 // mainHeadObjectIfNoneMatch - tests the HEAD object with if-none-match header set.
-func mainHeadObjectIfNoneMatch(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] HeadObject (If-None-Match):", curTest, globalTotalNumTest)
+func mainHeadObjectIfNoneMatch(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] HeadObject (If-None-Match):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create an ETag that won't match any already created.
 	validETag := "1234567890"
 	// All headobject if-none-match tests happen in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	object := s3verifyObjects[0]
+	bucketName := run.buckets[0].Name
+	object := run.objects[0]
 	// Create a new request for a HEAD object with if-none-match header set.
 	req, err := newHeadObjectIfNoneMatchReq(bucketName, object.Key, validETag)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("HEAD", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
 	if err := headObjectIfNoneMatchVerify(res, http.StatusOK); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new invalid request for a HEAD object with if-none-match header set.
 	badReq, err := newHeadObjectIfNoneMatchReq(bucketName, object.Key, object.ETag)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	badRes, err := config.execRequest("HEAD", badReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(badRes)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
 	if err := headObjectIfNoneMatchVerify(badRes, 304); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return newTestResult(message, nil)
 }
@@ -95,68 +95,60 @@ func verifyHeaderHeadObjectIfUnModifiedSince(header http.Header) error {
 }
 
 // mainHeadObjectIfUnModifiedSince - HEAD object with if-unmodified-since header set test.
-func mainHeadObjectIfUnModifiedSince(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] HeadObject (If-Unmodified-Since):", curTest, globalTotalNumTest)
-	scanBar(message)
+func mainHeadObjectIfUnModifiedSince(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] HeadObject (If-Unmodified-Since):", curTest, run.totalNumTest)
+	run.scanBar(message)
 	// Create a date in the past to use.
 	lastModified, err := time.Parse(http.TimeFormat, "Thu, 01 Jan 1970 00:00:00 GMT")
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// All headobject if-unmodified-since tests happen in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	object := s3verifyObjects[0]
+	bucketName := run.buckets[0].Name
+	object := run.objects[0]
 	// Create a new request.
 	req, err := newHeadObjectIfUnModifiedSinceReq(bucketName, object.Key, object.LastModified)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Perform the request.
 	res, err := config.execRequest("HEAD", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the request succeeds as expected.
 	if err := headObjectIfUnModifiedSinceVerify(res, http.StatusOK); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a bad request.
 	badReq, err := newHeadObjectIfUnModifiedSinceReq(bucketName, object.Key, lastModified)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Perform the bad request.
 	badRes, err := config.execRequest("HEAD", badReq)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(badRes)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response failed.
 	if err := headObjectIfUnModifiedSinceVerify(badRes, http.StatusPreconditionFailed); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
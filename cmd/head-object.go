@@ -97,64 +97,76 @@ func verifyHeaderHeadObject(header http.Header) error {
 }
 
 // mainHeadObject - test the HeadObject API with no header set.
-func mainHeadObject(config ServerConfig, curTest int, testObjects []*ObjectInfo, bucketName string) bool {
-	message := fmt.Sprintf("[%02d/%d] HeadObject:", curTest, globalTotalNumTest)
+func mainHeadObject(config ServerConfig, run *RunContext, curTest int, testObjects []*ObjectInfo, bucketName string) TestResult {
+	message := fmt.Sprintf("[%02d/%d] HeadObject:", curTest, run.totalNumTest)
 	// All headobject tests are run in s3verify buckets on s3verify created objects.
 	for _, object := range testObjects {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create a new HEAD object with no headers.
 		req, err := newHeadObjectReq(bucketName, object.Key)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the request.
 		res, err := config.execRequest("HEAD", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Verify the response.
 		if err := headObjectVerify(res, http.StatusOK); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// If the verification is valid then set the ETag, Size, and LastModified.
 		// No need to canonicalize ETags because they will come back uncanonicalized every time.
 		eTag := res.Header.Get("ETag")
 		date, err := time.Parse(http.TimeFormat, res.Header.Get("Last-Modified")) // This will never error out because it has already been verified.
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		size, err := strconv.ParseInt(res.Header.Get("Content-Length"), 10, 64)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		object.Size = size
 		object.ETag = eTag
 		object.LastModified = date
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
 
 // mainHeadObjectUnPrepared - Test for HeadObject API when the environment was not previously created.
-func mainHeadObjectUnPrepared(config ServerConfig, curTest int) bool {
-	bucketName := s3verifyBuckets[0].Name
-	testObjects := s3verifyObjects
-	return mainHeadObject(config, curTest, testObjects, bucketName)
+func mainHeadObjectUnPrepared(config ServerConfig, run *RunContext, curTest int) TestResult {
+	bucketName := run.buckets[0].Name
+	testObjects := run.objects
+	return mainHeadObject(config, run, curTest, testObjects, bucketName)
 }
 
-// mainHeadObjectPrepared - Test for HeadObject API when the environment was prepared.
-func mainHeadObjectPrepared(config ServerConfig, curTest int) bool {
-	bucketName := preparedBuckets[0].Name
-	testObjects := preparedObjects
-	return mainHeadObject(config, curTest, testObjects, bucketName)
+// mainHeadObjectPrepared - Test for HeadObject API when the environment was prepared, on the prepared
+// objects and on the object uploaded by PutObject whose metadata the conditional tests compare against.
+func mainHeadObjectPrepared(config ServerConfig, run *RunContext, curTest int) TestResult {
+	bucketName := run.preparedBuckets[0].Name
+	testObjects := run.preparedObjects
+	if result := mainHeadObject(config, run, curTest, testObjects, bucketName); result.Status != TestPass {
+		return result
+	}
+	return mainHeadObject(config, run, curTest, run.objects, run.buckets[0].Name)
+}
+
+// headedObjects - the s3verify created objects whose ETag and Last-Modified
+// were stored by the HeadObject test. Objects uploaded after it ran, such as
+// the streaming, presigned and POST uploads, have no metadata to compare
+// against in conditional requests.
+func (run *RunContext) headedObjects() []*ObjectInfo {
+	objects := []*ObjectInfo{}
+	for _, object := range run.objects {
+		if object.ETag != "" {
+			objects = append(objects, object)
+		}
+	}
+	return objects
 }
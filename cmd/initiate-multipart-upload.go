@@ -25,23 +25,6 @@ import (
 	"net/url"
 )
 
-// Holds all the objects to be uploaded by a multipart request.
-var multipartObjects = []*ObjectInfo{
-	// An object that will have more than 5MB of data to be uploaded as part of a multipart upload.
-	&ObjectInfo{
-		Key:         "s3verify/multipart/object",
-		ContentType: "application/octet-stream",
-		// Body: to be set dynamically,
-		// UploadID: to be set dynamically,
-	},
-	&ObjectInfo{
-		Key:         "s3verify/multipart/abort",
-		ContentType: "application/octet-stream",
-		// Body: to be set dynamically,
-		// UploadID: to be set dynamically,
-	},
-}
-
 // newInitiateMultipartUploadReq - Create a new HTTP request for the initiate-multipart-upload API.
 func newInitiateMultipartUploadReq(bucketName, objectName string) (Request, error) {
 	// Initialize url queries.
@@ -116,45 +99,41 @@ func verifyHeaderInitiateMultipartUpload(header http.Header) error {
 }
 
 // mainInitiateMultipartUpload - initiate multipart upload test.
-func mainInitiateMultipartUpload(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] Multipart (Initiate-Upload):", curTest, globalTotalNumTest)
+func mainInitiateMultipartUpload(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] Multipart (Initiate-Upload):", curTest, run.totalNumTest)
 	// Spin scanBar.
-	scanBar(message)
+	run.scanBar(message)
 	// All initiate-multipart tests happen in s3verify created buckets.
-	bucketName := s3verifyBuckets[0].Name
+	bucketName := run.buckets[0].Name
 	// Get the bucket to upload to and the objectName to call the new upload.
-	for _, object := range multipartObjects {
+	for _, object := range run.multipartObjects {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create a new InitiateMultiPartUpload request.
 		req, err := newInitiateMultipartUploadReq(bucketName, object.Key)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the request.
 		res, err := config.execRequest("POST", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Verify the response and get the uploadID.
 		uploadID, err := initiateMultipartUploadVerify(res, http.StatusOK)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Set the uploadId of the uploaded object.
 		object.UploadID = uploadID
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -0,0 +1,116 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// jsonReport - the results of all tests run against a server as printed by --format json.
+type jsonReport struct {
+	Version       string           `json:"version"`           // s3verify version.
+	Target        string           `json:"target,omitempty"`  // Name of the target in the config file, if --target was used.
+	Endpoint      string           `json:"endpoint"`          // URL of the tested server.
+	Region        string           `json:"region"`            // Region used to sign requests.
+	ServerVersion string           `json:"serverVersion"`     // Server header sent back by the tested server.
+	Profile       string           `json:"profile,omitempty"` // Name of the profile listing the known deviations of the server.
+	Started       time.Time        `json:"started"`           // When testing started.
+	Passed        bool             `json:"passed"`            // Whether every test passed or failed as expected.
+	Summary       map[string]int   `json:"summary"`           // Number of tests per status.
+	Tests         []jsonTestResult `json:"tests"`             // Results of every test in the order they were run.
+
+	Baseline *baselineComparison `json:"baseline,omitempty"` // Differences with the baseline, if --baseline was used.
+}
+
+// jsonTestResult - the result of a single test or of a single scenario inside of a test.
+type jsonTestResult struct {
+	Name       string           `json:"name"`
+	Status     TestStatus       `json:"status"`
+	Duration   float64          `json:"duration"` // In seconds.
+	Error      string           `json:"error,omitempty"`
+	Requests   int              `json:"requests"`
+	StatusCode int              `json:"statusCode,omitempty"` // HTTP status of the last response received.
+	Code       string           `json:"code,omitempty"`       // S3 error code of the last response received.
+	RequestID  string           `json:"requestId,omitempty"`
+	HostID     string           `json:"hostId,omitempty"`
+	Checks     []jsonTestResult `json:"checks,omitempty"`
+
+	Divergences []Divergence `json:"divergences,omitempty"` // Differences with the reference server.
+}
+
+// jsonTargetsReport - the results of every target tested with --target as printed by --format json.
+type jsonTargetsReport struct {
+	Passed  bool         `json:"passed"`  // Whether every test of every target passed or failed as expected.
+	Targets []jsonReport `json:"targets"` // Reports of the targets in the order they were tested.
+}
+
+// newJSONTestResult - convert the result of a test or scenario to its JSON form.
+func newJSONTestResult(result TestResult) jsonTestResult {
+	jsonResult := jsonTestResult{
+		Name:       result.Name,
+		Status:     result.Status,
+		Duration:   result.Duration.Seconds(),
+		Requests:   result.Requests,
+		StatusCode: result.Response.StatusCode,
+		Code:       result.Response.Code,
+		RequestID:  result.Response.RequestID,
+		HostID:     result.Response.HostID,
+
+		Divergences: result.Divergences,
+	}
+	if result.Err != nil {
+		jsonResult.Error = result.Err.Error()
+	}
+	for _, check := range result.Checks {
+		jsonResult.Checks = append(jsonResult.Checks, newJSONTestResult(check))
+	}
+	return jsonResult
+}
+
+// newJSONReport - create the JSON report of all tests run against the server in config.
+func newJSONReport(config ServerConfig, serverVersion string, started time.Time, results []TestResult) jsonReport {
+	report := jsonReport{
+		Version:       globalS3verifyVersion,
+		Endpoint:      config.Endpoint,
+		Region:        config.Region,
+		ServerVersion: serverVersion,
+		Started:       started.UTC(),
+		Passed:        testsPassed(results),
+		Summary:       make(map[string]int),
+		Tests:         []jsonTestResult{},
+	}
+	if config.profile != nil {
+		report.Profile = config.profile.Name
+	}
+	for _, result := range results {
+		report.Summary[string(result.Status)]++
+		report.Tests = append(report.Tests, newJSONTestResult(result))
+	}
+	return report
+}
+
+// printJSONReport - print a report as a single JSON document to stdout.
+func printJSONReport(report interface{}) error {
+	reportBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(append(reportBytes, '\n'))
+	return err
+}
@@ -0,0 +1,156 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// junitTestSuites - the root element of a JUnit XML report.
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite - all tests run against a single server.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Hostname  string          `xml:"hostname,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase - a single test or a single scenario inside of a test.
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"` // Differences with the reference server.
+}
+
+// junitMessage - why a test failed or was skipped.
+type junitMessage struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr,omitempty"`
+	Contents string `xml:",chardata"`
+}
+
+// junitSeconds - format a duration the way JUnit consumers expect it.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// newJUnitTestCase - convert the result of a test or scenario to a JUnit test case.
+func newJUnitTestCase(className, name string, result TestResult) junitTestCase {
+	testCase := junitTestCase{
+		ClassName: className,
+		Name:      name,
+		Time:      junitSeconds(result.Duration),
+	}
+	for _, divergence := range result.Divergences {
+		testCase.SystemOut += fmt.Sprintf("%s %s differs:\ntarget: %s\nreference: %s\n",
+			divergence.Request, divergence.Field, divergence.Target, divergence.Reference)
+	}
+	switch result.Status {
+	case TestFail:
+		// Include everything needed to find the failed request in the server logs.
+		details := []string{result.Err.Error()}
+		if result.Response.StatusCode != 0 {
+			details = append(details, fmt.Sprintf("HTTP Status: %d", result.Response.StatusCode))
+		}
+		if result.Response.Code != "" {
+			details = append(details, "Code: "+result.Response.Code)
+		}
+		if result.Response.RequestID != "" {
+			details = append(details, "RequestId: "+result.Response.RequestID)
+		}
+		if result.Response.HostID != "" {
+			details = append(details, "HostId: "+result.Response.HostID)
+		}
+		testCase.Failure = &junitMessage{
+			Message:  result.Err.Error(),
+			Type:     result.Response.Code,
+			Contents: strings.Join(details, "\n"),
+		}
+	case TestSkip:
+		testCase.Skipped = &junitMessage{
+			Message: result.Err.Error(),
+		}
+	case TestXFail:
+		testCase.Skipped = &junitMessage{
+			Message: "Expected failure: " + result.Err.Error(),
+		}
+	}
+	return testCase
+}
+
+// newJUnitTestSuite - convert the results of all tests run against endpoint to a JUnit test suite called name.
+// Every test is reported as a test case followed by one test case per scenario it verified.
+func newJUnitTestSuite(name, endpoint string, started time.Time, results []TestResult) junitTestSuite {
+	suite := junitTestSuite{
+		Name:      name,
+		Timestamp: started.UTC().Format("2006-01-02T15:04:05"),
+		Hostname:  endpoint,
+		Cases:     []junitTestCase{},
+	}
+	var total time.Duration
+	for _, result := range results {
+		total += result.Duration
+		testCases := []junitTestCase{newJUnitTestCase(name, result.Name, result)}
+		for _, check := range result.Checks {
+			testCases = append(testCases, newJUnitTestCase(name+"."+result.Name, result.Name+"/"+check.Name, check))
+		}
+		for _, testCase := range testCases {
+			suite.Tests++
+			if testCase.Failure != nil {
+				suite.Failures++
+			} else if testCase.Skipped != nil {
+				suite.Skipped++
+			}
+		}
+		suite.Cases = append(suite.Cases, testCases...)
+	}
+	suite.Time = junitSeconds(total)
+	return suite
+}
+
+// newJUnitReport - convert the results of all tests run against endpoint to a JUnit XML report.
+func newJUnitReport(endpoint string, started time.Time, results []TestResult) junitTestSuites {
+	return junitTestSuites{
+		Suites: []junitTestSuite{newJUnitTestSuite("s3verify", endpoint, started, results)},
+	}
+}
+
+// writeJUnitReport - write a JUnit XML report to fileName.
+func writeJUnitReport(fileName string, report junitTestSuites) error {
+	reportBytes, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	reportBytes = append([]byte(xml.Header), reportBytes...)
+	return ioutil.WriteFile(fileName, append(reportBytes, '\n'), 0644)
+}
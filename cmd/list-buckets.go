@@ -128,10 +128,10 @@ func verifyBodyListBuckets(resBody io.Reader, expected *listAllMyBucketsResult)
 }
 
 // Test the ListBuckets API with no added parameters.
-func mainListBuckets(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] ListBuckets:", curTest, globalTotalNumTest)
+func mainListBuckets(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] ListBuckets:", curTest, run.totalNumTest)
 	// Spin the scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// ListBuckets test will only run on s3verify created buckets.
 	expectedList := &listAllMyBucketsResult{
 		Owner: owner{
@@ -139,35 +139,31 @@ func mainListBuckets(config ServerConfig, curTest int) bool {
 			ID:          "",
 		},
 		Buckets: buckets{
-			Bucket: s3verifyBuckets,
+			Bucket: run.buckets,
 		},
 	}
 
 	// Generate new List Buckets request.
 	req, err := newListBucketsReq()
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin the scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// Generate the server response.
 	res, err := config.execRequest("GET", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin the scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Check for S3 Compatibility
 	if err := listBucketsVerify(res, http.StatusOK, expectedList); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin the scanBar
-	scanBar(message)
-	printMessage(message, err)
-	return true
+	run.scanBar(message)
+	return newTestResult(message, nil)
 }
@@ -115,14 +115,14 @@ func verifyBodyListMultipartUploads(resBody io.Reader, expectedList listMultipar
 }
 
 // mainListMultipartUploads - list-multipart-uplods API test.
-func mainListMultipartUploads(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] Multipart (List-Uploads):", curTest, globalTotalNumTest)
+func mainListMultipartUploads(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] Multipart (List-Uploads):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	uploads := []ObjectMultipartInfo{}
 	// All multipart objects are stored in s3verify created buckets so only list on those.
-	bucketName := s3verifyBuckets[0].Name
-	for _, multipartObject := range multipartObjects {
+	bucketName := run.buckets[0].Name
+	for _, multipartObject := range run.multipartObjects {
 		uploads = append(uploads, ObjectMultipartInfo{
 			Key:      multipartObject.Key,
 			UploadID: multipartObject.UploadID,
@@ -133,32 +133,28 @@ func mainListMultipartUploads(config ServerConfig, curTest int) bool {
 		Uploads: uploads,
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new request.
 	req, err := newListMultipartUploadsReq(bucketName)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("GET", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
 	if err := listMultipartUploadsVerify(res, http.StatusOK, expectedList); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -137,207 +137,125 @@ func verifyHeaderListObjectsV1(header http.Header) error {
 	return nil
 }
 
-// mainListObjectsV1 - ListObjects V1 API test. This test is the same for both --prepared and non --prepared environments.
-func mainListObjectsV1(config ServerConfig, curTest int, bucketName string, testObjects []*ObjectInfo) bool {
-	message := fmt.Sprintf("[%02d/%d] ListObjects V1:", curTest, globalTotalNumTest)
-	// Spin scanBar
-	scanBar(message)
-	objectInfo := ObjectInfos{}
-	for _, object := range testObjects {
-		objectInfo = append(objectInfo, *object)
-	}
-	sort.Sort(objectInfo)
-
-	// Test for listobjects with no extra parameters (should only return the first 1000 objects).
-	expectedList := listBucketResult{
-		Name:        bucketName,        // Listing from the first bucket created that houses all objects.
-		Contents:    objectInfo[:1000], // The first bucket created will house all the objects created by the PUT object test.
-		IsTruncated: true,              // This list is shorter than the actual 1002 objects stored in testObjects.
-	}
+// listObjectsV1Check - list bucketName with the given parameters and verify the listing matches what is expected.
+func listObjectsV1Check(config ServerConfig, bucketName string, parameters map[string]string, expectedList listBucketResult) error {
 	// Create a new request.
-	noParamReq, err := newListObjectsV1Req(bucketName, nil) // No extra parameters for the first test.
+	req, err := newListObjectsV1Req(bucketName, parameters)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return err
 	}
-	// Spin scanBar
-	scanBar(message)
 	// Execute the request.
-	noParamRes, err := config.execRequest("GET", noParamReq)
+	res, err := config.execRequest("GET", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return err
 	}
-	defer closeResponse(noParamRes)
-	// Spin scanBar
-	scanBar(message)
+	defer closeResponse(res)
 	// Verify the response.
-	if err := listObjectsV1Verify(noParamRes, http.StatusOK, expectedList); err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-
-	// Test for listobjects with maxkeys parameter set.
-	expectedListMaxKeys := listBucketResult{
-		Name:        bucketName,
-		Contents:    objectInfo[:30], // Only return the first 30 objects.
-		MaxKeys:     30,              // Only return the first 30 objects.
-		IsTruncated: true,
-	}
-	// Store the parameters to be set by the request.
-	maxKeysMap := map[string]string{
-		"max-keys": "30", // 30 objects.
-	}
-	// Create a new request with max-keys set to 30.
-	maxKeysReq, err := newListObjectsV1Req(bucketName, maxKeysMap) // MaxKeys set to 30.
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the request.
-	maxKeysRes, err := config.execRequest("GET", maxKeysReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(maxKeysRes)
-	// Spin scanBar
-	scanBar(message)
-	// Verify the max-keys parameter is respected.
-	if err := listObjectsV1Verify(maxKeysRes, http.StatusOK, expectedListMaxKeys); err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-
-	// Test for listobjects with prefix parameter set.
-	expectedListPrefix := listBucketResult{
-		Name: bucketName,
-		// Should only return objects that were put during the put-object test.
-		Contents:    objectInfo[:1000], // Will only get 1000 objects after the excluded object.
-		Prefix:      "s3verify/put/object/",
-		IsTruncated: true,
-	}
-	// Store the parameters.
-	prefixMap := map[string]string{
-		"prefix": "s3verify/put/object/",
-	}
-
-	prefixReq, err := newListObjectsV1Req(bucketName, prefixMap)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the request.
-	prefixRes, err := config.execRequest("GET", prefixReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(prefixRes)
-	// Verify the prefix parameter is respected.
-	if err := listObjectsV1Verify(prefixRes, http.StatusOK, expectedListPrefix); err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-
-	// Test for listobjects with delimiter parameter and prefix parameter set.
-	expectedListDelimiterPrefix := listBucketResult{
-		Name: bucketName,
-		// No objects will be returned only the prefix.
-		CommonPrefixes: []commonPrefix{commonPrefix{"s3verify/put/object/"}},
-		Prefix:         "s3verify/put/",
-		Delimiter:      "/",
-		IsTruncated:    false,
-	}
-
-	// Store the parameters.
-	prefixDelimiterMap := map[string]string{
-		"delimiter": "/",
-		"prefix":    "s3verify/put/",
-	}
+	return listObjectsV1Verify(res, http.StatusOK, expectedList)
+}
 
-	prefixDelimiterReq, err := newListObjectsV1Req(bucketName, prefixDelimiterMap)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the request.
-	prefixDelimRes, err := config.execRequest("GET", prefixDelimiterReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(prefixDelimRes)
-	// Verify that delimiter and prefix parameters are respected.
-	if err := listObjectsV1Verify(prefixDelimRes, http.StatusOK, expectedListDelimiterPrefix); err != nil {
-		printMessage(message, err)
-		return false
-	}
+// mainListObjectsV1 - ListObjects V1 API test. This test is the same for both --prepared and non --prepared environments.
+func mainListObjectsV1(config ServerConfig, run *RunContext, curTest int, bucketName string, testObjects []*ObjectInfo) TestResult {
+	message := fmt.Sprintf("[%02d/%d] ListObjects V1:", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
-
-	// Test for listobjects with max-keys set over 1000.
-	expectedListMaxKeysOver := listBucketResult{
-		Name: bucketName,
-		// Should return only 1000 objects.
-		Contents:    objectInfo[:1000], // Will only get 1000 objects after the excluded object.
-		IsTruncated: true,
+	run.scanBar(message)
+	objectInfo := ObjectInfos{}
+	for _, object := range testObjects {
+		objectInfo = append(objectInfo, *object)
 	}
+	sort.Sort(objectInfo)
 
-	// Store the parameters.
-	maxKeysOverMap := map[string]string{
-		"max-keys": "1001",
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Create the new request.
-	maxKeysOverReq, err := newListObjectsV1Req(bucketName, maxKeysOverMap)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the request.
-	maxKeysOverRes, err := config.execRequest("GET", maxKeysOverReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(maxKeysOverRes)
-	// Verify that the max-keys parameter tops out at 1000 objects.
-	if err := listObjectsV1Verify(maxKeysOverRes, http.StatusOK, expectedListMaxKeysOver); err != nil {
-		printMessage(message, err)
-		return false
+	// Every scenario lists the same bucket with different parameters.
+	checks := []struct {
+		name         string
+		parameters   map[string]string
+		expectedList listBucketResult
+	}{
+		// Test for listobjects with no extra parameters (should only return the first 1000 objects).
+		{
+			name: "NoParameters",
+			expectedList: listBucketResult{
+				Name:        bucketName,        // Listing from the first bucket created that houses all objects.
+				Contents:    objectInfo[:1000], // The first bucket created will house all the objects created by the PUT object test.
+				IsTruncated: true,              // This list is shorter than the actual 1002 objects stored in testObjects.
+			},
+		},
+		// Test for listobjects with maxkeys parameter set.
+		{
+			name: "MaxKeys",
+			parameters: map[string]string{
+				"max-keys": "30", // 30 objects.
+			},
+			expectedList: listBucketResult{
+				Name:        bucketName,
+				Contents:    objectInfo[:30], // Only return the first 30 objects.
+				MaxKeys:     30,              // Only return the first 30 objects.
+				IsTruncated: true,
+			},
+		},
+		// Test for listobjects with prefix parameter set.
+		{
+			name: "Prefix",
+			parameters: map[string]string{
+				"prefix": "s3verify/put/object/",
+			},
+			expectedList: listBucketResult{
+				Name: bucketName,
+				// Should only return objects that were put during the put-object test.
+				Contents:    objectInfo[:1000], // Will only get 1000 objects after the excluded object.
+				Prefix:      "s3verify/put/object/",
+				IsTruncated: true,
+			},
+		},
+		// Test for listobjects with delimiter parameter and prefix parameter set.
+		{
+			name: "DelimiterPrefix",
+			parameters: map[string]string{
+				"delimiter": "/",
+				"prefix":    "s3verify/put/",
+			},
+			expectedList: listBucketResult{
+				Name: bucketName,
+				// No objects will be returned only the prefix.
+				CommonPrefixes: []commonPrefix{commonPrefix{"s3verify/put/object/"}},
+				Prefix:         "s3verify/put/",
+				Delimiter:      "/",
+				IsTruncated:    false,
+			},
+		},
+		// Test for listobjects with max-keys set over 1000.
+		{
+			name: "MaxKeysOver1000",
+			parameters: map[string]string{
+				"max-keys": "1001",
+			},
+			expectedList: listBucketResult{
+				Name: bucketName,
+				// Should return only 1000 objects.
+				Contents:    objectInfo[:1000], // Will only get 1000 objects after the excluded object.
+				IsTruncated: true,
+			},
+		},
+	}
+	result := newTestResult(message, nil)
+	for _, check := range checks {
+		// Spin scanBar
+		run.scanBar(message)
+		result.addCheck(config, check.name, listObjectsV1Check(config, bucketName, check.parameters, check.expectedList))
 	}
 	// Spin scanBar
-	scanBar(message)
-
-	// Test passed.
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return result
 }
 
 // mainListObjectsV1UnPrepared - Test the ListObjects V1 API in an unprepared environment.
-func mainListObjectsV1UnPrepared(config ServerConfig, curTest int) bool {
-	bucketName := s3verifyBuckets[0].Name
-	return mainListObjectsV1(config, curTest, bucketName, s3verifyObjects)
+func mainListObjectsV1UnPrepared(config ServerConfig, run *RunContext, curTest int) TestResult {
+	bucketName := run.buckets[0].Name
+	return mainListObjectsV1(config, run, curTest, bucketName, run.objects)
 }
 
 // mainListObjectsV1Prepared - Test the ListObjects V1 API in a prepared environment.
-func mainListObjectsV1Prepared(config ServerConfig, curTest int) bool {
-	bucketName := preparedBuckets[0].Name
-	return mainListObjectsV1(config, curTest, bucketName, preparedObjects)
+func mainListObjectsV1Prepared(config ServerConfig, run *RunContext, curTest int) TestResult {
+	bucketName := run.preparedBuckets[0].Name
+	return mainListObjectsV1(config, run, curTest, bucketName, run.preparedObjects)
 }
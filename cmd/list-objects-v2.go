@@ -115,237 +115,137 @@ func verifyBodyListObjectsV2(resBody io.Reader, expectedList listBucketV2Result)
 	return nil
 }
 
-// mainListObjectsV2 - Entry point for the ListObjects V2 API test. This test is the same for --prepared environments and non --prepared.
-func mainListObjectsV2(config ServerConfig, curTest int, bucketName string, testObjects []*ObjectInfo) bool {
-	message := fmt.Sprintf("[%02d/%d] ListObjects V2:", curTest, globalTotalNumTest)
-	// Spin scanBar
-	scanBar(message)
-	objectInfo := ObjectInfos{}
-	for _, object := range testObjects {
-		objectInfo = append(objectInfo, *object)
-	}
-	sort.Sort(objectInfo)
-
-	expectedList := listBucketV2Result{
-		Name:        bucketName,        // List only from the first bucket created because that is the bucket holding the objects.
-		Contents:    objectInfo[:1000], // Will only return the first 1000 objects.
-		IsTruncated: true,
-	}
+// listObjectsV2Check - list bucketName with the given parameters and verify the listing matches what is expected.
+func listObjectsV2Check(config ServerConfig, bucketName string, requestParameters map[string]string, expectedList listBucketV2Result) error {
 	// Create a new request.
-	req, err := newListObjectsV2Req(bucketName, nil)
+	req, err := newListObjectsV2Req(bucketName, requestParameters)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return err
 	}
-	// Spin scanBar
-	scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("GET", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return err
 	}
 	defer closeResponse(res)
 	// Verify the response.
-	if err := listObjectsV2Verify(res, http.StatusOK, expectedList); err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-
-	// Test for listobjects with start-after parameter set.
-	expectedListStartAfter := listBucketV2Result{
-		Name:        bucketName,
-		Contents:    objectInfo[31:],
-		IsTruncated: false,
-	}
-
-	// Store the parameters.
-	startAfterMap := map[string]string{
-		"start-after": objectInfo[30].Key,
-	}
-
-	// Create a new request.
-	startAfterReq, err := newListObjectsV2Req(bucketName, startAfterMap)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Execute request.
-	startAfterRes, err := config.execRequest("GET", startAfterReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(startAfterRes)
-	// Verify the response
-	if err := listObjectsV2Verify(startAfterRes, http.StatusOK, expectedListStartAfter); err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-
-	// Test for listobjects with maxkeys parameter set.
-	expectedListMaxKeys := listBucketV2Result{
-		Name:        bucketName,
-		Contents:    objectInfo[:30], // Only return the first 30 objects.
-		MaxKeys:     30,              // Only return the first 30 objects.
-		IsTruncated: true,
-	}
-	// Store the parameters to be set by the request.
-	maxKeysMap := map[string]string{
-		"max-keys": "30", // 30 objects.
-	}
-	// Create a new request with max-keys set to 30.
-	maxKeysReq, err := newListObjectsV2Req(bucketName, maxKeysMap) // MaxKeys set to 30.
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the request.
-	maxKeysRes, err := config.execRequest("GET", maxKeysReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(maxKeysRes)
-	// Spin scanBar
-	scanBar(message)
-	// Verify the max-keys parameter is respected.
-	if err := listObjectsV2Verify(maxKeysRes, http.StatusOK, expectedListMaxKeys); err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-
-	// Test for listobjects with prefix parameter set.
-	expectedListPrefix := listBucketV2Result{
-		Name: bucketName,
-		// Should only return objects that were put during the put-object test.
-		Contents:    objectInfo[:1000], // Will only get 1000 objects after the excluded object.
-		Prefix:      "s3verify/put/object/",
-		IsTruncated: true,
-	}
-	// Store the parameters.
-	prefixMap := map[string]string{
-		"prefix": "s3verify/put/object/",
-	}
-
-	prefixReq, err := newListObjectsV2Req(bucketName, prefixMap)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the request.
-	prefixRes, err := config.execRequest("GET", prefixReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(prefixRes)
-	// Verify the prefix parameter is respected.
-	if err := listObjectsV2Verify(prefixRes, http.StatusOK, expectedListPrefix); err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-
-	// Test for listobjects with delimiter parameter and prefix parameter set.
-	expectedListDelimiterPrefix := listBucketV2Result{
-		Name: bucketName,
-		// Should return no objects just the prefix.
-		CommonPrefixes: []commonPrefix{commonPrefix{"s3verify/put/object/"}},
-		Prefix:         "s3verify/put/",
-		Delimiter:      "/",
-		IsTruncated:    false,
-	}
-
-	// Store the parameters.
-	prefixDelimiterMap := map[string]string{
-		"delimiter": "/",
-		"prefix":    "s3verify/put/",
-	}
+	return listObjectsV2Verify(res, http.StatusOK, expectedList)
+}
 
-	prefixDelimiterReq, err := newListObjectsV2Req(bucketName, prefixDelimiterMap)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the request.
-	prefixDelimRes, err := config.execRequest("GET", prefixDelimiterReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(prefixDelimRes)
-	// Verify that delimiter and prefix parameters are respected.
-	if err := listObjectsV2Verify(prefixDelimRes, http.StatusOK, expectedListDelimiterPrefix); err != nil {
-		printMessage(message, err)
-		return false
-	}
+// mainListObjectsV2 - Entry point for the ListObjects V2 API test. This test is the same for --prepared environments and non --prepared.
+func mainListObjectsV2(config ServerConfig, run *RunContext, curTest int, bucketName string, testObjects []*ObjectInfo) TestResult {
+	message := fmt.Sprintf("[%02d/%d] ListObjects V2:", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
-
-	// Test for listobjects with max-keys set over 1000.
-	expectedListMaxKeysOver := listBucketV2Result{
-		Name: bucketName,
-		// Should return only 1000 objects.
-		Contents:    objectInfo[:1000], // Will only get 1000 objects after the excluded object.
-		IsTruncated: true,
+	run.scanBar(message)
+	objectInfo := ObjectInfos{}
+	for _, object := range testObjects {
+		objectInfo = append(objectInfo, *object)
 	}
+	sort.Sort(objectInfo)
 
-	// Store the parameters.
-	maxKeysOverMap := map[string]string{
-		"max-keys": "1001",
+	// Every scenario lists the same bucket with different parameters.
+	checks := []struct {
+		name         string
+		parameters   map[string]string
+		expectedList listBucketV2Result
+	}{
+		// Test for listobjects with no extra parameters.
+		{
+			name: "NoParameters",
+			expectedList: listBucketV2Result{
+				Name:        bucketName,        // List only from the first bucket created because that is the bucket holding the objects.
+				Contents:    objectInfo[:1000], // Will only return the first 1000 objects.
+				IsTruncated: true,
+			},
+		},
+		// Test for listobjects with start-after parameter set.
+		{
+			name: "StartAfter",
+			parameters: map[string]string{
+				"start-after": objectInfo[30].Key,
+			},
+			expectedList: listBucketV2Result{
+				Name:        bucketName,
+				Contents:    objectInfo[31:],
+				IsTruncated: false,
+			},
+		},
+		// Test for listobjects with maxkeys parameter set.
+		{
+			name: "MaxKeys",
+			parameters: map[string]string{
+				"max-keys": "30", // 30 objects.
+			},
+			expectedList: listBucketV2Result{
+				Name:        bucketName,
+				Contents:    objectInfo[:30], // Only return the first 30 objects.
+				MaxKeys:     30,              // Only return the first 30 objects.
+				IsTruncated: true,
+			},
+		},
+		// Test for listobjects with prefix parameter set.
+		{
+			name: "Prefix",
+			parameters: map[string]string{
+				"prefix": "s3verify/put/object/",
+			},
+			expectedList: listBucketV2Result{
+				Name: bucketName,
+				// Should only return objects that were put during the put-object test.
+				Contents:    objectInfo[:1000], // Will only get 1000 objects after the excluded object.
+				Prefix:      "s3verify/put/object/",
+				IsTruncated: true,
+			},
+		},
+		// Test for listobjects with delimiter parameter and prefix parameter set.
+		{
+			name: "DelimiterPrefix",
+			parameters: map[string]string{
+				"delimiter": "/",
+				"prefix":    "s3verify/put/",
+			},
+			expectedList: listBucketV2Result{
+				Name: bucketName,
+				// Should return no objects just the prefix.
+				CommonPrefixes: []commonPrefix{commonPrefix{"s3verify/put/object/"}},
+				Prefix:         "s3verify/put/",
+				Delimiter:      "/",
+				IsTruncated:    false,
+			},
+		},
+		// Test for listobjects with max-keys set over 1000.
+		{
+			name: "MaxKeysOver1000",
+			parameters: map[string]string{
+				"max-keys": "1001",
+			},
+			expectedList: listBucketV2Result{
+				Name: bucketName,
+				// Should return only 1000 objects.
+				Contents:    objectInfo[:1000], // Will only get 1000 objects after the excluded object.
+				IsTruncated: true,
+			},
+		},
+	}
+	result := newTestResult(message, nil)
+	for _, check := range checks {
+		// Spin scanBar
+		run.scanBar(message)
+		result.addCheck(config, check.name, listObjectsV2Check(config, bucketName, check.parameters, check.expectedList))
 	}
 	// Spin scanBar
-	scanBar(message)
-	// Create the new request.
-	maxKeysOverReq, err := newListObjectsV2Req(bucketName, maxKeysOverMap)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the request.
-	maxKeysOverRes, err := config.execRequest("GET", maxKeysOverReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(maxKeysOverRes)
-	// Verify that the max-keys parameter tops out at 1000 objects.
-	if err := listObjectsV2Verify(maxKeysOverRes, http.StatusOK, expectedListMaxKeysOver); err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-
-	// Test passed.
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return result
 }
 
 // mainListObjectsV2UnPrepared - Test the ListObjects V2 API in an unprepared environment.
-func mainListObjectsV2UnPrepared(config ServerConfig, curTest int) bool {
-	bucketName := s3verifyBuckets[0].Name
-	return mainListObjectsV2(config, curTest, bucketName, s3verifyObjects)
+func mainListObjectsV2UnPrepared(config ServerConfig, run *RunContext, curTest int) TestResult {
+	bucketName := run.buckets[0].Name
+	return mainListObjectsV2(config, run, curTest, bucketName, run.objects)
 }
 
 // mainListObjectsV2Prepared - Test the ListObjects V2 API in a prepared environment.
-func mainListObjectsV2Prepared(config ServerConfig, curTest int) bool {
-	bucketName := preparedBuckets[0].Name
-	return mainListObjectsV2(config, curTest, bucketName, preparedObjects)
+func mainListObjectsV2Prepared(config ServerConfig, run *RunContext, curTest int) TestResult {
+	bucketName := run.preparedBuckets[0].Name
+	return mainListObjectsV2(config, run, curTest, bucketName, run.preparedObjects)
 }
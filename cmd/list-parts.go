@@ -109,48 +109,44 @@ func verifyHeaderListParts(header http.Header) error {
 }
 
 // mainListParts - Entry point for the ListParts API test.
-func mainListParts(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] Multipart (List-Parts):", curTest, globalTotalNumTest)
+func mainListParts(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] Multipart (List-Parts):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// All multipart objects are stored in s3verify created buckets so only list parts in those buckets.
-	bucketName := s3verifyBuckets[0].Name
+	bucketName := run.buckets[0].Name
 	// TODO: eventually separate tests will be needed here when during prepare we concurrently upload
 	// 1001 parts for the list parts test.
 
-	object := multipartObjects[0]
+	object := run.multipartObjects[0]
 	// Create a handcrafted ListObjectsPartsResult
 	expectedList := listObjectPartsResult{
 		Bucket:      bucketName,
 		Key:         object.Key,
 		UploadID:    object.UploadID,
-		ObjectParts: objectParts[0],
+		ObjectParts: run.objectParts[0],
 	}
 	// Create a new ListParts request.
 	req, err := newListPartsReq(bucketName, object.Key, object.UploadID)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("GET", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
 	if err := listPartsVerify(res, http.StatusOK, expectedList); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, err)
-	return true
+	return newTestResult(message, nil)
 }
@@ -20,6 +20,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/console"
@@ -62,13 +64,66 @@ EXAMPLES:
      $ set +o history
      $ s3verify --access YOUR_ACCESS_KEY --secret YOUR_SECRET_KEY --url https://s3.amazonaws.com --region us-west-1
      $ set -o history
+
+  3. Run only the GetObject tests, prerequisite tests such as PutBucket and PutObject are run automatically.
+     $ s3verify --run '^GetObject' --extended
+
+  4. Run all basic tests except for the bucket policy tests.
+     $ s3verify --skip 'BucketPolicy$'
+
+  5. List the names of all tests that can be selected with --run and --skip.
+     $ s3verify --list --extended
+
+  6. Run all basic tests and write a JUnit XML report for CI.
+     $ s3verify --report-junit s3verify-report.xml
+
+  7. Run all basic tests and print the results as a single JSON document.
+     $ s3verify --format json > s3verify-results.json
+
+  8. Run all tests and report the known deviations of the server listed in a profile as XFAIL.
+     $ s3verify --extended --profile minio-profile.json
+
+  9. Store the results of a run as a baseline, then only fail later runs on new failures.
+     $ s3verify --format json > baseline.json
+     $ s3verify --baseline baseline.json
+
+  10. Send every request to a trusted reference server as well and report every difference in the responses.
+     $ s3verify --reference-url https://s3.amazonaws.com --reference-access YOUR_AWS_ACCESS_KEY --reference-secret YOUR_AWS_SECRET_KEY
+
+  11. Record every HTTP exchange with credentials redacted, then run the tests again offline against the recording.
+     $ s3verify --record ./cassette
+     $ s3verify --replay ./cassette
+
+  12. Run all tests, running up to 8 independent tests at the same time.
+     $ s3verify --extended --parallel 8
+
+  13. Run the declarative tests of every YAML file in a directory along with the built-in tests.
+     $ s3verify --cases ./vendor-tests
+
+  14. Run all basic tests with the credentials of a profile of ~/.aws/credentials, or of an mc alias.
+     $ s3verify --aws-profile staging --url https://s3.amazonaws.com
+     $ s3verify --mc-alias play
+
+  15. Run the tests against two targets of ~/.s3verify/config.yaml and write a combined JUnit XML report.
+     $ s3verify --target minio --target aws --report-junit s3verify-report.xml
+
+  16. Run all basic tests, then the header, presigned and POST policy tests again signed with AWS Signature Version 2.
+     $ s3verify --signature v2
+
+  17. Run all basic tests with every bucket addressed in virtual-hosted style, as bucket.host/object.
+     $ s3verify --lookup virtual
+
+  18. Run all basic tests, then the core object tests again signed with SigV4A for the region of the server.
+     $ s3verify --signature v4a
 `
 
 // APItest - Define all mainXXX tests to be of this form.
 type APItest struct {
-	Test     func(ServerConfig, int) bool
-	Extended bool // Extended tests will only be invoked at the users request.
-	Critical bool // Tests marked critical must pass before more tests can be run.
+	Name     string // Stable identifier used to select tests with --run and --skip, e.g. "GetObject/IfMatch".
+	Test     func(ServerConfig, *RunContext, int) TestResult
+	Extended bool     // Extended tests will only be invoked at the users request.
+	Depends  []string // Names of earlier tests that must pass before this test can be run.
+	Parallel bool     // The test only reads what earlier tests created, only writes keys no other test of its group reads, writes or lists, and can run at the same time as the Parallel tests next to it, see --parallel.
 }
 
 func commandNotFound(ctx *cli.Context, command string) {
@@ -92,83 +147,347 @@ func registerApp() *cli.App {
 
 // callAllAPIS parse context extract flags and then call all.
 func callAllAPIs(ctx *cli.Context) {
+	// Determine which tests will be run.
+	selection, err := newTestSelection(ctx)
+	if err != nil {
+		console.Fatalln(err)
+	}
+	// Add the declarative tests to the built-in ones.
+	if path := ctx.GlobalString("cases"); path != "" {
+		declared, err := loadDeclarativeTests(path)
+		if err != nil {
+			console.Fatalln(err)
+		}
+		unpreparedTests = insertDeclarativeTests(unpreparedTests, declared)
+		preparedTests = insertDeclarativeTests(preparedTests, declared)
+	}
+	// Run the signing tests again with AWS Signature Version 2 or SigV4A if asked for.
+	rerun, err := rerunWithSignature(ctx.GlobalString("signature"))
+	if err != nil {
+		console.Fatalln(err)
+	}
+	if rerun != "" {
+		unpreparedTests = insertSignatureTests(unpreparedTests, rerun)
+		preparedTests = insertSignatureTests(preparedTests, rerun)
+	}
+	// Make sure every test only depends on tests run before it.
+	for _, tests := range [][]APItest{unpreparedTests, preparedTests} {
+		if err := checkDependencies(tests); err != nil {
+			console.Fatalln(err)
+		}
+	}
+	// Only print the selected tests, there is no need to contact the server.
+	if ctx.GlobalBool("list") {
+		for _, test := range selection.filter(unpreparedTests) {
+			console.Println(test.Name)
+		}
+		return
+	}
+	// Test every server selected from the config file one after the other.
+	if len(ctx.GlobalStringSlice("target")) > 0 {
+		if !runTestTargets(ctx) {
+			os.Exit(1)
+		}
+		return
+	}
 	// Create a new config from the context.
 	config, err := newServerConfig(ctx)
 	if err != nil {
 		// Could not create a config. Exit immediately.
-		cli.ShowAppHelpAndExit(ctx, 1)
+		console.Fatalln(err)
 	}
 	if config.Access == "" {
-		console.Fatalln(errors.New("Please set S3_ACCESS=<your-access-key>. Refer 's3verify --help'"))
+		console.Fatalln(errors.New("Please set S3_ACCESS=<your-access-key> or AWS_ACCESS_KEY_ID, or use --aws-profile or --mc-alias. Refer 's3verify --help'"))
 	}
 	if config.Secret == "" {
-		console.Fatalln(errors.New("Please set S3_SECRET=<your-secret-key>. Refer 's3verify --help'"))
+		console.Fatalln(errors.New("Please set S3_SECRET=<your-secret-key> or AWS_SECRET_ACCESS_KEY. Refer 's3verify --help'"))
 	}
-	// Test that the given endpoint is reachable with a simple GET request.
-	if err := verifyHostReachable(config.Endpoint, config.Region); err != nil {
-		// If the provided endpoint is unreachable error out instantly.
+	// Keep the buckets and objects of this run apart from any other run.
+	run, err := newRunContextFromContext(ctx)
+	if err != nil {
 		console.Fatalln(err)
 	}
-	// Determine whether or not extended tests will be run.
-	testExtended := ctx.GlobalBool("extended")
+	// Test that the given endpoints are reachable.
+	serverVersion := verifyServers(*config, run, ctx.GlobalString("record"), ctx.GlobalString("replay"))
+	// Load the baseline before running any test so a bad file is reported right away.
+	var baseline *jsonReport
+	if fileName := ctx.GlobalString("baseline"); fileName != "" {
+		report, err := loadBaseline(fileName)
+		if err != nil {
+			console.Fatalln(err)
+		}
+		baseline = &report
+	}
+	// Remember when testing started for the reports.
+	started := time.Now()
+	var results []TestResult
 	// If a test environment is asked for prepare it now.
 	if ctx.GlobalString("reuse") != "" {
-		bucketName := "s3verify-" + globalSuffix
-		console.Printf("S3Verify attempting to reuse %s to test AWS S3 V4 signature compatibility.\n", bucketName)
+		bucketName := "s3verify-" + run.suffix
+		if !run.quiet {
+			console.Printf("S3Verify attempting to reuse %s to test AWS S3 V4 signature compatibility.\n", bucketName)
+		}
 		// Reuse an already prepared environment or create a new one.
-		err := mainReuseS3Verify(*config)
+		err := mainReuseS3Verify(*config, run)
 		if err != nil {
 			console.Fatalln(err)
 		}
-		console.Printf("S3Verify starting testing:\n")
-		runPreparedTests(*config, testExtended)
+		if !run.quiet {
+			console.Printf("S3Verify starting testing:\n")
+		}
+		results = runPreparedTests(*config, run, selection)
 	} else if ctx.GlobalString("clean") != "" { // Clean any previously --prepare(d) tests up.
 		// Retrieve the bucket to be cleaned up.
 		bucketName := "s3verify-" + ctx.GlobalString("clean")
-		if err := cleanS3verify(*config, bucketName); err != nil {
+		if err := cleanS3verify(*config, run, bucketName); err != nil {
 			console.Fatalln(err)
 		}
+		return
 	} else {
 		// If the user does not use --prepare flag then just run all non preparedTests.
-		runUnPreparedTests(*config, testExtended)
+		results = runUnPreparedTests(*config, run, selection)
+	}
+	if !reportResults(ctx, *config, serverVersion, started, results, baseline) {
+		os.Exit(1)
+	}
+}
+
+// verifyServers - make sure the tested server, and the reference server if any, can be reached and
+// start a recording to recordDir if asked for. Nothing is reached when replaying the recording of
+// replayDir. Returns the Server header of the tested server.
+func verifyServers(config ServerConfig, run *RunContext, recordDir, replayDir string) string {
+	var serverVersion string
+	var err error
+	if replayDir != "" {
+		// There is no server to reach, everything comes from the recording.
+		session, err := loadCassetteSession(replayDir)
+		if err != nil {
+			console.Fatalln(err)
+		}
+		serverVersion = session.ServerVersion
+	} else {
+		// Test that the given endpoint is reachable with a simple GET request.
+		serverVersion, err = verifyHostReachable(config.Endpoint, config.Region, config.tlsConfig)
+		if err != nil {
+			// If the provided endpoint is unreachable error out instantly.
+			console.Fatalln(err)
+		}
+	}
+	if recordDir != "" {
+		// Store what is needed to generate the same requests again when replaying.
+		session := cassetteSession{
+			Version:       globalS3verifyVersion,
+			Seed:          run.seed,
+			Suffix:        run.suffix,
+			Endpoint:      config.Endpoint,
+			Region:        config.Region,
+			ServerVersion: serverVersion,
+		}
+		if err := saveCassetteSession(recordDir, session); err != nil {
+			console.Fatalln(err)
+		}
+	}
+	if config.reference != nil {
+		if config.reference.Access == "" || config.reference.Secret == "" {
+			console.Fatalln(errors.New("Please set S3_REFERENCE_ACCESS and S3_REFERENCE_SECRET for the reference server. Refer 's3verify --help'"))
+		}
+		if _, err := verifyHostReachable(config.reference.Endpoint, config.reference.Region, config.reference.tlsConfig); err != nil {
+			console.Fatalln(err)
+		}
+	}
+	return serverVersion
+}
+
+// reportResults - write out the results in every format asked for. Returns false if the run should fail.
+func reportResults(ctx *cli.Context, config ServerConfig, serverVersion string, started time.Time, results []TestResult, baseline *jsonReport) bool {
+	// Write out the report even if tests were skipped, that is when it is needed the most.
+	if fileName := ctx.GlobalString("report-junit"); fileName != "" {
+		if err := writeJUnitReport(fileName, newJUnitReport(config.Endpoint, started, results)); err != nil {
+			console.Fatalln(err)
+		}
+	}
+	report := newJSONReport(config, serverVersion, started, results)
+	passed := testsPassed(results)
+	if baseline != nil {
+		passed = compareWithBaseline(ctx, *baseline, &report)
+	}
+	if ctx.GlobalString("format") == "json" {
+		if err := printJSONReport(report); err != nil {
+			console.Fatalln(err)
+		}
+	}
+	return passed
+}
+
+// compareWithBaseline - add the differences of report with baseline to report and print them.
+// Returns false if the run should fail, only incompatibilities that are not already part of the baseline fail it.
+func compareWithBaseline(ctx *cli.Context, baseline jsonReport, report *jsonReport) bool {
+	comparison := compareBaseline(ctx.GlobalString("baseline"), baseline, *report)
+	report.Baseline = &comparison
+	if !isQuiet(ctx) {
+		printBaselineComparison(comparison)
+	}
+	return len(comparison.NewFailures) == 0
+}
+
+// runTestTargets - run the selected tests against every target of --target and write a
+// combined report of all of them. Returns false if the tests of any target failed. With
+// --baseline every target is compared with the report of the same target in the baseline.
+func runTestTargets(ctx *cli.Context) bool {
+	targets, err := newTestTargets(ctx)
+	if err != nil {
+		console.Fatalln(err)
+	}
+	var baselines map[string]jsonReport
+	if fileName := ctx.GlobalString("baseline"); fileName != "" {
+		if baselines, err = loadTargetsBaseline(fileName); err != nil {
+			console.Fatalln(err)
+		}
+	}
+	combined := jsonTargetsReport{
+		Passed:  true,
+		Targets: []jsonReport{},
+	}
+	junit := junitTestSuites{}
+	for _, target := range targets {
+		run, err := newRunContextFromContext(ctx)
+		if err != nil {
+			console.Fatalln(err)
+		}
+		if !run.quiet {
+			console.Printf("S3Verify testing target %s at %s:\n", target.name, target.config.Endpoint)
+		}
+		serverVersion := verifyServers(*target.config, run, target.recordDir, target.replayDir)
+		started := time.Now()
+		results := runUnPreparedTests(*target.config, run, target.selection)
+		report := newJSONReport(*target.config, serverVersion, started, results)
+		report.Target = target.name
+		passed := report.Passed
+		if baselines != nil {
+			// A target missing from the baseline compares every failure as a new one.
+			passed = compareWithBaseline(ctx, baselines[target.name], &report)
+		}
+		combined.Passed = combined.Passed && passed
+		combined.Targets = append(combined.Targets, report)
+		junit.Suites = append(junit.Suites, newJUnitTestSuite("s3verify."+target.name, target.config.Endpoint, started, results))
+	}
+	if fileName := ctx.GlobalString("report-junit"); fileName != "" {
+		if err := writeJUnitReport(fileName, junit); err != nil {
+			console.Fatalln(err)
+		}
+	}
+	if ctx.GlobalString("format") == "json" {
+		if err := printJSONReport(combined); err != nil {
+			console.Fatalln(err)
+		}
+	} else {
+		printTargetsSummary(combined)
+	}
+	return combined.Passed
+}
+
+// printTargetsSummary - print how many tests passed, failed and were skipped for every target.
+func printTargetsSummary(combined jsonTargetsReport) {
+	console.Println("Summary:")
+	for _, report := range combined.Targets {
+		status := "PASS"
+		if !report.Passed {
+			status = "FAIL"
+		}
+		counts := []string{}
+		for _, testStatus := range []TestStatus{TestPass, TestFail, TestXFail, TestSkip} {
+			if n := report.Summary[string(testStatus)]; n > 0 {
+				counts = append(counts, fmt.Sprintf("%d %s", n, testStatus))
+			}
+		}
+		console.Printf("  %s %s (%s): %s\n", status, report.Target, report.Endpoint, strings.Join(counts, ", "))
 	}
 }
 
 // runUnPreparedTests - run all tests if --prepare was not used.
-func runUnPreparedTests(config ServerConfig, testExtended bool) {
-	runTests(config, unpreparedTests, testExtended)
+func runUnPreparedTests(config ServerConfig, run *RunContext, selection testSelection) []TestResult {
+	return runTests(config, run, unpreparedTests, selection)
 }
 
 // runPreparedTests - run all previously prepared tests.
-func runPreparedTests(config ServerConfig, testExtended bool) {
-	runTests(config, preparedTests, testExtended)
+func runPreparedTests(config ServerConfig, run *RunContext, selection testSelection) []TestResult {
+	return runTests(config, run, preparedTests, selection)
 }
 
-// runTests - run all provided tests.
-func runTests(config ServerConfig, tests []APItest, testExtended bool) {
-	count := 1
-	for _, test := range tests {
-		if test.Extended {
-			// Only run extended tests if explicitly asked for.
-			if testExtended {
-				test.Test(config, count)
-				count++
-			}
-		} else {
-			if !test.Test(config, count) && test.Critical {
-				// If the test failed and it was critical exit immediately.
-				os.Exit(1)
+// runTests - run all provided tests that were selected by the user and collect their results.
+// Tests whose dependencies did not pass are skipped, every other test is still run.
+// With --parallel, tests marked as Parallel next to each other are run at the same time
+// and their results are printed in order once all of them finished.
+func runTests(config ServerConfig, run *RunContext, tests []APItest, selection testSelection) []TestResult {
+	tests = selection.filter(tests)
+	run.totalNumTest = len(tests)
+	// Keep track of the tests that passed to decide whether dependent tests can be run.
+	passed := make(map[string]bool)
+	results := []TestResult{}
+	for start := 0; start < len(tests); {
+		end := parallelGroupEnd(tests, start, run.parallel)
+		for _, result := range runParallelTests(config, run, tests[start:end], start, passed) {
+			// Known deviations do not prevent dependent tests from being run.
+			passed[result.Name] = result.Status == TestPass || result.Status == TestXFail
+			if !run.quiet {
+				printResult(result)
 			}
-			count++
+			results = append(results, result)
 		}
+		start = end
 	}
+	return results
+}
+
+// runSelectedTest - run a selected test, unless the profile, a dependency that did not pass
+// or the run being canceled means it must be skipped.
+func runSelectedTest(config ServerConfig, run *RunContext, test APItest, curTest int, passed map[string]bool) TestResult {
+	message := fmt.Sprintf("[%02d/%d] %s:", curTest, run.totalNumTest, test.Name)
+	if reason, ok := config.profile.skipReason(test.Name); ok {
+		return TestResult{
+			Name:    test.Name,
+			Message: message,
+			Status:  TestSkip,
+			Err:     errors.New(reason),
+		}
+	}
+	if err := config.canceled(); err != nil {
+		return TestResult{
+			Name:    test.Name,
+			Message: message,
+			Status:  TestSkip,
+			Err:     err,
+		}
+	}
+	if failedDep := firstFailedDependency(test, passed); failedDep != "" {
+		return TestResult{
+			Name:    test.Name,
+			Message: message,
+			Status:  TestSkip,
+			Err:     fmt.Errorf("Depends on %s which did not pass.", failedDep),
+		}
+	}
+	return config.profile.applyTo(runTest(config, run, test, curTest))
+}
+
+// testsPassed - check that no test failed unexpectedly.
+// Tests are only skipped if they were not meant to be run or because a test they depend on failed.
+func testsPassed(results []TestResult) bool {
+	for _, result := range results {
+		if result.Status == TestFail {
+			return false
+		}
+	}
+	return true
 }
 
 // Main - Set up and run the app.
 func Main() {
 	app := registerApp()
 	app.Before = func(ctx *cli.Context) error {
-		setGlobalsFromContext(ctx)
+		if err := setGlobalsFromContext(ctx); err != nil {
+			console.Fatalln(err)
+		}
 		return nil
 	}
 	app.RunAndExitOnError()
@@ -0,0 +1,82 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import "sync"
+
+// parallelGroupEnd - return the index after the last test of the group starting at start.
+// A group is a single test, or with --parallel the tests marked as Parallel next to each
+// other that do not depend on one another.
+func parallelGroupEnd(tests []APItest, start int, parallel int) int {
+	if parallel <= 1 || !tests[start].Parallel {
+		return start + 1
+	}
+	inGroup := make(map[string]bool)
+	end := start
+	for ; end < len(tests) && tests[end].Parallel; end++ {
+		for _, dep := range tests[end].Depends {
+			// The test depends on a test of the group, it must be run after it.
+			if inGroup[dep] {
+				return end
+			}
+		}
+		inGroup[tests[end].Name] = true
+	}
+	return end
+}
+
+// runParallelTests - run a group of tests, see parallelGroupEnd, and return their results in order.
+// first is the index of the first test of the group among every selected test.
+func runParallelTests(config ServerConfig, run *RunContext, tests []APItest, first int, passed map[string]bool) []TestResult {
+	results := make([]TestResult, len(tests))
+	if len(tests) == 1 {
+		results[0] = runSelectedTest(config, run, tests[0], first+1, passed)
+		return results
+	}
+	// The progress bars of tests running at the same time would garble
+	// each other, only their results are printed.
+	quiet := run.quiet
+	run.quiet = true
+	run.forEach(len(tests), func(i int) {
+		results[i] = runSelectedTest(config, run, tests[i], first+i+1, passed)
+	})
+	run.quiet = quiet
+	return results
+}
+
+// forEach - call f for every index from 0 to n-1, running up to run.parallel of them at the same time.
+func (run *RunContext) forEach(n int, f func(i int)) {
+	if run.parallel <= 1 {
+		for i := 0; i < n; i++ {
+			f(i)
+		}
+		return
+	}
+	var wg sync.WaitGroup
+	// Every running call holds a slot until it returns.
+	slots := make(chan struct{}, run.parallel)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			f(i)
+			<-slots
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,143 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// accessLog - the keys every test reads and writes and the buckets it lists, by test name.
+// Keys are bucket/object, or bucket/?policy for the policy of a bucket.
+type accessLog struct {
+	next http.Handler
+
+	mutex   sync.Mutex
+	current string // Name of the test being run.
+	reads   map[string]map[string]bool
+	writes  map[string]map[string]bool
+	lists   map[string]map[string]bool
+}
+
+// newAccessLog - log the accesses of every request served by next.
+func newAccessLog(next http.Handler) *accessLog {
+	return &accessLog{
+		next:   next,
+		reads:  make(map[string]map[string]bool),
+		writes: make(map[string]map[string]bool),
+		lists:  make(map[string]map[string]bool),
+	}
+}
+
+// add - log key as accessed by the current test.
+func (l *accessLog) add(accesses map[string]map[string]bool, key string) {
+	if accesses[l.current] == nil {
+		accesses[l.current] = make(map[string]bool)
+	}
+	accesses[l.current][key] = true
+}
+
+// ServeHTTP - log what r accesses and serve it with the wrapped server.
+func (l *accessLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mutex.Lock()
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket := parts[0]
+	switch {
+	case hasQuery(r, "policy"):
+		if r.Method == "GET" {
+			l.add(l.reads, bucket+"/?policy")
+		} else {
+			l.add(l.writes, bucket+"/?policy")
+		}
+	case isObjectRequest(r):
+		key := bucket + "/" + parts[1]
+		if r.Method == "GET" || r.Method == "HEAD" {
+			l.add(l.reads, key)
+		} else {
+			l.add(l.writes, key)
+		}
+		if source := r.Header.Get("X-Amz-Copy-Source"); source != "" {
+			if unescaped, err := url.QueryUnescape(source); err == nil {
+				source = unescaped
+			}
+			l.add(l.reads, strings.TrimPrefix(source, "/"))
+		}
+	case r.Method == "GET" && bucket != "":
+		l.add(l.lists, bucket)
+	}
+	l.mutex.Unlock()
+	l.next.ServeHTTP(w, r)
+}
+
+// TestParallelContract - the tests marked as Parallel only read what earlier tests created, and only
+// write keys no other test of their group reads, writes or lists, so their groups can run at the same time.
+func TestParallelContract(t *testing.T) {
+	setGlobals(false)
+	log := newAccessLog(s3mem.New(testAccessKey, testSecretKey, testRegion))
+	server := httptest.NewServer(log)
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Skip the presigned GET test which waits for its URL to expire, and the
+	// tests of temporary credentials.
+	selection := testSelection{extended: true, skip: regexp.MustCompile("^(GetObject/Presigned|SessionToken/.*)$")}
+	// Run the tests one after the other, so that every request is logged as made by its test.
+	tests := []APItest{}
+	for _, test := range selection.filter(unpreparedTests) {
+		test := test
+		runTest := test.Test
+		test.Test = func(config ServerConfig, run *RunContext, curTest int) TestResult {
+			log.mutex.Lock()
+			log.current = test.Name
+			log.mutex.Unlock()
+			return runTest(config, run, curTest)
+		}
+		tests = append(tests, test)
+	}
+	for _, result := range runTests(*config, newQuietRunContext("contract", 1), tests, testSelection{}) {
+		if result.Status != TestPass {
+			t.Fatalf("%s: expected %s, got %s: %v", result.Name, TestPass, result.Status, result.Err)
+		}
+	}
+	for start := 0; start < len(tests); {
+		end := parallelGroupEnd(tests, start, len(tests))
+		group := tests[start:end]
+		for _, writer := range group {
+			for key := range log.writes[writer.Name] {
+				bucket := strings.SplitN(key, "/", 2)[0]
+				for _, other := range group {
+					if other.Name == writer.Name {
+						continue
+					}
+					if log.reads[other.Name][key] || log.writes[other.Name][key] || log.lists[other.Name][bucket] {
+						t.Errorf("%s writes %s which %s of the same parallel group accesses", writer.Name, key, other.Name)
+					}
+				}
+			}
+		}
+		start = end
+	}
+}
@@ -22,11 +22,12 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"math/rand"
 	"mime/multipart"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/minio/s3verify/signv2"
 	"github.com/minio/s3verify/signv4"
 )
 
@@ -40,28 +41,12 @@ func newPostObjectReq(config ServerConfig, bucketName, objectName string, object
 		objectName:   "",
 		customHeader: http.Header{},
 	}
-	// Keep time.
-	t := time.Now().UTC()
 	// Expire the request five minutes from now.
-	expirationTime := t.Add(time.Minute * 5)
-	// Get the user credential.
-	credential := signv4.GetCredential(config.Access, config.Region, t)
-	// Create a new post policy.
-	policy := newPostPolicyBytes(credential, bucketName, objectName, expirationTime)
-	// Only need the encoding.
-	encodedPolicy := base64.StdEncoding.EncodeToString(policy)
-
-	// Presign with V4 signature based on the policy.
-	signature := signv4.PostPresignSignatureV4(encodedPolicy, t, config.Secret, config.Region)
-
-	formData := map[string]string{
-		"bucket":           bucketName,
-		"key":              objectName,
-		"x-amz-credential": credential,
-		"policy":           encodedPolicy,
-		"x-amz-signature":  signature,
-		"x-amz-date":       t.Format(iso8601DateFormat),
-		"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+	expirationTime := time.Now().UTC().Add(time.Minute * 5)
+	formData := newPostFormData(config, bucketName, objectName, expirationTime)
+	// Temporary credentials are only valid along with their session token.
+	if config.SessionToken != "" {
+		formData["x-amz-security-token"] = config.SessionToken
 	}
 
 	// Create the multipart form.
@@ -94,11 +79,56 @@ func newPostObjectReq(config ServerConfig, bucketName, objectName string, object
 	// Set the headers.
 	postPolicyReq.customHeader.Set("Content-Type", w.FormDataContentType())
 	postPolicyReq.customHeader.Set("User-Agent", appUserAgent)
-	postPolicyReq.customHeader.Set("Content-Length", string(contentLength))
+	postPolicyReq.customHeader.Set("Content-Length", strconv.FormatInt(contentLength, 10))
+	// The form is signed for this server only, the reference server needs a form of its own.
+	if config.reference != nil {
+		referenceReq, err := newPostObjectReq(*config.reference, bucketName, objectName, objectData)
+		if err != nil {
+			return Request{}, err
+		}
+		postPolicyReq.reference = &referenceReq
+	}
 	// return req, nil
 	return postPolicyReq, nil
 }
 
+// newPostFormData - the fields of a POST form signed for config by a policy that expires at expirationTime.
+func newPostFormData(config ServerConfig, bucketName, objectName string, expirationTime time.Time) map[string]string {
+	if config.signature == signatureV2 {
+		// Create a new post policy without the fields of Signature Version 4.
+		policy := newPostPolicyV2Bytes(config.SessionToken, bucketName, objectName, expirationTime)
+		encodedPolicy := base64.StdEncoding.EncodeToString(policy)
+		return map[string]string{
+			"bucket":         bucketName,
+			"key":            objectName,
+			"AWSAccessKeyId": config.Access,
+			"policy":         encodedPolicy,
+			"signature":      signv2.PostPresignSignatureV2(encodedPolicy, config.Secret),
+		}
+	}
+	// Keep time.
+	t := time.Now().UTC()
+	// Get the user credential.
+	credential := signv4.GetCredential(config.Access, config.Region, t)
+	// Create a new post policy.
+	policy := newPostPolicyBytes(credential, config.SessionToken, bucketName, objectName, expirationTime)
+	// Only need the encoding.
+	encodedPolicy := base64.StdEncoding.EncodeToString(policy)
+
+	// Presign with V4 signature based on the policy.
+	signature := signv4.PostPresignSignatureV4(encodedPolicy, t, config.Secret, config.Region)
+
+	return map[string]string{
+		"bucket":           bucketName,
+		"key":              objectName,
+		"x-amz-credential": credential,
+		"policy":           encodedPolicy,
+		"x-amz-signature":  signature,
+		"x-amz-date":       t.Format(iso8601DateFormat),
+		"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+	}
+}
+
 // postObjectVerify - verify the response returned.
 func postObjectVerify(res *http.Response, expectedStatusCode int, expectedError ErrorResponse) error {
 	if err := verifyHeaderPostObject(res.Header); err != nil {
@@ -156,80 +186,56 @@ func verifyBodyPostObject(resBody io.Reader, expectedError ErrorResponse) error
 	return nil
 }
 
-// mainPostObject - entry point for the postobject test.
-func mainPostObject(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] PostObject:", curTest, globalTotalNumTest)
-	// Spin scanBar
-	scanBar(message)
-
-	// Post the object to the s3verify created bucket.
-	bucketName := s3verifyBuckets[0].Name
-	testObject := &ObjectInfo{
-		Key:  "s3verify/put/object/post",
-		Body: []byte(randString(60, rand.NewSource(time.Now().UnixNano()), "s3verify post data: ")),
-	}
-
-	// Spin scanBar
-	scanBar(message)
+// postObjectCheck - POST objectName to bucketName and verify the response.
+func postObjectCheck(config ServerConfig, bucketName, objectName string, objectData []byte, expectedStatusCode int, expectedError ErrorResponse) error {
 	// Create a new request.
-	req, err := newPostObjectReq(config, bucketName, testObject.Key, testObject.Body)
+	req, err := newPostObjectReq(config, bucketName, objectName, objectData)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return err
 	}
-
 	// Execute the request.
-	// res, err := config.Client.Do(req)
 	res, err := config.execRequest("POST", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return err
 	}
 	defer closeResponse(res)
-	// Spin scanBar
-	scanBar(message)
 	// Verify the response.
-	if err := postObjectVerify(res, http.StatusNoContent, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+	return postObjectVerify(res, expectedStatusCode, expectedError)
+}
+
+// mainPostObject - entry point for the postobject test.
+func mainPostObject(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PostObject:", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+
+	// Post the object to the s3verify created bucket.
+	bucketName := run.buckets[0].Name
+	testObject := &ObjectInfo{
+		Key:  "s3verify/put/object/post",
+		Body: []byte(randString(60, run.newRandSource(), "s3verify post data: ")),
+	}
+
+	result := newTestResult(message, nil)
+	// Spin scanBar
+	run.scanBar(message)
+	err := postObjectCheck(config, bucketName, testObject.Key, testObject.Body, http.StatusNoContent, ErrorResponse{})
+	if err == nil {
+		// Store this object in the global list of objects only if the upload succeeded.
+		run.objects = append(run.objects, testObject)
 	}
-	// Store this object in the global list of objects only if test passed.
-	s3verifyObjects = append(s3verifyObjects, testObject)
+	result.addCheck(config, "Valid", err)
 
 	// Create a bad request to test error response.
 	expectedError := ErrorResponse{
 		Message: "The specified bucket does not exist",
 	}
 	// Send the request to a non existent bucket.
-	bucketName = randString(60, rand.NewSource(time.Now().UnixNano()), "")
+	invalidBucketName := randString(60, run.newRandSource(), "")
 	// Spin scanBar
-	scanBar(message)
-	// Create a new request.
-	badReq, err := newPostObjectReq(config, bucketName, testObject.Key, testObject.Body)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	// Spin scanBar
-	scanBar(message)
-	// Execute the request.
-	// 	badRes, err := config.Client.Do(badReq)
-	badRes, err := config.execRequest("POST", badReq)
-	if err != nil {
-		printMessage(message, err)
-		return false
-	}
-	defer closeResponse(badRes)
-	// Spin scanBar
-	scanBar(message)
-	// Verify the bad request failed appropriately.
-	if err := postObjectVerify(badRes, http.StatusNotFound, expectedError); err != nil {
-		printMessage(message, err)
-		return false
-	}
+	run.scanBar(message)
+	result.addCheck(config, "BucketDNE", postObjectCheck(config, invalidBucketName, testObject.Key, testObject.Body, http.StatusNotFound, expectedError))
 	// Spin scanBar
-	scanBar(message)
-	// Test passed.
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return result
 }
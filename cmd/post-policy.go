@@ -27,7 +27,8 @@ const expirationDateFormat = "2006-01-02T15:04:05.999Z"
 // Should create some invalid cases to check for error messages.
 
 // newPostPolicyBytes - creates a bare bones postpolicy string with key and bucket matches.
-func newPostPolicyBytes(credential, bucketName, objectKey string, expiration time.Time) []byte {
+// The session token of temporary credentials must be matched as well, it is left out if empty.
+func newPostPolicyBytes(credential, sessionToken, bucketName, objectKey string, expiration time.Time) []byte {
 	t := time.Now().UTC()
 	// Add the expiration date.
 	expirationStr := fmt.Sprintf(`"expiration": "%s"`, expiration.Format(expirationDateFormat))
@@ -43,7 +44,12 @@ func newPostPolicyBytes(credential, bucketName, objectKey string, expiration tim
 	credentialConditionStr := fmt.Sprintf(`["eq", "$x-amz-credential", "%s"]`, credential)
 
 	// Combine all conditions into one string.
-	conditionStr := fmt.Sprintf(`"conditions":[%s, %s, %s, %s, %s]`, bucketConditionStr, keyConditionStr, algorithmConditionStr, dateConditionStr, credentialConditionStr)
+	conditionStr := fmt.Sprintf(`"conditions":[%s, %s, %s, %s, %s`, bucketConditionStr, keyConditionStr, algorithmConditionStr, dateConditionStr, credentialConditionStr)
+	if sessionToken != "" {
+		// Add the session token condition, only accept the token passed.
+		conditionStr += fmt.Sprintf(`, ["eq", "$x-amz-security-token", "%s"]`, sessionToken)
+	}
+	conditionStr += "]"
 	retStr := "{"
 	retStr = retStr + expirationStr + ","
 	retStr = retStr + conditionStr
@@ -51,3 +57,24 @@ func newPostPolicyBytes(credential, bucketName, objectKey string, expiration tim
 
 	return []byte(retStr)
 }
+
+// newPostPolicyV2Bytes - creates a bare bones postpolicy string with key and bucket matches
+// for forms signed with Signature Version 2, which sign with the access key instead of a credential.
+// The session token of temporary credentials must be matched as well, it is left out if empty.
+func newPostPolicyV2Bytes(sessionToken, bucketName, objectKey string, expiration time.Time) []byte {
+	// Add the expiration date.
+	expirationStr := fmt.Sprintf(`"expiration": "%s"`, expiration.Format(expirationDateFormat))
+	// Add the bucket condition, only accept buckets equal to the one passed.
+	bucketConditionStr := fmt.Sprintf(`["eq", "$bucket", "%s"]`, bucketName)
+	// Add the key condition, only accept keys equal to the one passed.
+	keyConditionStr := fmt.Sprintf(`["eq", "$key", "%s"]`, objectKey)
+
+	// Combine all conditions into one string.
+	conditionStr := fmt.Sprintf(`"conditions":[%s, %s`, bucketConditionStr, keyConditionStr)
+	if sessionToken != "" {
+		// Add the session token condition, only accept the token passed.
+		conditionStr += fmt.Sprintf(`, ["eq", "$x-amz-security-token", "%s"]`, sessionToken)
+	}
+	conditionStr += "]"
+	return []byte("{" + expirationStr + "," + conditionStr + "}")
+}
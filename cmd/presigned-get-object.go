@@ -25,8 +25,8 @@ import (
 	"time"
 )
 
-// newGetObjectPresignedReq - create a new request for GetObject with a presigned URL.
-func newGetObjectPresignedReq(config ServerConfig, bucketName, objectName string, expires time.Duration, requestParameters url.Values) (*url.URL, error) {
+// newGetObjectPresignedRequest - create a new Request for GetObject that will be presigned when sent.
+func newGetObjectPresignedRequest(bucketName, objectName string, expires time.Duration, requestParameters url.Values) Request {
 	// getObjectPresignedReq - represents a request for GetObject with a presigned URL
 	var getObjectPresignedReq = Request{
 		customHeader: http.Header{},
@@ -45,7 +45,12 @@ func newGetObjectPresignedReq(config ServerConfig, bucketName, objectName string
 	// Set the request parameters.
 	getObjectPresignedReq.queryValues = requestParameters
 
-	req, err := config.newRequest("GET", getObjectPresignedReq)
+	return getObjectPresignedReq
+}
+
+// newGetObjectPresignedReq - create a new presigned URL for GetObject.
+func newGetObjectPresignedReq(config ServerConfig, bucketName, objectName string, expires time.Duration, requestParameters url.Values) (*url.URL, error) {
+	req, err := config.newRequest("GET", newGetObjectPresignedRequest(bucketName, objectName, expires, requestParameters))
 	if err != nil {
 		return nil, err
 	}
@@ -109,41 +114,46 @@ func verifyHeaderGetObjectPresigned(header http.Header) error {
 }
 
 // mainGetObjectPresigned - test the compliance of the GetObject API using presigned URLs.
-func mainGetObjectPresigned(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] GetObject (Presigned):", curTest, globalTotalNumTest)
+func mainGetObjectPresigned(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (Presigned):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Save an expired presigned url for testing the error response.
 	var expiredURL *url.URL
 	// Presigned getobject will only be tested in s3verify created buckets
 	// on s3verify created objects.
-	bucketName := s3verifyBuckets[0].Name
-	testObject := s3verifyObjects[0]
+	bucketName := run.buckets[0].Name
+	testObject := run.objects[0]
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new presigned GetObject req.
 	// TODO: so far these requests do not use request/response parameters.
 	reqURL, err := newGetObjectPresignedReq(config, bucketName, testObject.Key, time.Second*5, nil)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Store the created URL and make sure it expires later.
 	expiredURL = reqURL
 	// Execute the request.
-	res, err := config.Client.Get(reqURL.String())
+	res, err := getPresignedURL(config, reqURL, bucketName, testObject.Key)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
+	// Download the same object from the reference server with a URL presigned for it.
+	// The expired URL is not compared, the reference server would only get a fresh one.
+	if config.reference != nil {
+		referenceReq := newGetObjectPresignedRequest(bucketName, testObject.Key, time.Second*5, nil)
+		if err := config.diffWithReference("GET", referenceReq, res); err != nil {
+			return newTestResult(message, err)
+		}
+	}
 	// Verify the response.
 	if err := getObjectPresignedVerify(res, http.StatusOK, testObject.Body, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Make sure the saved URL has expired.
 	time.Sleep(time.Second * 5)
 	// Create the expected error.
@@ -151,22 +161,19 @@ func mainGetObjectPresigned(config ServerConfig, curTest int) bool {
 		Message: "Request has expired",
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Attempt to use the expired url.
-	badRes, err := config.Client.Get(expiredURL.String())
+	badRes, err := getPresignedURL(config, expiredURL, bucketName, testObject.Key)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(badRes)
 	// Verify that this badRes failed as expected.
 	if err := getObjectPresignedVerify(badRes, http.StatusForbidden, testObject.Body, expectedError); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -0,0 +1,283 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Longest expiry of presigned URLs AWS S3 accepts, a week in seconds.
+const maxPresignedExpiry = 7 * 24 * time.Hour
+
+// presignedTamperCheck - a presigned URL changed after presigning and the error the server must reject it with.
+type presignedTamperCheck struct {
+	name string
+	// Method the URL is presigned for and method it is sent with.
+	presignMethod string
+	sendMethod    string
+	// Change the query or the headers of the presigned request.
+	tamper             func(query url.Values, header http.Header)
+	expectedStatusCode int
+	expectedCode       string
+}
+
+// tamperQuery - replace the value of key with the value returned by change.
+func tamperQuery(key string, change func(string) string) func(url.Values, http.Header) {
+	return func(query url.Values, header http.Header) {
+		query.Set(key, change(query.Get(key)))
+	}
+}
+
+// shiftPresignedDate - move an X-Amz-Date by a second, staying on the day of the credential scope.
+func shiftPresignedDate(date string) string {
+	t, err := time.Parse(iso8601DateFormat, date)
+	if err != nil {
+		return date
+	}
+	shifted := t.Add(-time.Second)
+	if shifted.Day() != t.Day() {
+		shifted = t.Add(time.Second)
+	}
+	return shifted.Format(iso8601DateFormat)
+}
+
+// flipSignature - change the first hexadecimal digit of a signature.
+func flipSignature(signature string) string {
+	if signature == "" {
+		return signature
+	}
+	if signature[0] >= 'a' {
+		return "0" + signature[1:]
+	}
+	return "f" + signature[1:]
+}
+
+// newPresignedURL - presign a request of method for objectName, with the response header overrides
+// of requestParameters, valid for expires.
+func newPresignedURL(config ServerConfig, method, bucketName, objectName string, expires time.Duration, requestParameters url.Values) (*url.URL, error) {
+	if method == "PUT" {
+		return newPresignedPutObjectReq(config, bucketName, objectName, expires)
+	}
+	return newGetObjectPresignedReq(config, bucketName, objectName, expires, requestParameters)
+}
+
+// tamperPresignedToken - change the session token of a presigned URL, or add one to a URL presigned
+// with long term credentials.
+func tamperPresignedToken(sessionToken string) string {
+	if sessionToken == "" {
+		return "s3verify-session-token"
+	}
+	return tamperSessionToken(sessionToken)
+}
+
+// getPresignedURL - download the object at a presigned URL.
+func getPresignedURL(config ServerConfig, reqURL *url.URL, bucketName, objectName string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", appUserAgent)
+	return config.doSignedRequest(req, bucketName, objectName)
+}
+
+// presignedTamperVerify - send the tampered request of check and verify that the server rejects it. Uploads
+// must not have stored their object, downloads must not have returned it.
+func presignedTamperVerify(config ServerConfig, run *RunContext, bucketName string, object *ObjectInfo, data []byte, check presignedTamperCheck) error {
+	reqURL, err := newPresignedURL(config, check.presignMethod, bucketName, object.Key, time.Minute, nil)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	header.Set("User-Agent", appUserAgent)
+	query := reqURL.Query()
+	check.tamper(query, header)
+	reqURL.RawQuery = query.Encode()
+	var body []byte
+	if check.sendMethod == "PUT" {
+		body = data
+	}
+	req, err := http.NewRequest(check.sendMethod, reqURL.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header = header
+	res, err := config.doSignedRequest(req, bucketName, object.Key)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+	rejectedErr := verifyErrorCode(res, check.expectedStatusCode, check.expectedCode)
+	if check.sendMethod != "PUT" {
+		return rejectedErr
+	}
+	// Nothing may have been stored by the rejected upload.
+	if err := verifyObjectNotStored(config, run, bucketName, object); err != nil {
+		return err
+	}
+	return rejectedErr
+}
+
+// mainGetObjectPresignedTampered - test that presigned URLs changed after presigning are rejected.
+func mainGetObjectPresignedTampered(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (Presigned Tampered):", curTest, run.totalNumTest)
+	result := newTestResult(message, nil)
+	// The tampered requests are only sent to the tested server.
+	config.reference = nil
+	bucketName := run.buckets[0].Name
+	testObject := run.objects[0]
+	checks := []presignedTamperCheck{
+		{"Algorithm", "GET", "GET", tamperQuery("X-Amz-Algorithm", func(string) string { return "AWS4-HMAC-SHA1" }), http.StatusBadRequest, "AuthorizationQueryParametersError"},
+		{"Credential", "GET", "GET", tamperQuery("X-Amz-Credential", func(credential string) string {
+			return strings.Replace(credential, config.Access+"/", config.Access+"X/", 1)
+		}), http.StatusForbidden, "InvalidAccessKeyId"},
+		// The session token is checked before the signature it is part of.
+		{"SecurityToken", "GET", "GET", tamperQuery("X-Amz-Security-Token", tamperPresignedToken), http.StatusBadRequest, "InvalidToken"},
+		{"Date", "GET", "GET", tamperQuery("X-Amz-Date", shiftPresignedDate), http.StatusForbidden, "SignatureDoesNotMatch"},
+		{"Expires", "GET", "GET", tamperQuery("X-Amz-Expires", func(string) string { return "61" }), http.StatusForbidden, "SignatureDoesNotMatch"},
+		{"SignedHeaders", "GET", "GET", tamperQuery("X-Amz-SignedHeaders", func(string) string { return "host;user-agent" }), http.StatusForbidden, "SignatureDoesNotMatch"},
+		{"Signature", "GET", "GET", tamperQuery("X-Amz-Signature", flipSignature), http.StatusForbidden, "SignatureDoesNotMatch"},
+		// A URL presigned for downloading an object can not upload it.
+		{"Method", "GET", "PUT", func(url.Values, http.Header) {}, http.StatusForbidden, "SignatureDoesNotMatch"},
+		// Metadata can not be added to a presigned upload.
+		{"UnsignedHeader", "PUT", "PUT", func(query url.Values, header http.Header) {
+			header.Set("X-Amz-Meta-Unsigned", "s3verify")
+		}, http.StatusForbidden, "AccessDenied"},
+	}
+	for _, check := range checks {
+		// Spin scanBar
+		run.scanBar(message)
+		object := testObject
+		if check.sendMethod == "PUT" {
+			// Uploads go to objects of their own, which must not exist afterwards.
+			object = &ObjectInfo{Key: "s3verify/presigned/tampered/" + strings.ToLower(check.name)}
+		}
+		result.addCheck(config, check.name, presignedTamperVerify(config, run, bucketName, object, testObject.Body, check))
+	}
+	return result
+}
+
+// presignedExpiryVerify - download objectName with a URL presigned for expires once wait passed and
+// verify the response, the object if expectedCode is empty.
+func presignedExpiryVerify(config ServerConfig, bucketName string, object *ObjectInfo, expires, wait time.Duration, expectedStatusCode int, expectedCode string) error {
+	reqURL, err := newPresignedURL(config, "GET", bucketName, object.Key, expires, nil)
+	if err != nil {
+		return err
+	}
+	time.Sleep(wait)
+	res, err := getPresignedURL(config, reqURL, bucketName, object.Key)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+	if expectedCode != "" {
+		return verifyErrorCode(res, expectedStatusCode, expectedCode)
+	}
+	return getObjectPresignedVerify(res, expectedStatusCode, object.Body, ErrorResponse{})
+}
+
+// presignedExpiredWait - how long to wait for a URL presigned now for expires to have expired on the server.
+// The clock of the server, read from the Date header of a HEAD request, may be behind the one the URL is
+// signed with, and the Date header is only precise to the second.
+func presignedExpiredWait(config ServerConfig, bucketName string, expires time.Duration) (time.Duration, error) {
+	req, err := newHeadBucketReq(bucketName)
+	if err != nil {
+		return 0, err
+	}
+	res, err := config.execRequest("HEAD", req)
+	if err != nil {
+		return 0, err
+	}
+	defer closeResponse(res)
+	serverDate, err := time.Parse(http.TimeFormat, res.Header.Get("Date"))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid Date Header Received: %v", res.Header.Get("Date"))
+	}
+	wait := expires + 2*time.Second
+	if skew := time.Now().Sub(serverDate); skew > 0 {
+		wait += skew
+	}
+	return wait, nil
+}
+
+// mainGetObjectPresignedExpiry - test that presigned URLs are valid for as long as they were presigned for and no longer,
+// and that they can not be presigned for longer than a week.
+func mainGetObjectPresignedExpiry(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (Presigned Expiry):", curTest, run.totalNumTest)
+	result := newTestResult(message, nil)
+	// The expired URLs are only sent to the tested server.
+	config.reference = nil
+	bucketName := run.buckets[0].Name
+	testObject := run.objects[0]
+	// Spin scanBar
+	run.scanBar(message)
+	wait, err := presignedExpiredWait(config, bucketName, time.Second)
+	if err == nil {
+		err = presignedExpiryVerify(config, bucketName, testObject, time.Second, wait, http.StatusForbidden, "AccessDenied")
+	}
+	result.addCheck(config, "Expired", err)
+	// Spin scanBar
+	run.scanBar(message)
+	result.addCheck(config, "Maximum", presignedExpiryVerify(config, bucketName, testObject, maxPresignedExpiry, 0, http.StatusOK, ""))
+	// Spin scanBar
+	run.scanBar(message)
+	result.addCheck(config, "TooLong", presignedExpiryVerify(config, bucketName, testObject, maxPresignedExpiry+time.Second, 0, http.StatusBadRequest, "AuthorizationQueryParametersError"))
+	return result
+}
+
+// mainGetObjectPresignedResponseHeaders - test that the response header overrides signed into a presigned URL are honored.
+func mainGetObjectPresignedResponseHeaders(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] GetObject (Presigned Response Headers):", curTest, run.totalNumTest)
+	// Spin scanBar
+	run.scanBar(message)
+	expectedHeaders := map[string]string{
+		"response-content-type":        "image/gif",
+		"response-content-disposition": "attachment; filename=\"s3verify.txt\"",
+	}
+	requestParameters := url.Values{}
+	for k, v := range expectedHeaders {
+		requestParameters.Set(k, v)
+	}
+	bucketName := run.buckets[0].Name
+	testObject := run.objects[0]
+	reqURL, err := newPresignedURL(config, "GET", bucketName, testObject.Key, time.Minute, requestParameters)
+	if err != nil {
+		return newTestResult(message, err)
+	}
+	res, err := getPresignedURL(config, reqURL, bucketName, testObject.Key)
+	if err != nil {
+		return newTestResult(message, err)
+	}
+	defer closeResponse(res)
+	// Download the same object from the reference server with a URL presigned for it.
+	if config.reference != nil {
+		referenceReq := newGetObjectPresignedRequest(bucketName, testObject.Key, time.Minute, requestParameters)
+		if err := config.diffWithReference("GET", referenceReq, res); err != nil {
+			return newTestResult(message, err)
+		}
+	}
+	if err := getObjectVerify(res, testObject.Body, http.StatusOK, expectedHeaders, ErrorResponse{}); err != nil {
+		return newTestResult(message, err)
+	}
+	// Spin scanBar
+	run.scanBar(message)
+	return newTestResult(message, nil)
+}
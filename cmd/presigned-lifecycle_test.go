@@ -0,0 +1,90 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// TestGetObjectPresignedTamperedCounted - every tampered request is counted and recorded.
+func TestGetObjectPresignedTamperedCounted(t *testing.T) {
+	results := runSuite(t, s3mem.New(testAccessKey, testSecretKey, testRegion), testSelection{run: regexp.MustCompile("^GetObject/Presigned/Tampered$")})
+	result := results["GetObject/Presigned/Tampered"]
+	if result.Status != TestPass {
+		t.Fatalf("Expected %s, got %s: %v", TestPass, result.Status, result.Err)
+	}
+	for _, check := range result.Checks {
+		if check.Requests == 0 || check.Response.StatusCode == 0 {
+			t.Errorf("%s: expected the tampered request to be recorded, got %d requests", check.Name, check.Requests)
+		}
+	}
+}
+
+// TestGetObjectPresignedTamperedCleanup - tampered uploads stored by a broken server are removed
+// along with the other objects, so that the buckets of the run can still be removed.
+func TestGetObjectPresignedTamperedCleanup(t *testing.T) {
+	handler := storingServer{
+		next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+		accept: func(r *http.Request) bool {
+			return strings.Contains(r.URL.Path, "/s3verify/presigned/tampered/")
+		},
+	}
+	results := runSuite(t, handler, testSelection{run: regexp.MustCompile("^(GetObject/Presigned/Tampered|RemoveBucket)$")})
+	expected := map[string]TestStatus{
+		"GetObject/Presigned/Tampered": TestFail,
+		"RemoveObject":                 TestPass,
+		"RemoveBucket":                 TestPass,
+	}
+	for name, status := range expected {
+		if result := results[name]; result.Status != status {
+			t.Errorf("%s: expected %s, got %s: %v", name, status, result.Status, result.Err)
+		}
+	}
+}
+
+// TestPresignedExpiredWait - the wait for a presigned URL to expire covers a server clock behind the one of the client.
+func TestPresignedExpiredWait(t *testing.T) {
+	for _, behind := range []time.Duration{0, 5 * time.Second} {
+		handler := brokenServer{
+			next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+			mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+				rec.HeaderMap.Set("Date", time.Now().Add(-behind).UTC().Format(http.TimeFormat))
+			},
+		}
+		server := httptest.NewServer(handler)
+		config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wait, err := presignedExpiredWait(*config, "s3verify-wait", time.Second)
+		server.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The Date header is only precise to the second.
+		if wait < time.Second+behind+time.Second || wait > time.Second+behind+4*time.Second {
+			t.Errorf("Server %v behind: expected a wait of %v plus a margin, got %v", behind, time.Second+behind, wait)
+		}
+	}
+}
@@ -21,14 +21,13 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"time"
 )
 
-// newPresignedPutObjectReq - Create a new Request for PUT object requests using presigned URLs.
-func newPresignedPutObjectReq(config ServerConfig, bucketName, objectName string, expires time.Duration) (*url.URL, error) {
+// newPresignedPutObjectRequest - Create a new Request for PUT object requests that will be presigned when sent.
+func newPresignedPutObjectRequest(bucketName, objectName string, expires time.Duration) Request {
 	// presignedPutObjectReq - a new request with presigned URL for PUT object requests.
 	var presignedPutObjectReq = Request{
 		customHeader: http.Header{},
@@ -45,8 +44,13 @@ func newPresignedPutObjectReq(config ServerConfig, bucketName, objectName string
 	expireSeconds := int64(expires / time.Second)
 	presignedPutObjectReq.expires = expireSeconds
 
+	return presignedPutObjectReq
+}
+
+// newPresignedPutObjectReq - Create a new presigned URL for PUT object requests.
+func newPresignedPutObjectReq(config ServerConfig, bucketName, objectName string, expires time.Duration) (*url.URL, error) {
 	// Extract the url from the Request.
-	req, err := config.newRequest("PUT", presignedPutObjectReq)
+	req, err := config.newRequest("PUT", newPresignedPutObjectRequest(bucketName, objectName, expires))
 	if err != nil {
 		return nil, err
 	}
@@ -112,52 +116,57 @@ func verifyBodyPresignedPutObject(resBody io.Reader, expectedError ErrorResponse
 
 // THIS MIGHT CAUSE PROBLEMS HAVE TO CHECK LIST OBJECTS AFTER THIS IS DONE.
 // mainPresignedPutObject - test the compatibility of the presigned PutObject API.
-func mainPresignedPutObject(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] PutObject (Presigned):", curTest, globalTotalNumTest)
+func mainPresignedPutObject(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PutObject (Presigned):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// New objects will only be added to s3verify created buckets.
-	bucketName := s3verifyBuckets[0].Name
+	bucketName := run.buckets[0].Name
 	// Prefix this object differently to allow ListObjects to function easier.
-	objectName := randString(60, rand.NewSource(time.Now().UnixNano()), "s3verify/presigned/object/00")
+	objectName := randString(60, run.newRandSource(), "s3verify/presigned/object/00")
 
 	presignedObject := &ObjectInfo{
 		Key:  objectName,
-		Body: []byte(randString(60, rand.NewSource(time.Now().UnixNano()), "a")),
+		Body: []byte(randString(60, run.newRandSource(), "a")),
 	}
 	reader := bytes.NewReader(presignedObject.Body)
 
 	// Create a new presigned PUT URL.
 	reqURL, err := newPresignedPutObjectReq(config, bucketName, objectName, time.Second*5)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 
 	// Create a new http Request out of the URL.
 	req, err := http.NewRequest("PUT", reqURL.String(), reader)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 
 	// Execute the request.
-	res, err := config.Client.Do(req)
+	res, err := config.doSignedRequest(req, bucketName, objectName)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
+	}
+	defer closeResponse(res)
+	// Upload the same object to the reference server with a URL presigned for it.
+	if config.reference != nil {
+		referenceReq := newPresignedPutObjectRequest(bucketName, objectName, time.Second*5)
+		referenceReq.contentBody = bytes.NewReader(presignedObject.Body)
+		referenceReq.contentLength = int64(len(presignedObject.Body))
+		if err := config.diffWithReference("PUT", referenceReq, res); err != nil {
+			return newTestResult(message, err)
+		}
 	}
 
 	// Verify the response.
 	if err := presignedPutObjectVerify(res, 200, ErrorResponse{}); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 
 	// Store the newly created object.
-	s3verifyObjects = append(s3verifyObjects, presignedObject)
+	run.objects = append(run.objects, presignedObject)
 
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -0,0 +1,178 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// vendorProfile - the known ways a server deviates from AWS S3, loaded with --profile.
+// Known deviations are reported as XFAIL so that only regressions fail the run.
+type vendorProfile struct {
+	Name string `json:"name"` // Name of the vendor or server, e.g. "minio".
+
+	// Tests and scenarios expected to fail, a scenario is named after its test e.g. "ListObjectsV2/MaxKeys".
+	ExpectedFailures []profileEntry `json:"expectedFailures"`
+	// Tests of APIs the server does not implement, tests depending on them are skipped as well.
+	Skip []profileEntry `json:"skip"`
+	// Response headers the server sends on top of the standard AWS S3 headers.
+	Headers []string `json:"headers"`
+	// Values accepted for response headers on top of the AWS S3 ones, {bucket} is replaced by the bucket name.
+	HeaderValues map[string][]string `json:"headerValues"`
+}
+
+// profileEntry - a test or scenario listed in a profile.
+type profileEntry struct {
+	Test   string `json:"test"`   // Regular expression matched against the name of the test or scenario.
+	Reason string `json:"reason"` // Why the server deviates, shown in the results.
+
+	test *regexp.Regexp
+}
+
+// loadProfile - read and validate the profile stored in fileName.
+func loadProfile(fileName string) (*vendorProfile, error) {
+	profileFile, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer profileFile.Close()
+	profile := &vendorProfile{}
+	if err := jsonDecoder(profileFile, profile); err != nil {
+		return nil, fmt.Errorf("Unable to parse profile %s: %v", fileName, err)
+	}
+	for _, entries := range [][]profileEntry{profile.ExpectedFailures, profile.Skip} {
+		for i := range entries {
+			test, err := regexp.Compile(entries[i].Test)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid test pattern %q in profile %s: %v", entries[i].Test, fileName, err)
+			}
+			entries[i].test = test
+		}
+	}
+	return profile, nil
+}
+
+// findEntry - return the first entry matching name.
+func findEntry(entries []profileEntry, name string) (profileEntry, bool) {
+	for _, entry := range entries {
+		if entry.test.MatchString(name) {
+			return entry, true
+		}
+	}
+	return profileEntry{}, false
+}
+
+// skipReason - return why the test should not be run against this server, if it should not be run.
+// A nil profile skips nothing.
+func (p *vendorProfile) skipReason(name string) (string, bool) {
+	if p == nil {
+		return "", false
+	}
+	entry, ok := findEntry(p.Skip, name)
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprintf("Skipped by profile %s: %s", p.Name, entry.Reason), true
+}
+
+// expectFailure - mark a failed test or scenario as XFAIL if the profile expects it to fail.
+func (p *vendorProfile) expectFailure(result *TestResult, name string) {
+	if p == nil || result.Status != TestFail {
+		return
+	}
+	entry, ok := findEntry(p.ExpectedFailures, name)
+	if !ok {
+		return
+	}
+	result.Status = TestXFail
+	if entry.Reason != "" {
+		result.Err = fmt.Errorf("%v\nExpected by profile %s: %s", result.Err, p.Name, entry.Reason)
+	}
+}
+
+// applyTo - mark the known deviations in the result of a test as XFAIL.
+// A test with scenarios is only XFAIL if every one of its failed scenarios is, or if the test itself is expected to fail.
+func (p *vendorProfile) applyTo(result TestResult) TestResult {
+	if p == nil || result.Status != TestFail {
+		return result
+	}
+	failed, expected := 0, 0
+	for i := range result.Checks {
+		if result.Checks[i].Status != TestFail {
+			continue
+		}
+		failed++
+		p.expectFailure(&result.Checks[i], result.Name+"/"+result.Checks[i].Name)
+		if result.Checks[i].Status == TestXFail {
+			expected++
+		}
+	}
+	if failed > 0 && failed == expected {
+		result.Status = TestXFail
+		return result
+	}
+	p.expectFailure(&result, result.Name)
+	return result
+}
+
+// headerTolerated - check whether the profile allows the server to send a non standard header.
+func (p *vendorProfile) headerTolerated(headerName string) bool {
+	if p == nil {
+		return false
+	}
+	for _, name := range p.Headers {
+		if strings.EqualFold(name, headerName) {
+			return true
+		}
+	}
+	return false
+}
+
+// removeToleratedHeaders - remove the non standard headers the profile allows the server to send from
+// the header of a response, so that the tests only verify the standard ones.
+func (p *vendorProfile) removeToleratedHeaders(header http.Header) {
+	if p == nil {
+		return
+	}
+	for headerName := range header {
+		if _, ok := validResponseHeaders[strings.ToLower(headerName)]; !ok && p.headerTolerated(headerName) {
+			header.Del(headerName)
+		}
+	}
+}
+
+// headerValueTolerated - check whether the profile accepts value for headerName in a response about bucketName.
+func (p *vendorProfile) headerValueTolerated(headerName, value, bucketName string) bool {
+	if p == nil {
+		return false
+	}
+	for name, values := range p.HeaderValues {
+		if !strings.EqualFold(name, headerName) {
+			continue
+		}
+		for _, tolerated := range values {
+			if strings.Replace(tolerated, "{bucket}", bucketName, -1) == value {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// runProfileSuite - run the selected tests against handler with the known deviations of profile.
+func runProfileSuite(t *testing.T, handler http.Handler, profile *vendorProfile, selection testSelection) map[string]TestResult {
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.profile = profile
+	results := make(map[string]TestResult)
+	for _, result := range runTests(*config, newQuietRunContext("profile", 1), unpreparedTests, selection) {
+		results[result.Name] = result
+	}
+	return results
+}
+
+// newVendorServer - a server that sends a header of its own and ignores If-Modified-Since in HeadObject.
+func newVendorServer() http.Handler {
+	return brokenServer{
+		next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+		mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+			rec.HeaderMap.Set("X-Vendor-Header", "s3verify")
+			if r.Method == "HEAD" && rec.Code == http.StatusNotModified {
+				replaceResponse(rec, http.StatusOK)
+			}
+		},
+	}
+}
+
+// TestProfile - the profile of the server tolerates its headers, expects its failures and
+// skips the APIs it does not implement, while a server without a profile is tested as is.
+func TestProfile(t *testing.T) {
+	profile := &vendorProfile{
+		Name:             "vendor",
+		ExpectedFailures: []profileEntry{{Test: "^HeadObject/IfModifiedSince$", test: regexp.MustCompile("^HeadObject/IfModifiedSince$")}},
+		Skip:             []profileEntry{{Test: "^ListBuckets$", test: regexp.MustCompile("^ListBuckets$")}},
+		Headers:          []string{"X-Vendor-Header"},
+	}
+	selection := testSelection{run: regexp.MustCompile("^(ListBuckets|HeadObject/IfModifiedSince)$")}
+	expected := map[string]TestStatus{
+		"PutBucket":                  TestPass,
+		"PutObject":                  TestPass,
+		"ListBuckets":                TestSkip,
+		"HeadObject/IfModifiedSince": TestXFail,
+	}
+	results := runProfileSuite(t, newVendorServer(), profile, selection)
+	for name, status := range expected {
+		if result := results[name]; result.Status != status {
+			t.Errorf("%s: expected %s, got %s: %v", name, status, result.Status, result.Err)
+		}
+	}
+	// The same server fails without its profile.
+	results = runProfileSuite(t, newVendorServer(), nil, selection)
+	if result := results["PutBucket"]; result.Status != TestFail {
+		t.Errorf("PutBucket: expected %s without a profile, got %s", TestFail, result.Status)
+	}
+}
@@ -28,9 +28,6 @@ import (
 	"net/url"
 )
 
-// s3verifyPolicies - maintain a list of all access policies used by s3verify.
-var s3verifyPolicies = []BucketAccessPolicy{}
-
 // newPutBucketPolicyReq - create a new PutBucketPolicyReq
 func newPutBucketPolicyReq(bucketName string, bucketPolicy BucketAccessPolicy) (Request, error) {
 	//
@@ -113,10 +110,10 @@ func verifyBodyPutBucketPolicy(resBody io.Reader) error {
 }
 
 // mainPutBucketPolicy - entry point for the putbucketpolicy test.
-func mainPutBucketPolicy(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] PutBucketPolicy:", curTest, globalTotalNumTest)
+func mainPutBucketPolicy(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PutBucketPolicy:", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// List of different bucketPolicies to create.
 	policies := []BucketPolicy{
@@ -126,9 +123,9 @@ func mainPutBucketPolicy(config ServerConfig, curTest int) bool {
 	}
 
 	// Set a policy for all buckets created by s3verify.
-	for i, bucket := range s3verifyBuckets[:3] {
+	for i, bucket := range run.buckets[:3] {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 
 		bucketName := bucket.Name
 		// Gather the policy you wish to create.
@@ -140,34 +137,30 @@ func mainPutBucketPolicy(config ServerConfig, curTest int) bool {
 			Statements: statements,
 		}
 		// Add this new policy to the list of s3verify activated policies.
-		s3verifyPolicies = append(s3verifyPolicies, bucketPolicy)
+		run.policies = append(run.policies, bucketPolicy)
 		// Create a new request to add the bucket policy to the bucket.
 		req, err := newPutBucketPolicyReq(bucketName, bucketPolicy)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Execute the request.
 		res, err := config.execRequest("PUT", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Verify the response.
 		if err := putBucketPolicyVerify(res, http.StatusNoContent); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
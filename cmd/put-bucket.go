@@ -28,10 +28,6 @@ import (
 )
 
 var (
-	//
-	s3verifyBuckets = []BucketInfo{}
-	// Make two random buckets below in the test.
-	preparedBuckets = []BucketInfo{}
 	// See http://docs.aws.amazon.com/AmazonS3/latest/dev/BucketRestrictions.html for all bucket naming restrictions.
 	invalidBuckets = []BucketInfo{
 		BucketInfo{
@@ -104,7 +100,7 @@ func newPutBucketReq(region, bucketName string) (Request, error) {
 }
 
 // putBucketVerify - Check the response Body, Header, Status for AWS S3 compliance.
-func putBucketVerify(res *http.Response, bucketName string, expectedStatusCode int, expectedError ErrorResponse) error {
+func putBucketVerify(res *http.Response, profile *vendorProfile, bucketName string, expectedStatusCode int, expectedError ErrorResponse) error {
 	// Previous attempt to create bucket succeeded, treat it as a good condition.
 	if res.StatusCode == http.StatusConflict {
 		return nil
@@ -112,7 +108,7 @@ func putBucketVerify(res *http.Response, bucketName string, expectedStatusCode i
 	if err := verifyStatusPutBucket(res.StatusCode, expectedStatusCode); err != nil {
 		return err
 	}
-	if err := verifyHeaderPutBucket(res.Header, bucketName, expectedStatusCode); err != nil {
+	if err := verifyHeaderPutBucket(res.Header, profile, bucketName, expectedStatusCode); err != nil {
 		return err
 	}
 	if err := verifyBodyPutBucket(res.Body, expectedError); err != nil {
@@ -157,12 +153,12 @@ func verifyBodyPutBucket(resBody io.Reader, expectedError ErrorResponse) error {
 	return nil
 }
 
-// verifyHeaderPutBucket - Check the response header for AWS S3 compliance.
-func verifyHeaderPutBucket(header http.Header, bucketName string, expectedStatusCode int) error {
+// verifyHeaderPutBucket - Check the response header for AWS S3 compliance, along with the Location values the profile accepts.
+func verifyHeaderPutBucket(header http.Header, profile *vendorProfile, bucketName string, expectedStatusCode int) error {
 	if expectedStatusCode == http.StatusOK {
 		location := header.Get("Location")
-		if location != "http://"+bucketName+".s3.amazonaws.com/" && location != "/"+bucketName {
-			// TODO: wait for Minio server to fix endpoint detection.
+		if location != "http://"+bucketName+".s3.amazonaws.com/" && location != "/"+bucketName &&
+			!profile.headerValueTolerated("Location", location, bucketName) {
 			err := fmt.Errorf("Unexpected Location: got %v", location)
 			return err
 		}
@@ -174,86 +170,78 @@ func verifyHeaderPutBucket(header http.Header, bucketName string, expectedStatus
 }
 
 // mainPutBucket- entry point for the putBucket test with valid names.
-func mainPutBucket(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] PutBucket (Valid Names):", curTest, globalTotalNumTest)
-	// Spin the scanBar scanBar(message)
+func mainPutBucket(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PutBucket (Valid Names):", curTest, run.totalNumTest)
+	// Spin the scanBar run.scanBar(message)
 	// Four new buckets are created on the same host regardless of whether or not the test has been prepared.
 	for i := 0; i < 4; i++ {
 		validBucket := BucketInfo{
-			Name: "s3verify-" + globalSuffix + strconv.Itoa(i),
+			Name: "s3verify-" + run.suffix + strconv.Itoa(i),
 		}
 		// Spin the scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create a new Make bucket request.
 		customPutBucketReq, err := newPutBucketReq(config.Region, validBucket.Name)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin the scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Execute the request.
 		res, err := config.execRequest("PUT", customPutBucketReq)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Spin the scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Check the responses Body, Status, Header.
-		if err := putBucketVerify(res, validBucket.Name, http.StatusOK, ErrorResponse{}); err != nil {
-			printMessage(message, err)
-			return false
+		if err := putBucketVerify(res, config.profile, validBucket.Name, http.StatusOK, ErrorResponse{}); err != nil {
+			return newTestResult(message, err)
 		}
 		// Save the newly created bucket.
-		s3verifyBuckets = append(s3verifyBuckets, validBucket)
+		run.buckets = append(run.buckets, validBucket)
 		// Spin the scanBar
-		scanBar(message)
+		run.scanBar(message)
 	}
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
 
 // mainPutBucketInvalid - entry point for testing putbucket API with invalid names.
-func mainPutBucketInvalid(config ServerConfig, curTest int) bool {
+func mainPutBucketInvalid(config ServerConfig, run *RunContext, curTest int) TestResult {
 	// Test invalid names. This cannot be separated yet into its own test because of the way --prepared is laid out currently.
-	message := fmt.Sprintf("[%02d/%d] PutBucket (Invalid Names):", curTest, globalTotalNumTest)
+	message := fmt.Sprintf("[%02d/%d] PutBucket (Invalid Names):", curTest, run.totalNumTest)
 	expectedError := ErrorResponse{
 		Message: "The specified bucket is not valid.",
 	}
 	// Test that all invalid names fail correctly.
 	for _, bucket := range invalidBuckets {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create a new PUT bucket request.
 		customPutBucketReq, err := newPutBucketReq(config.Region, bucket.Name)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Execute the request.
 		res, err := config.execRequest("PUT", customPutBucketReq)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Verify that the request failed as predicted.
-		if err := putBucketVerify(res, bucket.Name, 400, expectedError); err != nil {
-			printMessage(message, err)
-			return false
+		if err := putBucketVerify(res, config.profile, bucket.Name, 400, expectedError); err != nil {
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
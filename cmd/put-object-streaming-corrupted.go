@@ -0,0 +1,179 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Size of the chunks of the corrupted streams, the smallest AWS S3 accepts for every chunk but the last.
+const corruptedStreamChunkSize = 8 * 1024
+
+// corruptedStreamCheck - a streaming PUT object request corrupted after signing and the error the server must reject it with.
+type corruptedStreamCheck struct {
+	name string
+	// Differences between the x-amz-decoded-content-length and Content-Length signed
+	// and the lengths of the data and of the aws-chunked body.
+	decodedLengthDelta int64
+	contentLengthDelta int64
+	// Corrupt the signed aws-chunked body, nil to send it unchanged. The length of
+	// the corrupted body must be the signed Content-Length.
+	corrupt            func(body []byte) []byte
+	expectedStatusCode int
+	expectedCode       string
+}
+
+// corruptChunkSignature - change the signature of the second chunk.
+func corruptChunkSignature(body []byte) []byte {
+	extension := []byte(";chunk-signature=")
+	first := bytes.Index(body, extension)
+	if first < 0 {
+		return body
+	}
+	second := bytes.Index(body[first+len(extension):], extension)
+	if second < 0 {
+		return body
+	}
+	signature := first + len(extension) + second + len(extension)
+	corrupted := append([]byte{}, body...)
+	// Signatures are lower case hexadecimal, a digit is swapped for a letter and the other way around.
+	if corrupted[signature] >= 'a' {
+		corrupted[signature] = '0'
+	} else {
+		corrupted[signature] = 'f'
+	}
+	return corrupted
+}
+
+// corruptChunkLength - declare the first chunk one byte shorter than it is.
+func corruptChunkLength(body []byte) []byte {
+	end := bytes.IndexByte(body, ';')
+	if end < 0 {
+		return body
+	}
+	size, err := strconv.ParseInt(string(body[:end]), 16, 64)
+	if err != nil {
+		return body
+	}
+	return append([]byte(fmt.Sprintf("%x", size-1)), body[end:]...)
+}
+
+// removeFinalChunk - cut the body right in front of the final, empty chunk. The
+// Content-Length signed must leave out calculateSignedChunkLength(0) bytes.
+func removeFinalChunk(body []byte) []byte {
+	end := bytes.LastIndex(body, []byte("\r\n0;chunk-signature="))
+	if end < 0 {
+		return body
+	}
+	return body[:end+2]
+}
+
+// newCorruptedStreamReq - Create a new HTTP streaming request for PUT object whose
+// x-amz-decoded-content-length and Content-Length are off by decodedLengthDelta and
+// contentLengthDelta.
+func newCorruptedStreamReq(bucketName, objectName string, objectData []byte, decodedLengthDelta, contentLengthDelta int64) Request {
+	// An HTTP request for a PUT object.
+	var putObjectReq = Request{
+		customHeader:  http.Header{},
+		streamingSign: true,
+		chunkSize:     corruptedStreamChunkSize,
+	}
+
+	// Set the bucketName and objectName.
+	putObjectReq.bucketName = bucketName
+	putObjectReq.objectName = objectName
+
+	dataLen := int64(len(objectData))
+	contentLength := calculateStreamContentLength(dataLen, putObjectReq.chunkSize) + contentLengthDelta
+
+	putObjectReq.customHeader.Set("User-Agent", appUserAgent)
+	putObjectReq.customHeader.Set("x-amz-content-sha256", "STREAMING-AWS4-HMAC-SHA256-PAYLOAD")
+	putObjectReq.customHeader.Set("content-encoding", "aws-chunked")
+	putObjectReq.customHeader.Set("x-amz-decoded-content-length", strconv.FormatInt(dataLen+decodedLengthDelta, 10))
+	putObjectReq.customHeader.Set("Content-Length", strconv.FormatInt(contentLength, 10))
+
+	// Set the body to the data held in objectData.
+	putObjectReq.contentBody = bytes.NewReader(objectData)
+	putObjectReq.contentLength = contentLength
+
+	return putObjectReq
+}
+
+// corruptedStreamVerify - send the corrupted request of check and verify that the server rejects it without storing object.
+// An object stored anyway is added to the run, so that RemoveObject still removes it.
+func corruptedStreamVerify(config ServerConfig, run *RunContext, bucketName string, object *ObjectInfo, check corruptedStreamCheck) error {
+	req, err := config.newRequest("PUT", newCorruptedStreamReq(bucketName, object.Key, object.Body, check.decodedLengthDelta, check.contentLengthDelta))
+	if err != nil {
+		return err
+	}
+	if check.corrupt != nil {
+		// The body is only encoded in chunks while signing.
+		body, err := ioutil.ReadAll(req.Body)
+		if err != nil {
+			return err
+		}
+		// Content-Length is signed, it can not be changed along with the body.
+		body = check.corrupt(body)
+		if int64(len(body)) != req.ContentLength {
+			return fmt.Errorf("Corrupted body of %d bytes does not match the signed Content-Length %d", len(body), req.ContentLength)
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	res, err := config.doSignedRequest(req, bucketName, object.Key)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+	rejectedErr := verifyErrorCode(res, check.expectedStatusCode, check.expectedCode)
+	// Nothing of the corrupted data may have been stored.
+	if err := verifyObjectNotStored(config, run, bucketName, object); err != nil {
+		return err
+	}
+	return rejectedErr
+}
+
+// Test that streaming PUT object requests whose aws-chunked body was corrupted are rejected.
+func mainPutObjectStreamCorrupted(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PutObject (Streaming Corrupted):", curTest, run.totalNumTest)
+	result := newTestResult(message, nil)
+	// The corrupted requests are only sent to the tested server.
+	config.reference = nil
+	bucket := run.buckets[0]
+	checks := []corruptedStreamCheck{
+		{"ChunkSignature", 0, 0, corruptChunkSignature, http.StatusForbidden, "SignatureDoesNotMatch"},
+		{"ChunkLength", 0, 0, corruptChunkLength, http.StatusBadRequest, "IncompleteBody"},
+		{"FinalChunk", 0, -calculateSignedChunkLength(0), removeFinalChunk, http.StatusBadRequest, "IncompleteBody"},
+		{"DecodedLengthShort", -1, 0, nil, http.StatusBadRequest, "IncompleteBody"},
+		{"DecodedLengthLong", 1, 0, nil, http.StatusBadRequest, "IncompleteBody"},
+	}
+	for _, check := range checks {
+		// Spin scanBar
+		run.scanBar(message)
+		object := &ObjectInfo{
+			Key: "s3verify/put/object/stream/corrupted/" + strings.ToLower(check.name),
+			// Two full chunks and a partial one, followed by the final chunk.
+			Body: []byte(randString(2*corruptedStreamChunkSize+60, run.newRandSource(), "")),
+		}
+		result.addCheck(config, check.name, corruptedStreamVerify(config, run, bucket.Name, object, check))
+	}
+	return result
+}
@@ -0,0 +1,123 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// TestPutObjectStreamCorruptedAgainstBrokenServers - the corrupted stream test fails against a server
+// that stores corrupted streams, or rejects them with another error.
+func TestPutObjectStreamCorruptedAgainstBrokenServers(t *testing.T) {
+	corrupted := func(r *http.Request) bool {
+		return strings.Contains(r.URL.Path, "/stream/corrupted/")
+	}
+	handlers := map[string]brokenServer{
+		// The server does not verify chunk signatures.
+		"unsigned": {
+			next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+			mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+				if corrupted(r) && r.Method == "PUT" && rec.Code == http.StatusForbidden {
+					replaceResponse(rec, http.StatusOK)
+				}
+			},
+		},
+		// The server rejects every corrupted stream with the same error.
+		"generic": {
+			next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+			mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+				if corrupted(r) && r.Method == "PUT" {
+					rec.Code = http.StatusBadRequest
+					replaceBody(rec, "<Code>SignatureDoesNotMatch</Code>", "<Code>IncompleteBody</Code>")
+				}
+			},
+		},
+	}
+	for name, handler := range handlers {
+		results := runSuite(t, handler, testSelection{run: regexp.MustCompile("^PutObject/Streaming/Corrupted$")})
+		result := results["PutObject/Streaming/Corrupted"]
+		if result.Status != TestFail {
+			t.Errorf("%s: expected %s, got %s", name, TestFail, result.Status)
+		}
+		if len(result.Checks) != 5 || result.Checks[0].Status != TestFail {
+			t.Errorf("%s: expected the chunk signature check to fail, got %v", name, result.Checks)
+		}
+	}
+}
+
+// TestPutObjectStreamCorruptedCleanup - corrupted streams stored by a broken server are removed
+// along with the other objects, so that the buckets of the run can still be removed.
+func TestPutObjectStreamCorruptedCleanup(t *testing.T) {
+	handler := storingServer{
+		next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+		accept: func(r *http.Request) bool {
+			return strings.Contains(r.URL.Path, "/stream/corrupted/")
+		},
+	}
+	results := runSuite(t, handler, testSelection{run: regexp.MustCompile("^(PutObject/Streaming/Corrupted|RemoveBucket)$")})
+	expected := map[string]TestStatus{
+		"PutObject/Streaming/Corrupted": TestFail,
+		"RemoveObject":                  TestPass,
+		"RemoveBucket":                  TestPass,
+	}
+	for name, status := range expected {
+		if result := results[name]; result.Status != status {
+			t.Errorf("%s: expected %s, got %s: %v", name, status, result.Status, result.Err)
+		}
+	}
+}
+
+// TestCorruptStream - every corruption changes the encoded body but keeps the length expected of it.
+func TestCorruptStream(t *testing.T) {
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, "http://localhost:9000", testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(strings.Repeat("a", 2*corruptedStreamChunkSize+60))
+	req, err := config.newRequest("PUT", newCorruptedStreamReq("bucket", "object", data, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(req.Body); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		corrupt            func(body []byte) []byte
+		contentLengthDelta int
+	}{
+		{corruptChunkSignature, 0},
+		{corruptChunkLength, 0},
+		{removeFinalChunk, -int(calculateSignedChunkLength(0))},
+	}
+	for i, testCase := range testCases {
+		corrupted := testCase.corrupt(body.Bytes())
+		if bytes.Equal(corrupted, body.Bytes()) {
+			t.Errorf("Test %d: expected the body to be corrupted", i+1)
+		}
+		if len(corrupted) != body.Len()+testCase.contentLengthDelta {
+			t.Errorf("Test %d: expected %d bytes, got %d", i+1, body.Len()+testCase.contentLengthDelta, len(corrupted))
+		}
+	}
+}
@@ -23,21 +23,10 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
 	"strconv"
-	"time"
 )
 
-// Store all objects that are uploaded by s3verify tests.
-var s3verifyObjects = []*ObjectInfo{}
-
-// Store all objects that are uploaded through the preparing operation.
-var preparedObjects = []*ObjectInfo{}
-
-// Store all objects that were copied.
-var copyObjects = []*ObjectInfo{}
-
 // newPutObjectReq - Create a new HTTP request for PUT object.
 func newPutObjectReq(bucketName, objectName string, objectData []byte) (Request, error) {
 	// An HTTP request for a PUT object.
@@ -169,135 +158,151 @@ func verifyHeaderPutObject(header http.Header) error {
 }
 
 // mainPutObjectPrepared - Test the PutObject API in a prepared environment.
-func mainPutObjectPrepared(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] PutObject:", curTest, globalTotalNumTest)
+func mainPutObjectPrepared(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PutObject:", curTest, run.totalNumTest)
 	// Use the last bucket created by s3verify itself.
-	bucket := s3verifyBuckets[0]
+	bucket := run.buckets[0]
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Since the use of --prepare will have set up enough objects for future tests
 	// only add one more additional object.
 	object := &ObjectInfo{
 		Key:  "s3verify/made/put/object",
-		Body: []byte(randString(60, rand.NewSource(time.Now().UnixNano()), "")),
+		Body: []byte(randString(60, run.newRandSource(), "")),
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new request.
 	req, err := newPutObjectReq(bucket.Name, object.Key, object.Body)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("PUT", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	defer closeResponse(res)
 	// Verify the response.
 	if err := putObjectVerify(res, http.StatusOK); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Store this object in the global objects list.
-	s3verifyObjects = append(s3verifyObjects, object)
+	run.objects = append(run.objects, object)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
 
 // Test a PUT object request with no special headers set. This adds one object to each of the test buckets.
-func mainPutObjectUnPrepared(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] PutObject:", curTest, globalTotalNumTest)
+func mainPutObjectUnPrepared(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PutObject:", curTest, run.totalNumTest)
 	// TODO: create tests designed to fail.
-	bucket := s3verifyBuckets[0]
+	bucket := run.buckets[0]
 	// Spin scanBar
-	scanBar(message)
-	// TODO: need to update to 1001 once this is production ready.
-	// Upload 1001 objects with 1 byte each to check the ListObjects API with.
-	for i := 0; i < globalNumTestObjects; i++ {
-		// Spin scanBar
-		scanBar(message)
-		object := &ObjectInfo{}
-		object.Key = "s3verify/put/object/" + strconv.Itoa(i)
-		// Create 60 bytes worth of random data for each object.
-		body := randString(60, rand.NewSource(time.Now().UnixNano()), "")
-		object.Body = []byte(body)
-		// Create a new request.
-		req, err := newPutObjectReq(bucket.Name, object.Key, object.Body)
-		if err != nil {
-			printMessage(message, err)
-			return false
+	run.scanBar(message)
+	// Generate every object first, so that their data does not depend on the order they are uploaded in.
+	objects := make([]*ObjectInfo, globalNumTestObjects)
+	for i := range objects {
+		objects[i] = &ObjectInfo{
+			Key: "s3verify/put/object/" + strconv.Itoa(i),
+			// Create 60 bytes worth of random data for each object.
+			Body: []byte(randString(60, run.newRandSource(), "")),
 		}
-		// Execute the request.
-		res, err := config.execRequest("PUT", req)
+	}
+	// Upload 1001 objects with 1 byte each to check the ListObjects API with, up to --parallel at a time.
+	errs := make([]error, len(objects))
+	run.forEach(len(objects), func(i int) {
+		// Spin scanBar
+		run.scanBar(message)
+		errs[i] = putObjectCheck(config, bucket.Name, objects[i])
+	})
+	for _, err := range errs {
 		if err != nil {
-			printMessage(message, err)
-			return false
-		}
-		defer closeResponse(res)
-		// Verify the response.
-		if err := putObjectVerify(res, http.StatusOK); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
-		// Add the new object to the list of objects.
-		s3verifyObjects = append(s3verifyObjects, object)
-		// Spin scanBar
-		scanBar(message)
 	}
+	// Add the new objects to the list of objects.
+	run.objects = append(run.objects, objects...)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
+}
+
+// putObjectCheck - upload object to bucketName and verify the response.
+func putObjectCheck(config ServerConfig, bucketName string, object *ObjectInfo) error {
+	// Create a new request.
+	req, err := newPutObjectReq(bucketName, object.Key, object.Body)
+	if err != nil {
+		return err
+	}
+	// Execute the request.
+	res, err := config.execRequest("PUT", req)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+	// Verify the response.
+	return putObjectVerify(res, http.StatusOK)
+}
+
+// verifyObjectNotStored - verify that object does not exist after its upload was rejected. An object
+// that was stored anyway is added to the run, so that RemoveObject removes it and the bucket can be removed.
+func verifyObjectNotStored(config ServerConfig, run *RunContext, bucketName string, object *ObjectInfo) error {
+	headReq, err := newHeadObjectReq(bucketName, object.Key)
+	if err != nil {
+		return err
+	}
+	headRes, err := config.execRequest("HEAD", headReq)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(headRes)
+	if headRes.StatusCode != http.StatusNotFound {
+		run.addObjects(object)
+	}
+	return verifyStatusHeadObject(headRes.StatusCode, http.StatusNotFound)
 }
 
 // Test a PUT object streaming request with no special headers set. This adds one object to each of the test buckets.
-func mainPutObjectStream(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] PutObject (Streaming):", curTest, globalTotalNumTest)
+func mainPutObjectStream(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PutObject (Streaming):", curTest, run.totalNumTest)
 	// TODO: create tests designed to fail.
-	bucket := s3verifyBuckets[0]
+	bucket := run.buckets[0]
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	object := &ObjectInfo{}
 	// Only need to upload one new object.
 	object.Key = "s3verify/put/object/stream"
 	// Create 60 bytes worth of random data for each object.
-	body := randString(60, rand.NewSource(time.Now().UnixNano()), "")
+	body := randString(60, run.newRandSource(), "")
 	object.Body = []byte(body)
 	// Create a new request.
 	req, err := newPutObjectStreamingReq(config, bucket.Name, object.Key, object.Body)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the request.
 	res, err := config.execRequest("PUT", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Verify the response.
 	if err := putObjectVerify(res, http.StatusOK); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Add the new object to the list of objects.
-	s3verifyObjects = append(s3verifyObjects, object)
+	run.objects = append(run.objects, object)
 
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -21,9 +21,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
-	"time"
 )
 
 // newRemoveBucketReq - Fill in the dynamic fields of a DELETE request here.
@@ -99,45 +97,41 @@ func verifyStatusRemoveBucket(respStatusCode, expectedStatusCode int) error {
 }
 
 // mainRemoveBucketExists - test the removebucket API.
-func mainRemoveBucketExists(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] RemoveBucket (Bucket Exists):", curTest, globalTotalNumTest)
+func mainRemoveBucketExists(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] RemoveBucket (Bucket Exists):", curTest, run.totalNumTest)
 	// Only remove s3verify created buckets.
-	for _, bucket := range s3verifyBuckets {
+	for _, bucket := range run.buckets {
 		// Spin the scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Generate the new DELETE bucket request.
 		req, err := newRemoveBucketReq(bucket.Name)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin the scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Perform the request.
 		res, err := config.execRequest("DELETE", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Spin the scanBar
-		scanBar(message)
+		run.scanBar(message)
 		if err := removeBucketVerify(res, 204, ErrorResponse{}); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin the scanBar
-		scanBar(message)
+		run.scanBar(message)
 	}
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
 
 // Test the RemoveBucket API when the bucket does not exist.
-func mainRemoveBucketDNE(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] RemoveBucket (Bucket DNE):", curTest, globalTotalNumTest)
+func mainRemoveBucketDNE(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] RemoveBucket (Bucket DNE):", curTest, run.totalNumTest)
 	// Generate a random bucketName.
-	bucketName := randString(60, rand.NewSource(time.Now().UnixNano()), "")
+	bucketName := randString(60, run.newRandSource(), "")
 	// Hardcode the expected error response.
 	errResponse := ErrorResponse{
 		Code:       "NoSuchBucket",
@@ -146,41 +140,37 @@ func mainRemoveBucketDNE(config ServerConfig, curTest int) bool {
 		Key:        "",
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Generate a new DELETE bucket request for a bucket that does not exist.
 	req, err := newRemoveBucketReq(bucketName)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Perform the request.
 	res, err := config.execRequest("DELETE", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	if err := removeBucketVerify(res, http.StatusNotFound, errResponse); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
-	printMessage(message, nil)
-	return true
+	run.scanBar(message)
+	return newTestResult(message, nil)
 }
 
 // Test the RemoveBucket API when the bucket is not empty.
-func mainRemoveBucketNotEmpty(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] RemoveBucket (Bucket Not Empty):", curTest, globalTotalNumTest)
+func mainRemoveBucketNotEmpty(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] RemoveBucket (Bucket Not Empty):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Attempt to remove a s3verify created bucket before the objects inside have been removed.
-	bucketName := s3verifyBuckets[0].Name
+	bucketName := run.buckets[0].Name
 
 	// Expected error response.
 	errResponse := ErrorResponse{
@@ -189,34 +179,30 @@ func mainRemoveBucketNotEmpty(config ServerConfig, curTest int) bool {
 	}
 
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Create a new DELETE request for a bucket that is not yet empty.
 	req, err := newRemoveBucketReq(bucketName)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// Execute the request.
 	res, err := config.execRequest("DELETE", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 
 	// Verify that the request failed.
 	if err := removeBucketVerify(res, http.StatusConflict, errResponse); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
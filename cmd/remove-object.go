@@ -22,9 +22,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"math/rand"
 	"net/http"
-	"time"
 )
 
 // newRemoveObjectReq - Create a new DELETE object HTTP request.
@@ -95,129 +93,115 @@ func verifyStatusRemoveObject(respStatusCode, expectedStatusCode int) error {
 }
 
 // mainRemoveObjectExists - RemoveObject API test when object exists.
-func mainRemoveObjectExists(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%d/%d] RemoveObject:", curTest, globalTotalNumTest)
+func mainRemoveObjectExists(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%d/%d] RemoveObject:", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Only remove objects from s3verify created buckets.
 	// Only remove s3verify created objects.
 	// First remove all PutObject test created objects.
-	bucketName := s3verifyBuckets[0].Name
-	for _, object := range s3verifyObjects {
+	bucketName := run.buckets[0].Name
+	for _, object := range run.objects {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create a new request.
 		req, err := newRemoveObjectReq(bucketName, object.Key)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the request.
 		res, err := config.execRequest("DELETE", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Verify the response.
 		if err := removeObjectVerify(res, http.StatusNoContent); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 	}
 	// Remove all MultipartObject test objects.
-	for _, object := range multipartObjects {
+	for _, object := range run.multipartObjects {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create a new request.
 		req, err := newRemoveObjectReq(bucketName, object.Key)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the request.
 		res, err := config.execRequest("DELETE", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Verify the response.
 		if err := removeObjectVerify(res, http.StatusNoContent); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 	}
 
 	// Remove all copied objects. These exist in a different bucket.
-	bucketName = s3verifyBuckets[1].Name
-	for _, object := range copyObjects {
+	bucketName = run.buckets[1].Name
+	for _, object := range run.copyObjects {
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		// Create a new request.
 		req, err := newRemoveObjectReq(bucketName, object.Key)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the request.
 		res, err := config.execRequest("DELETE", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Verify the response.
 		if err := removeObjectVerify(res, http.StatusNoContent); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 	}
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
 
 // mainRemoveObjectDNE - Test the RemoveObject API when the object does not exist.
-func mainRemoveObjectDNE(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] RemoveObject (Object DNE):", curTest, globalTotalNumTest)
+func mainRemoveObjectDNE(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] RemoveObject (Object DNE):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
-	bucketName := s3verifyBuckets[0].Name
+	run.scanBar(message)
+	bucketName := run.buckets[0].Name
 	object := ObjectInfo{
-		Key: randString(60, rand.NewSource(time.Now().UnixNano()), ""),
+		Key: randString(60, run.newRandSource(), ""),
 	}
 	// Create a new request.
 	req, err := newRemoveObjectReq(bucketName, object.Key)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Execute the request.
 	res, err := config.execRequest("DELETE", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Verify the response.
 	if err := removeObjectVerify(res, http.StatusNoContent); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -24,6 +24,7 @@ import (
 	"net/url"
 	"time"
 
+	"github.com/minio/s3verify/signv2"
 	"github.com/minio/s3verify/signv4"
 )
 
@@ -34,8 +35,10 @@ type Request struct {
 	presignURL bool  // Indicates whether or not this will be a presigned http.Request.
 	expires    int64 // Describes for how long the presigned URL will be valid for.
 
-	streamingSign bool
-	chunkSize     int64
+	streamingSign  bool
+	chunkSize      int64
+	trailer        http.Header // Trailing headers sent after the final chunk of a streaming request, nil if none.
+	unsignedChunks bool        // Indicates whether the chunks of a streaming request with a trailer are sent unsigned.
 
 	customHeader http.Header
 	contentBody  io.Reader
@@ -45,34 +48,91 @@ type Request struct {
 	queryValues url.Values
 
 	contentLength int64
+
+	reference *Request // Request to send to the reference server instead, for requests signed inside of their body.
 }
 
 // execRequest - Executes an HTTP request creating an HTTP response and implements retry logic for predefined retryable errors.
-func (c ServerConfig) execRequest(method string, customReq Request) (resp *http.Response, err error) {
+// The request is sent to the reference server as well if there is one and any difference in the responses is recorded.
+func (c ServerConfig) execRequest(method string, customReq Request) (*http.Response, error) {
+	resp, err := c.doRequest(method, customReq)
+	if err != nil || c.reference == nil {
+		return resp, err
+	}
+	referenceReq := customReq
+	if customReq.reference != nil {
+		referenceReq = *customReq.reference
+	}
+	if err := c.diffWithReference(method, referenceReq, resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// doRequest - Executes an HTTP request against this server only, retrying predefined retryable errors.
+func (c ServerConfig) doRequest(method string, customReq Request) (resp *http.Response, err error) {
 	var isRetryable bool     // Indicates if request can be retried.
 	var bodySeeker io.Seeker // io.Seeking for seeking.
 	if customReq.contentBody != nil {
 		// Check if body is seekable then it is retryable.
 		bodySeeker, isRetryable = customReq.contentBody.(io.Seeker)
 	}
+	return c.retryRequest(func() (*http.Request, error) {
+		if isRetryable {
+			// Seek back to beginning for each attempt.
+			if _, err := bodySeeker.Seek(0, 0); err != nil {
+				return nil, err
+			}
+		}
+		// Create a new request.
+		return c.newRequest(method, customReq)
+	}, customReq.bucketName, customReq.objectName)
+}
+
+// doSignedRequest - Executes an HTTP request that was already signed, e.g. one changed after signing,
+// against this server only. It is counted and retried like the requests of doRequest, the same
+// signed request is sent again on every attempt.
+func (c ServerConfig) doSignedRequest(req *http.Request, bucketName, objectName string) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+	}
+	return c.retryRequest(func() (*http.Request, error) {
+		attempt := *req
+		attempt.Body = ioutil.NopCloser(bytes.NewReader(body))
+		// Abort the request as soon as the run is canceled.
+		if c.ctx != nil {
+			return attempt.WithContext(c.ctx), nil
+		}
+		return &attempt, nil
+	}, bucketName, objectName)
+}
 
+// retryRequest - send the requests created by newReq until one succeeds, fails with an error that
+// can not be retried or the retries run out, counting every request and response in the stats.
+func (c ServerConfig) retryRequest(newReq func() (*http.Request, error), bucketName, objectName string) (resp *http.Response, err error) {
 	doneCh := make(chan struct{}, 1)
 	defer func() {
 		doneCh <- struct{}{}
+		// Deviations the profile of the server tolerates are not verified by the tests.
+		if resp != nil {
+			c.profile.removeToleratedHeaders(resp.Header)
+		}
 	}()
 
 	// Do not need the index.
 	for _ = range newRetryTimer(MaxRetry, time.Second, doneCh) {
-		if isRetryable {
-			// Seek back to beginning for each attempt.
-			if _, err := bodySeeker.Seek(0, 0); err != nil {
-				// If seek failed no need to retry.
-				return resp, err
-			}
+		// There is no point in retrying once the run was canceled.
+		if err := c.canceled(); err != nil {
+			return nil, err
 		}
 		// Create a new request.
 		var req *http.Request
-		req, err = c.newRequest(method, customReq)
+		req, err = newReq()
 		if err != nil {
 			errResponse := ToErrorResponse(err)
 			if isS3CodeRetryable(errResponse.Code) {
@@ -80,6 +140,9 @@ func (c ServerConfig) execRequest(method string, customReq Request) (resp *http.
 			}
 			return nil, err
 		}
+		if c.stats != nil {
+			c.stats.countRequest()
+		}
 		resp, err = c.Client.Do(req)
 		if err != nil {
 			// For supported network errors verify.
@@ -92,6 +155,9 @@ func (c ServerConfig) execRequest(method string, customReq Request) (resp *http.
 		// For any known successful http status, return quickly.
 		for _, httpStatus := range successStatus {
 			if httpStatus == resp.StatusCode {
+				if c.stats != nil {
+					c.stats.recordResponse(resp, ErrorResponse{})
+				}
 				return resp, nil
 			}
 		}
@@ -105,7 +171,14 @@ func (c ServerConfig) execRequest(method string, customReq Request) (resp *http.
 		resp.Body = ioutil.NopCloser(errBodySeeker)
 
 		// For errors verify if its retryable otherwise fail quickly.
-		errResponse := ToErrorResponse(httpRespToErrorResponse(resp, customReq.bucketName, customReq.objectName))
+		errResponse := ToErrorResponse(httpRespToErrorResponse(resp, bucketName, objectName))
+		if c.stats != nil {
+			c.stats.recordResponse(resp, errResponse)
+		}
+
+		// Save the body back again, the last response is returned once the retries run out.
+		errBodySeeker.Seek(0, 0) // Seek back to starting point.
+		resp.Body = ioutil.NopCloser(errBodySeeker)
 
 		//Verify if error response code is retryable.
 		if isS3CodeRetryable(errResponse.Code) {
@@ -116,10 +189,6 @@ func (c ServerConfig) execRequest(method string, customReq Request) (resp *http.
 			continue // Retry.
 		}
 
-		// Save the body back again.
-		errBodySeeker.Seek(0, 0) // Seek back to starting point.
-		resp.Body = ioutil.NopCloser(errBodySeeker)
-
 		// For all other cases break out of the retry loop.
 		break
 	}
@@ -129,7 +198,7 @@ func (c ServerConfig) execRequest(method string, customReq Request) (resp *http.
 // newRequest - create an HTTP request out of a customRequest.
 func (c ServerConfig) newRequest(method string, customReq Request) (req *http.Request, err error) {
 	// Construct a new target URL.
-	targetURL, err := makeTargetURL(c.Endpoint, customReq.bucketName, customReq.objectName, c.Region, customReq.queryValues)
+	targetURL, err := makeTargetURL(c.Endpoint, customReq.bucketName, customReq.objectName, c.Region, c.lookup, customReq.queryValues)
 	if err != nil {
 		return nil, err
 	}
@@ -160,14 +229,40 @@ func (c ServerConfig) newRequest(method string, customReq Request) (req *http.Re
 
 	// Sign the request if needed.
 	if customReq.presignURL && method == "POST" { // These requests would have already been signed.
+	} else if c.signature == signatureV2 {
+		// The payload checksum is only part of Signature Version 4.
+		req.Header.Del("X-Amz-Content-Sha256")
+		// The bucket of virtual-hosted-style requests is signed as part of the resource.
+		var virtualHostBucket string
+		if endpointURL, err := url.Parse(c.Endpoint); err == nil && isVirtualHostStyle(c.lookup, endpointURL, customReq.bucketName) {
+			virtualHostBucket = customReq.bucketName
+		}
+		if customReq.presignURL {
+			req = signv2.PreSignV2(*req, c.Access, c.Secret, c.SessionToken, virtualHostBucket, customReq.expires)
+		} else {
+			req = signv2.SignV2(*req, c.Access, c.Secret, c.SessionToken, virtualHostBucket)
+		}
+	} else if c.signature == signatureV4A && customReq.presignURL {
+		// Presign the request for the region set of the region of the server.
+		req = signv4.PreSignV4A(*req, c.Access, c.Secret, c.SessionToken, c.Region, customReq.expires)
+	} else if c.signature == signatureV4A && !customReq.streamingSign {
+		req = signv4.SignV4A(*req, c.Access, c.Secret, c.SessionToken, c.Region)
 	} else if customReq.presignURL {
 		// Presign the request.
-		req = signv4.PreSignV4(*req, c.Access, c.Secret, c.Region, customReq.expires)
+		req = signv4.PreSignV4(*req, c.Access, c.Secret, c.SessionToken, c.Region, customReq.expires)
+	} else if customReq.streamingSign && customReq.unsignedChunks {
+		req = signv4.StreamingUnsignedTrailerV4(*req, c.Access, c.Secret, c.SessionToken, c.Region, customReq.chunkSize, customReq.trailer)
+	} else if customReq.streamingSign && customReq.trailer != nil {
+		req = signv4.StreamingSignV4Trailer(*req, c.Access, c.Secret, c.SessionToken, c.Region, customReq.chunkSize, customReq.trailer)
 	} else if customReq.streamingSign {
-		req = signv4.StreamingSignV4(*req, c.Access, c.Secret, c.Region, customReq.chunkSize)
+		req = signv4.StreamingSignV4(*req, c.Access, c.Secret, c.SessionToken, c.Region, customReq.chunkSize)
 	} else {
 		// Else use regular signature v4.
-		req = signv4.SignV4(*req, c.Access, c.Secret, c.Region)
+		req = signv4.SignV4(*req, c.Access, c.Secret, c.SessionToken, c.Region)
+	}
+	// Abort the request as soon as the run is canceled.
+	if c.ctx != nil {
+		req = req.WithContext(c.ctx)
 	}
 
 	return req, nil
@@ -88,8 +88,7 @@ var retryableS3Codes = map[string]struct{}{
 	"RequestLimitExceeded":  {},
 	"RequestThrottled":      {},
 	"InternalError":         {},
-	"ExpiredToken":          {},
-	"ExpiredTokenException": {},
+	// ExpiredToken is not retried, the credentials of s3verify are never refreshed.
 	// Add more AWS S3 codes here.
 }
 
@@ -18,18 +18,16 @@ package cmd
 
 import (
 	"bytes"
-	"math/rand"
 	"net/url"
 	"strconv"
-	"time"
 
 	"github.com/minio/minio-go"
 )
 
 // prepareBucket - Uses minio-go library to create new testing bucket for use by s3verify.
-func prepareBucket(region string, client *minio.Client) (string, error) {
+func prepareBucket(run *RunContext, region string, client *minio.Client) (string, error) {
 	reuseMessage := "Reusing test bucket"
-	bucketName := "s3verify-" + globalSuffix
+	bucketName := "s3verify-" + run.suffix
 	preparedBucket := BucketInfo{
 		Name: bucketName,
 	}
@@ -37,37 +35,37 @@ func prepareBucket(region string, client *minio.Client) (string, error) {
 	// Check to see if the desired bucket already exists.
 	bucketExists, err := client.BucketExists(bucketName)
 	if err != nil {
-		printMessage(reuseMessage, err)
+		run.printMessage(reuseMessage, err)
 		return "", err
 	}
 	// Exit successfully if bucket already exists.
 	if bucketExists {
 		// Store the existing bucket for testing.
-		preparedBuckets = append(preparedBuckets, preparedBucket)
+		run.preparedBuckets = append(run.preparedBuckets, preparedBucket)
 		// Don't print anything for successfully reusable environments.
 		return bucketName, nil
 	}
 	createMessage := "Creating test bucket"
 	// Spin scanBar
-	scanBar(createMessage)
+	run.scanBar(createMessage)
 	// Create the new testing bucket.
 	if err := client.MakeBucket(bucketName, region); err != nil {
-		printMessage(createMessage, err)
+		run.printMessage(createMessage, err)
 		return "", err
 	}
 	// Store the created bucket for testing.
-	preparedBuckets = append(preparedBuckets, preparedBucket)
+	run.preparedBuckets = append(run.preparedBuckets, preparedBucket)
 	// Spin scanBar
-	scanBar(createMessage)
+	run.scanBar(createMessage)
 	// Bucket preparation passed.
-	printMessage(createMessage, nil)
+	run.printMessage(createMessage, nil)
 	return bucketName, nil
 }
 
 // TODO: see if parallelization has a place here.
 
 // prepareObjects - Uses minio-go library to create 1001 new testing objects for use by s3verify.
-func prepareObjects(client *minio.Client, bucketName string) error {
+func prepareObjects(run *RunContext, client *minio.Client, bucketName string) error {
 	createMessage := "Creating test objects"
 	// First check that the bucketName does not already contain the correct number of s3verify objects.
 	var objCount int
@@ -80,7 +78,7 @@ func prepareObjects(client *minio.Client, bucketName string) error {
 			Key: obj.Key,
 		}
 		// Store the already created object for testing.
-		preparedObjects = append(preparedObjects, preparedObject)
+		run.preparedObjects = append(run.preparedObjects, preparedObject)
 	}
 	if objCount == globalNumTestObjects {
 		//  Don't print anything for successfully prepared environments.
@@ -89,9 +87,9 @@ func prepareObjects(client *minio.Client, bucketName string) error {
 	// Upload 1001 objects specifically for the list-objects tests.
 	for i := objCount; i < globalNumTestObjects; i++ {
 		// Spin scanBar
-		scanBar(createMessage)
-		randomData := randString(60, rand.NewSource(time.Now().UnixNano()), "")
-		objectKey := "s3verify/put/object/" + globalSuffix + strconv.Itoa(i)
+		run.scanBar(createMessage)
+		randomData := randString(60, run.newRandSource(), "")
+		objectKey := "s3verify/put/object/" + run.suffix + strconv.Itoa(i)
 		byteData := []byte(randomData)
 		preparedObject := &ObjectInfo{
 			Key:  objectKey,
@@ -101,26 +99,26 @@ func prepareObjects(client *minio.Client, bucketName string) error {
 		reader := bytes.NewReader(byteData)
 		_, err := client.PutObject(bucketName, objectKey, reader, "application/octet-stream")
 		if err != nil {
-			printMessage(createMessage, err)
+			run.printMessage(createMessage, err)
 			return err
 		}
 		// Store the created object for testing.
-		preparedObjects = append(preparedObjects, preparedObject)
+		run.preparedObjects = append(run.preparedObjects, preparedObject)
 
 		// Spin scanBar
-		scanBar(createMessage)
+		run.scanBar(createMessage)
 	}
 	// Spin scanBar
-	scanBar(createMessage)
+	run.scanBar(createMessage)
 	// Object preparation passed.
-	printMessage(createMessage, nil)
+	run.printMessage(createMessage, nil)
 	return nil
 }
 
 // TODO: Create function using minio-go to upload 1001 parts of a multipart operation.
 
 // mainReuseS3Verify - Create one new buckets and 1001 objects for s3verify to use in the test.
-func mainReuseS3Verify(config ServerConfig) error {
+func mainReuseS3Verify(config ServerConfig, run *RunContext) error {
 	// Extract necessary values from the config.
 	hostURL, err := url.Parse(config.Endpoint)
 	if err != nil {
@@ -133,12 +131,12 @@ func mainReuseS3Verify(config ServerConfig) error {
 		return err
 	}
 	// Create testing bucket if it doesn't already exist.
-	validBucketName, err := prepareBucket(region, client)
+	validBucketName, err := prepareBucket(run, region, client)
 	if err != nil {
 		return err
 	}
 	// Use the first newly created bucket to store all the objects.
-	if err := prepareObjects(client, validBucketName); err != nil {
+	if err := prepareObjects(run, client, validBucketName); err != nil {
 		return err
 	}
 	return nil
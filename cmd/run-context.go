@@ -0,0 +1,142 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/minio/cli"
+)
+
+// RunContext - the state of a single run of the suite. Tests store the
+// buckets, objects and uploads they create in it and read back what the
+// tests they depend on created, so that runs do not share any state and
+// several of them can be made in one process.
+type RunContext struct {
+	suffix       string     // The suffix to append to all s3verify created objects and buckets.
+	seed         int64      // The seed of random, stored in recordings to replay them.
+	random       *rand.Rand // Source of the names and data generated by the run.
+	totalNumTest int        // The total number of tests being run, set once the tests have been selected.
+	quiet        bool       // Nothing may be printed to the console by the tests of the run.
+	parallel     int        // The number of tests, or uploads of a test, run at the same time.
+
+	mu sync.Mutex // Guards what tests running at the same time add to the run, see addCopyObjects.
+
+	buckets         []BucketInfo         // Buckets created by PutBucket.
+	preparedBuckets []BucketInfo         // Buckets of a reusable environment, see --reuse.
+	policies        []BucketAccessPolicy // Policies set by PutBucketPolicy, in the order of buckets.
+
+	objects         []*ObjectInfo // Objects uploaded by the tests.
+	preparedObjects []*ObjectInfo // Objects of a reusable environment, see --reuse.
+	copyObjects     []*ObjectInfo // Objects created by CopyObject.
+
+	multipartObjects      []*ObjectInfo              // Objects uploaded with multipart uploads.
+	objectParts           [2][]objectPart            // Parts uploaded, grouped by multipart object.
+	complMultipartUploads []*completeMultipartUpload // Parts to complete every multipart upload with.
+}
+
+// newRunContext - create the state of a new run. Names and data are
+// generated from seed, so that a run can be replayed with the same seed.
+func newRunContext(suffix string, seed int64) *RunContext {
+	return &RunContext{
+		suffix:   suffix,
+		seed:     seed,
+		random:   rand.New(&lockedRandSource{src: rand.NewSource(seed)}),
+		parallel: 1,
+		multipartObjects: []*ObjectInfo{
+			// An object that will have more than 5MB of data to be uploaded as part of a multipart upload.
+			&ObjectInfo{
+				Key:         "s3verify/multipart/object",
+				ContentType: "application/octet-stream",
+				// Body: to be set dynamically,
+				// UploadID: to be set dynamically,
+			},
+			&ObjectInfo{
+				Key:         "s3verify/multipart/abort",
+				ContentType: "application/octet-stream",
+				// Body: to be set dynamically,
+				// UploadID: to be set dynamically,
+			},
+		},
+		complMultipartUploads: []*completeMultipartUpload{
+			// To be filled out by the UploadPart test.
+			&completeMultipartUpload{},
+			&completeMultipartUpload{},
+		},
+	}
+}
+
+// newRunContextFromContext - create the state of a run from the --reuse and --replay flags.
+func newRunContextFromContext(ctx *cli.Context) (*RunContext, error) {
+	// Standard suffix.
+	suffix := "tmp-bkt"
+	if ctx.GlobalString("reuse") != "" {
+		suffix = ctx.GlobalString("reuse")
+	}
+	seed := time.Now().UTC().UnixNano()
+	// A replayed run must generate the same names and data as the recorded one.
+	if dir := ctx.GlobalString("replay"); dir != "" {
+		session, err := loadCassetteSession(dir)
+		if err != nil {
+			return nil, err
+		}
+		seed = session.Seed
+		suffix = session.Suffix
+	}
+	run := newRunContext(suffix, seed)
+	run.quiet = isQuiet(ctx)
+	run.parallel = ctx.GlobalInt("parallel")
+	return run, nil
+}
+
+// newRandSource - a new source of random numbers derived from the seed of the run,
+// so that the same names and data are generated again when a run is replayed.
+func (run *RunContext) newRandSource() rand.Source {
+	return rand.NewSource(run.random.Int63())
+}
+
+// addCopyObjects - remember objects created by a CopyObject test, so that RemoveObject removes them.
+func (run *RunContext) addCopyObjects(objects ...*ObjectInfo) {
+	run.mu.Lock()
+	run.copyObjects = append(run.copyObjects, objects...)
+	run.mu.Unlock()
+}
+
+// addObjects - remember objects uploaded by a test, so that RemoveObject removes them.
+func (run *RunContext) addObjects(objects ...*ObjectInfo) {
+	run.mu.Lock()
+	run.objects = append(run.objects, objects...)
+	run.mu.Unlock()
+}
+
+// scanBar - spin the progress bar of the run, unless it must stay quiet.
+func (run *RunContext) scanBar(message string) {
+	if run.quiet {
+		return
+	}
+	scanBar(message)
+}
+
+// printMessage - print the outcome of a step of the run, unless it must stay quiet.
+func (run *RunContext) printMessage(message string, err error) {
+	if run.quiet {
+		return
+	}
+	printMessage(message, err)
+}
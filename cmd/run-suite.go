@@ -0,0 +1,101 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// SuiteOptions - which tests RunSuite runs and how.
+type SuiteOptions struct {
+	Run      string // Only run tests whose name matches this regular expression, see --run.
+	Skip     string // Never run tests whose name matches this regular expression, see --skip.
+	Extended bool   // Run the extended tests as well, see --extended.
+	Suffix   string // Suffix of the buckets created by the run, "tmp-bkt" if empty. Concurrent runs against one server need different suffixes.
+	Seed     int64  // Seed the names and data of the run are generated from, the current time if zero.
+	Parallel int    // Run up to this many independent tests, and object uploads, at the same time, see --parallel. 1 if zero.
+	Progress bool   // Print the progress and the results of the tests to the console like s3verify does.
+
+	SignatureV2  bool   // Run the signing tests again signed with AWS Signature Version 2, see --signature.
+	SignatureV4A bool   // Run the core object tests again signed with SigV4A, see --signature.
+	Lookup       string // Style buckets are addressed with, virtual, path or auto, see --lookup. Path if empty.
+	Profile      string // JSON file of the known deviations of the server from AWS S3, see --profile. None if empty.
+}
+
+// RunSuite - run the selected tests against the server of config and return their results.
+// Unlike the s3verify command it never exits and prints nothing unless opts.Progress is set,
+// so that it can be called from other programs, see package verify. Once ctx is canceled the
+// requests in flight are aborted and the remaining tests are skipped.
+func RunSuite(ctx context.Context, config ServerConfig, opts SuiteOptions) ([]TestResult, error) {
+	selection, err := newTestSelectionFor(opts.Run, opts.Skip, opts.Extended)
+	if err != nil {
+		return nil, err
+	}
+	tests := unpreparedTests
+	if opts.SignatureV2 {
+		tests = insertSignatureTests(tests, signatureV2)
+	}
+	if opts.SignatureV4A {
+		tests = insertSignatureTests(tests, signatureV4A)
+	}
+	if err := checkDependencies(tests); err != nil {
+		return nil, err
+	}
+	if config.Endpoint == "" {
+		return nil, errors.New("The endpoint of the server to test is required.")
+	}
+	if config.Access == "" || config.Secret == "" {
+		return nil, errors.New("Access and secret keys are required to run the tests.")
+	}
+	// Fill in the defaults the s3verify command would use.
+	defaults, err := newServerConfigFor(config.Access, config.Secret, config.Endpoint, config.Region, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if config.Client == nil {
+		config.Client = defaults.Client
+	}
+	config.Region = defaults.Region
+	config.ctx = ctx
+	config.lookup, err = parseBucketLookup(opts.Lookup)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Profile != "" {
+		if config.profile, err = loadProfile(opts.Profile); err != nil {
+			return nil, err
+		}
+	}
+
+	suffix := opts.Suffix
+	if suffix == "" {
+		suffix = "tmp-bkt"
+	}
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UTC().UnixNano()
+	}
+	run := newRunContext(suffix, seed)
+	run.quiet = !opts.Progress
+	if opts.Parallel > 1 {
+		run.parallel = opts.Parallel
+	}
+	results := runTests(config, run, tests, selection)
+	return results, ctx.Err()
+}
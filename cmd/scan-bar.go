@@ -19,6 +19,7 @@ package cmd
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/cheggaaa/pb"
 	"github.com/minio/mc/pkg/console"
@@ -51,6 +52,8 @@ type scanBarFunc func(string)
 // scanBarFactory returns a progress bar function to report URL scanning.
 func scanBarFactory() scanBarFunc {
 	prevLineSize := 0
+	// Uploads running concurrently spin the same bar, see --parallel.
+	var mu sync.Mutex
 	var termWidth int
 	termWidth, e := pb.GetTerminalWidth()
 	if e != nil {
@@ -60,6 +63,8 @@ func scanBarFactory() scanBarFunc {
 	}
 
 	return func(message string) {
+		mu.Lock()
+		defer mu.Unlock()
 		scanPrefix := fmt.Sprintf("%s", message)
 		padding := messageWidth - len([]rune(scanPrefix))
 
@@ -17,44 +17,166 @@
 package cmd
 
 import (
+	"context"
+	"crypto/tls"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
 	"time"
 
 	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/console"
 	"github.com/minio/mc/pkg/httptracer"
 )
 
 // ServerConfig - container for all the user passed server info
 // and a reusable http.Client
 type ServerConfig struct {
-	Access   string
-	Secret   string
-	Endpoint string
-	Region   string
-	Client   *http.Client
+	Access       string
+	Secret       string
+	SessionToken string // Session token of temporary credentials, empty for long term credentials.
+	Endpoint     string
+	Region       string
+	Client       *http.Client
+
+	stats     *requestStats   // Counters for the test currently being run, nil outside of tests.
+	reference *ServerConfig   // Server every request is also sent to for comparison, nil unless --reference-url was used.
+	ctx       context.Context // Cancels the requests of the run, nil if the run can not be canceled.
+	tlsConfig *tls.Config     // TLS settings of the target, nil for the default settings.
+	expired   *credentials    // Temporary credentials that expired, nil unless --expired-aws-profile was used.
+	signature string          // Signature version requests are signed with, Signature Version 4 if empty.
+	lookup    string          // Style buckets are addressed with, see --lookup. Path style if empty.
+	profile   *vendorProfile  // Known deviations of the server, nil unless --profile was used.
+}
+
+// canceled - return why the run was canceled, nil if it was not.
+func (c ServerConfig) canceled() error {
+	if c.ctx == nil {
+		return nil
+	}
+	return c.ctx.Err()
 }
 
 // newServerConfig - new server config.
 func newServerConfig(ctx *cli.Context) (*ServerConfig, error) {
+	verbose := ctx.Bool("verbose") || ctx.GlobalBool("verbose")
+	access, secret, endpoint, region := ctx.String("access"), ctx.String("secret"), ctx.String("url"), ctx.String("region")
+	var sessionToken string
+	replayDir := ctx.String("replay")
+	if replayDir == "" {
+		// Look for credentials everywhere the other S3 tools look for them.
+		creds, err := newCredentialChain(ctx, endpoint).resolve()
+		if err != nil {
+			return nil, err
+		}
+		access, secret, sessionToken = creds.access, creds.secret, creds.sessionToken
+		// The alias of --mc-alias names the server as well.
+		if creds.endpoint != "" && !ctx.GlobalIsSet("url") {
+			endpoint = creds.endpoint
+		}
+		if creds.source != "" && !isQuiet(ctx) {
+			console.Printf("S3Verify using credentials from %s.\n", creds.source)
+		}
+	} else {
+		// A replayed run talks to the recorded server, whose credentials are not part of the recording.
+		session, err := loadCassetteSession(replayDir)
+		if err != nil {
+			return nil, err
+		}
+		endpoint, region = session.Endpoint, session.Region
+		if access == "" && secret == "" {
+			access, secret = "REPLAY", "REPLAY"
+		}
+	}
 	// Set config fields from either flags or env. variables.
+	serverCfg, err := newServerConfigFor(access, secret, endpoint, region, nil, verbose)
+	if err != nil {
+		return nil, err
+	}
+	serverCfg.SessionToken = sessionToken
+	serverCfg.lookup, err = parseBucketLookup(ctx.GlobalString("lookup"))
+	if err != nil {
+		return nil, err
+	}
+	if fileName := ctx.GlobalString("profile"); fileName != "" {
+		if serverCfg.profile, err = loadProfile(fileName); err != nil {
+			return nil, err
+		}
+	}
+	if err := configureRun(ctx, serverCfg, ctx.String("record"), replayDir, verbose); err != nil {
+		return nil, err
+	}
+	return serverCfg, nil
+}
+
+// configureRun - set up serverCfg for the flags shared by every tested server: the temporary credentials
+// that expired, recording its exchanges to recordDir or replaying them from replayDir, and the reference server.
+func configureRun(ctx *cli.Context, serverCfg *ServerConfig, recordDir, replayDir string, verbose bool) error {
+	if profile := ctx.GlobalString("expired-aws-profile"); profile != "" {
+		chain := defaultCredentialChain(serverCfg.Endpoint)
+		chain.awsProfile = profile
+		expired, err := chain.resolve()
+		if err != nil {
+			return err
+		}
+		if expired.sessionToken == "" {
+			return fmt.Errorf("AWS profile %s holds no session token, temporary credentials are needed to test expired tokens", profile)
+		}
+		serverCfg.expired = &expired
+	}
+	if replayDir != "" {
+		var transport http.RoundTripper = newCassettePlayer(replayDir)
+		if verbose {
+			transport = httptracer.GetNewTraceTransport(newTraceV4(), transport)
+		}
+		serverCfg.Client.Transport = transport
+	} else if recordDir != "" {
+		secrets := []string{serverCfg.Access, serverCfg.SessionToken}
+		if serverCfg.expired != nil {
+			secrets = append(secrets, serverCfg.expired.access, serverCfg.expired.sessionToken)
+		}
+		recorder, err := newCassetteRecorder(recordDir, secrets, serverCfg.Client.Transport)
+		if err != nil {
+			return err
+		}
+		serverCfg.Client.Transport = recorder
+	}
+	// Responses of the reference server are compared against those of the tested server.
+	if ctx.String("reference-url") != "" {
+		var err error
+		serverCfg.reference, err = newServerConfigFor(ctx.String("reference-access"), ctx.String("reference-secret"),
+			ctx.String("reference-url"), ctx.String("reference-region"), nil, verbose)
+		if err != nil {
+			return err
+		}
+		serverCfg.reference.lookup = serverCfg.lookup
+	}
+	return nil
+}
+
+// newServerConfigFor - new server config for the server at endpoint, an empty region is replaced by the default region.
+// A nil tlsConfig uses the default TLS settings.
+func newServerConfigFor(access, secret, endpoint, region string, tlsConfig *tls.Config, verbose bool) (*ServerConfig, error) {
+	transport := &http.Transport{
+		Dial: (&net.Dialer{
+			Timeout: 5 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout: 5 * time.Second,
+		TLSClientConfig:     tlsConfig,
+	}
 	serverCfg := &ServerConfig{
-		Access:   ctx.String("access"),
-		Secret:   ctx.String("secret"),
-		Endpoint: ctx.String("url"),
-		Region:   ctx.String("region"),
+		Access:   access,
+		Secret:   secret,
+		Endpoint: endpoint,
+		Region:   region,
 		Client: &http.Client{
-			Transport: &http.Transport{
-				Dial: (&net.Dialer{
-					Timeout: 5 * time.Second,
-				}).Dial,
-				TLSHandshakeTimeout: 5 * time.Second,
-			},
+			Transport: transport,
 		},
+		tlsConfig: tlsConfig,
 	}
 	// Region was not provided, we try to set a default region instead.
-	if !ctx.IsSet("region") {
+	if region == "" {
 		endpointURL, err := url.Parse(serverCfg.Endpoint)
 		if err != nil {
 			return nil, err
@@ -62,9 +184,9 @@ func newServerConfig(ctx *cli.Context) (*ServerConfig, error) {
 		serverCfg.Region = defaultRegion(endpointURL)
 	}
 
-	if ctx.Bool("verbose") || ctx.GlobalBool("verbose") {
+	if verbose {
 		// Set up new tracer.
-		serverCfg.Client.Transport = httptracer.GetNewTraceTransport(newTraceV4(), http.DefaultTransport)
+		serverCfg.Client.Transport = httptracer.GetNewTraceTransport(newTraceV4(), transport)
 	}
 	return serverCfg, nil
 }
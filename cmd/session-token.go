@@ -0,0 +1,160 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// sessionTokenCheck - send requests with the credentials of rejected every way a session token is sent:
+// list the buckets signed with the Authorization header and presigned, and upload an object with a
+// streaming signature and with a POST policy. Verify that the server rejects every request and, with
+// the credentials of config, that the rejected uploads stored nothing. Their objects are named after keyPrefix.
+func sessionTokenCheck(config, rejected ServerConfig, run *RunContext, result *TestResult, keyPrefix string, expectedStatusCode int, expectedCode string) {
+	// The credentials are only meant for the tested server.
+	config.reference = nil
+	rejected.reference = nil
+	for _, presign := range []bool{false, true} {
+		name := "Header"
+		if presign {
+			name = "Presigned"
+		}
+		// Spin the scanBar
+		run.scanBar(result.Message)
+		req, err := newListBucketsReq()
+		if err != nil {
+			result.addCheck(config, name, err)
+			continue
+		}
+		req.presignURL = presign
+		req.expires = 60
+		res, err := rejected.execRequest("GET", req)
+		if err != nil {
+			result.addCheck(config, name, err)
+			continue
+		}
+		err = verifyErrorCode(res, expectedStatusCode, expectedCode)
+		closeResponse(res)
+		result.addCheck(config, name, err)
+	}
+	bucketName := run.buckets[0].Name
+	for _, name := range []string{"Streaming", "PostPolicy"} {
+		// Spin the scanBar
+		run.scanBar(result.Message)
+		object := &ObjectInfo{
+			Key:  keyPrefix + strings.ToLower(name),
+			Body: []byte(randString(60, run.newRandSource(), "s3verify session token data: ")),
+		}
+		err := sessionTokenUploadVerify(config, rejected, run, bucketName, object, name == "Streaming", expectedStatusCode, expectedCode)
+		result.addCheck(config, name, err)
+	}
+}
+
+// sessionTokenUploadVerify - upload object with the credentials of rejected, with a streaming signature or with
+// a POST policy, and verify that the server rejects the upload without storing the object.
+func sessionTokenUploadVerify(config, rejected ServerConfig, run *RunContext, bucketName string, object *ObjectInfo, streaming bool, expectedStatusCode int, expectedCode string) error {
+	var req Request
+	var err error
+	method := "PUT"
+	if streaming {
+		req, err = newPutObjectStreamingReq(rejected, bucketName, object.Key, object.Body)
+	} else {
+		method = "POST"
+		req, err = newPostObjectReq(rejected, bucketName, object.Key, object.Body)
+	}
+	if err != nil {
+		return err
+	}
+	res, err := rejected.execRequest(method, req)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+	rejectedErr := verifyErrorCode(res, expectedStatusCode, expectedCode)
+	// The rejected upload must not have stored anything.
+	if err := verifyObjectNotStored(config, run, bucketName, object); err != nil {
+		return err
+	}
+	return rejectedErr
+}
+
+// newSessionTokenSkip - the result of a session token test that can not be run without credentials that are not set.
+func newSessionTokenSkip(message, reason string) TestResult {
+	return TestResult{
+		Message: message,
+		Status:  TestSkip,
+		Err:     errors.New(reason),
+	}
+}
+
+// tamperSessionToken - change the last character of a session token.
+func tamperSessionToken(sessionToken string) string {
+	last := sessionToken[len(sessionToken)-1]
+	if last == 'A' {
+		return sessionToken[:len(sessionToken)-1] + "B"
+	}
+	return sessionToken[:len(sessionToken)-1] + "A"
+}
+
+// Test that temporary credentials are rejected without their session token.
+func mainSessionTokenMissing(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] SessionToken/Missing:", curTest, run.totalNumTest)
+	if config.SessionToken == "" {
+		return newSessionTokenSkip(message, "Needs temporary credentials with a session token.")
+	}
+	// Spin the scanBar
+	run.scanBar(message)
+	result := newTestResult(message, nil)
+	// Without its token the access key of temporary credentials is unknown.
+	rejected := config
+	rejected.SessionToken = ""
+	sessionTokenCheck(config, rejected, run, &result, "s3verify/session/token/missing/", http.StatusForbidden, "InvalidAccessKeyId")
+	return result
+}
+
+// Test that temporary credentials are rejected with a session token that was changed.
+func mainSessionTokenTampered(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] SessionToken/Tampered:", curTest, run.totalNumTest)
+	if config.SessionToken == "" {
+		return newSessionTokenSkip(message, "Needs temporary credentials with a session token.")
+	}
+	// Spin the scanBar
+	run.scanBar(message)
+	result := newTestResult(message, nil)
+	rejected := config
+	rejected.SessionToken = tamperSessionToken(config.SessionToken)
+	sessionTokenCheck(config, rejected, run, &result, "s3verify/session/token/tampered/", http.StatusBadRequest, "InvalidToken")
+	return result
+}
+
+// Test that temporary credentials are rejected once they expired.
+func mainSessionTokenExpired(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] SessionToken/Expired:", curTest, run.totalNumTest)
+	if config.expired == nil {
+		return newSessionTokenSkip(message, "Needs temporary credentials that expired, see --expired-aws-profile.")
+	}
+	// Spin the scanBar
+	run.scanBar(message)
+	result := newTestResult(message, nil)
+	rejected := config
+	rejected.Access, rejected.Secret, rejected.SessionToken = config.expired.access, config.expired.secret, config.expired.sessionToken
+	sessionTokenCheck(config, rejected, run, &result, "s3verify/session/token/expired/", http.StatusBadRequest, "ExpiredToken")
+	return result
+}
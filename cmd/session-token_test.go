@@ -0,0 +1,150 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// Temporary credentials accepted by the in-memory server.
+const (
+	testTemporaryAccessKey = "s3verify-temporary-access"
+	testTemporarySecretKey = "s3verify-temporary-secret"
+	testSessionToken       = "s3verify-session-token"
+)
+
+// runSessionTokenSuite - run the selected tests against handler with temporary credentials.
+func runSessionTokenSuite(t *testing.T, handler http.Handler, selection testSelection) map[string]TestResult {
+	setGlobals(false)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	config, err := newServerConfigFor(testTemporaryAccessKey, testTemporarySecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.SessionToken = testSessionToken
+	config.expired = &credentials{access: "s3verify-expired-access", secret: "s3verify-expired-secret", sessionToken: "s3verify-expired-token"}
+	results := make(map[string]TestResult)
+	for _, result := range runTests(*config, newQuietRunContext("session-token", 1), unpreparedTests, selection) {
+		results[result.Name] = result
+	}
+	return results
+}
+
+// newSessionTokenServer - an in-memory server accepting the temporary test credentials.
+func newSessionTokenServer() *s3mem.Server {
+	server := s3mem.New(testAccessKey, testSecretKey, testRegion)
+	server.AddTemporaryCredentials(testTemporaryAccessKey, testTemporarySecretKey, testSessionToken, time.Now().Add(time.Hour))
+	server.AddTemporaryCredentials("s3verify-expired-access", "s3verify-expired-secret", "s3verify-expired-token", time.Now().Add(-time.Hour))
+	return server
+}
+
+// TestSessionTokens - every request is signed along with the session token of temporary
+// credentials, and the server rejects requests with a missing, tampered or expired token.
+func TestSessionTokens(t *testing.T) {
+	// Skip the presigned GET test which waits for its URL to expire.
+	selection := testSelection{extended: true, skip: regexp.MustCompile("^GetObject/Presigned$")}
+	results := runSessionTokenSuite(t, newSessionTokenServer(), selection)
+	for _, test := range selection.filter(unpreparedTests) {
+		if result := results[test.Name]; result.Status != TestPass {
+			t.Errorf("%s: expected %s, got %s: %v", test.Name, TestPass, result.Status, result.Err)
+		}
+	}
+	for _, name := range []string{"SessionToken/Missing", "SessionToken/Tampered", "SessionToken/Expired"} {
+		if checks := results[name].Checks; len(checks) != 4 {
+			t.Errorf("%s: expected a header, a presigned, a streaming and a POST policy check, got %d checks", name, len(checks))
+		}
+	}
+}
+
+// TestSessionTokensAgainstBrokenServer - the tests fail against a server that accepts any token.
+func TestSessionTokensAgainstBrokenServer(t *testing.T) {
+	handler := brokenServer{
+		next: newSessionTokenServer(),
+		mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+			if rec.Code == http.StatusBadRequest || rec.Code == http.StatusForbidden {
+				replaceResponse(rec, http.StatusOK)
+			}
+		},
+	}
+	results := runSessionTokenSuite(t, handler, testSelection{run: regexp.MustCompile("^SessionToken/")})
+	for _, name := range []string{"SessionToken/Missing", "SessionToken/Tampered", "SessionToken/Expired"} {
+		if result := results[name]; result.Status != TestFail {
+			t.Errorf("%s: expected %s, got %s", name, TestFail, result.Status)
+		}
+	}
+}
+
+// TestSessionTokensAgainstBrokenUploads - the tests fail against servers that only validate the
+// token of requests signed with the Authorization header or presigned.
+func TestSessionTokensAgainstBrokenUploads(t *testing.T) {
+	variants := []struct {
+		check   string
+		handler http.Handler
+	}{
+		{"Streaming", brokenServer{
+			next: newSessionTokenServer(),
+			mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+				if r.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" && rec.Code != http.StatusOK {
+					replaceResponse(rec, http.StatusOK)
+				}
+			},
+		}},
+		{"PostPolicy", brokenServer{
+			next: newSessionTokenServer(),
+			mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+				if r.Method == "POST" && !isObjectRequest(r) && rec.Code != http.StatusNoContent {
+					replaceResponse(rec, http.StatusNoContent)
+				}
+			},
+		}},
+		// Storing the rejected uploads anyway must not prevent removing the bucket.
+		{"Streaming", storingServer{
+			next:   newSessionTokenServer(),
+			accept: func(r *http.Request) bool { return strings.Contains(r.URL.Path, "/s3verify/session/token/") },
+		}},
+	}
+	selection := testSelection{run: regexp.MustCompile("^(SessionToken/.*|RemoveBucket)$")}
+	for i, variant := range variants {
+		results := runSessionTokenSuite(t, variant.handler, selection)
+		for _, name := range []string{"SessionToken/Missing", "SessionToken/Tampered", "SessionToken/Expired"} {
+			result := results[name]
+			if result.Status != TestFail {
+				t.Errorf("Variant %d: %s: expected %s, got %s", i+1, name, TestFail, result.Status)
+			}
+			for _, check := range result.Checks {
+				expected := TestPass
+				if check.Name == variant.check {
+					expected = TestFail
+				}
+				if check.Status != expected {
+					t.Errorf("Variant %d: %s/%s: expected %s, got %s: %v", i+1, name, check.Name, expected, check.Status, check.Err)
+				}
+			}
+		}
+		if result := results["RemoveBucket"]; result.Status != TestPass {
+			t.Errorf("Variant %d: RemoveBucket: expected %s, got %s: %v", i+1, TestPass, result.Status, result.Err)
+		}
+	}
+}
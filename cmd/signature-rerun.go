@@ -0,0 +1,123 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Signature versions requests can be signed with, see --signature. Requests are signed with
+// Signature Version 4 unless ServerConfig.signature says otherwise.
+const (
+	signatureV4  = "v4"
+	signatureV2  = "v2"
+	signatureV4A = "v4a" // SigV4A, for the region set of the region of the server. Streaming uploads stay on Signature Version 4.
+)
+
+// Tests that are run again signed with AWS Signature Version 2 by --signature v2. Between them
+// they sign requests with the Authorization header, presigned URLs and POST policies. Tests
+// whose objects are listed by later tests are left out, uploading them again would change the listings.
+var signatureV2Tests = map[string]bool{
+	"HeadBucket":          true,
+	"HeadObject":          true,
+	"ListBuckets":         true,
+	"ListObjectsV1":       true,
+	"ListObjectsV2":       true,
+	"PutObject/Presigned": true,
+	"PostObject":          true,
+	"CopyObject":          true,
+	"GetObject":           true,
+	"GetObject/Presigned": true,
+	"GetObject/Range":     true,
+}
+
+// Tests that are run again signed with SigV4A by --signature v4a, the core object tests
+// signed with the Authorization header and presigned URLs. POST policies are only
+// signed with Signature Version 4.
+var signatureV4ATests = map[string]bool{
+	"HeadBucket":          true,
+	"HeadObject":          true,
+	"ListBuckets":         true,
+	"ListObjectsV1":       true,
+	"ListObjectsV2":       true,
+	"PutObject/Presigned": true,
+	"CopyObject":          true,
+	"GetObject":           true,
+	"GetObject/Presigned": true,
+	"GetObject/Range":     true,
+}
+
+// signatureRerunTests - the tests run again by every signature version of --signature but v4.
+var signatureRerunTests = map[string]map[string]bool{
+	signatureV2:  signatureV2Tests,
+	signatureV4A: signatureV4ATests,
+}
+
+// rerunWithSignature - check the version of --signature, return the version the tests
+// must be run again signed with, empty if they are only run signed with Signature Version 4.
+func rerunWithSignature(signature string) (string, error) {
+	switch signature {
+	case "", signatureV4:
+		return "", nil
+	case signatureV2, signatureV4A:
+		return signature, nil
+	}
+	return "", fmt.Errorf("Unknown signature version %s, use v4, v4a or v2.", signature)
+}
+
+// signatureRerunName - the name of the rerun of the test name signed with version, such as SignatureV2/HeadBucket.
+func signatureRerunName(version, name string) string {
+	return "Signature" + strings.ToUpper(version) + "/" + name
+}
+
+// insertSignatureTests - add a SignatureV2/<name> or SignatureV4A/<name> test after every test
+// of the reruns of version, which runs the same test again signed with version once the test passed.
+func insertSignatureTests(tests []APItest, version string) []APItest {
+	merged := []APItest{}
+	for _, test := range tests {
+		merged = append(merged, test)
+		if !signatureRerunTests[version][test.Name] {
+			continue
+		}
+		name := signatureRerunName(version, test.Name)
+		merged = append(merged, APItest{
+			Name:     name,
+			Test:     signedWith(version, name, test.Test),
+			Extended: test.Extended,
+			Depends:  []string{test.Name}, // Only meaningful once the test passed signed with Signature Version 4.
+			Parallel: test.Parallel,
+		})
+	}
+	return merged
+}
+
+// signedWith - run test with every request signed with the signature version.
+func signedWith(version, name string, test func(ServerConfig, *RunContext, int) TestResult) func(ServerConfig, *RunContext, int) TestResult {
+	return func(config ServerConfig, run *RunContext, curTest int) TestResult {
+		config.signature = version
+		// Sign the requests sent to the reference server the same way.
+		if config.reference != nil {
+			reference := *config.reference
+			reference.signature = version
+			config.reference = &reference
+		}
+		result := test(config, run, curTest)
+		result.Message = fmt.Sprintf("[%02d/%d] %s:", curTest, run.totalNumTest, name)
+		return result
+	}
+}
@@ -0,0 +1,193 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+	"github.com/minio/s3verify/signv4"
+)
+
+// runSignatureSuite - run the selected tests, along with their reruns signed with
+// the signature version, against handler and return their results by name.
+func runSignatureSuite(t *testing.T, handler http.Handler, version string, selection testSelection) map[string]TestResult {
+	setGlobals(false)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := insertSignatureTests(unpreparedTests, version)
+	if err := checkDependencies(tests); err != nil {
+		t.Fatal(err)
+	}
+	results := make(map[string]TestResult)
+	for _, result := range runTests(*config, newQuietRunContext("test-bkt", 1), tests, selection) {
+		results[result.Name] = result
+	}
+	return results
+}
+
+// TestSignatureV2 - every test signed with Signature Version 2 passes against a server that supports it.
+func TestSignatureV2(t *testing.T) {
+	// Skip the presigned GET tests which wait for their URL to expire.
+	selection := testSelection{extended: true, skip: regexp.MustCompile("GetObject/Presigned$")}
+	results := runSignatureSuite(t, s3mem.New(testAccessKey, testSecretKey, testRegion), signatureV2, selection)
+	for _, test := range selection.filter(insertSignatureTests(unpreparedTests, signatureV2)) {
+		expected := TestPass
+		// The tests of temporary credentials need a session token.
+		if strings.HasPrefix(test.Name, "SessionToken/") {
+			expected = TestSkip
+		}
+		if result := results[test.Name]; result.Status != expected {
+			t.Errorf("%s: expected %s, got %s: %v", test.Name, expected, result.Status, result.Err)
+		}
+	}
+	for name := range signatureV2Tests {
+		if _, ok := results["SignatureV2/"+name]; !ok && !strings.HasSuffix(name, "GetObject/Presigned") {
+			t.Errorf("SignatureV2/%s was not run", name)
+		}
+	}
+}
+
+// TestSignatureV2AgainstBrokenServer - the reruns fail against a server that only supports Signature Version 4.
+func TestSignatureV2AgainstBrokenServer(t *testing.T) {
+	handler := brokenServer{
+		next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+		mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+			if strings.HasPrefix(r.Header.Get("Authorization"), "AWS ") || r.URL.Query().Get("AWSAccessKeyId") != "" {
+				replaceResponse(rec, http.StatusForbidden)
+			}
+		},
+	}
+	selection := testSelection{run: regexp.MustCompile("^SignatureV2/(HeadBucket|ListBuckets|PutObject/Presigned|CopyObject|GetObject)$")}
+	results := runSignatureSuite(t, handler, signatureV2, selection)
+	for name, result := range results {
+		expected := TestPass
+		if strings.HasPrefix(name, "SignatureV2/") {
+			expected = TestFail
+		}
+		if result.Status != expected {
+			t.Errorf("%s: expected %s, got %s: %v", name, expected, result.Status, result.Err)
+		}
+	}
+}
+
+// TestSignatureV4A - every test signed with SigV4A passes against a server that supports it.
+func TestSignatureV4A(t *testing.T) {
+	// Skip the presigned GET tests which wait for their URL to expire.
+	selection := testSelection{extended: true, skip: regexp.MustCompile("GetObject/Presigned$")}
+	results := runSignatureSuite(t, s3mem.New(testAccessKey, testSecretKey, testRegion), signatureV4A, selection)
+	for _, test := range selection.filter(insertSignatureTests(unpreparedTests, signatureV4A)) {
+		expected := TestPass
+		// The tests of temporary credentials need a session token.
+		if strings.HasPrefix(test.Name, "SessionToken/") {
+			expected = TestSkip
+		}
+		if result := results[test.Name]; result.Status != expected {
+			t.Errorf("%s: expected %s, got %s: %v", test.Name, expected, result.Status, result.Err)
+		}
+	}
+	for name := range signatureV4ATests {
+		if _, ok := results["SignatureV4A/"+name]; !ok && !strings.HasSuffix(name, "GetObject/Presigned") {
+			t.Errorf("SignatureV4A/%s was not run", name)
+		}
+	}
+}
+
+// TestSignatureV4AAgainstBrokenServer - the reruns fail against a server that does not support SigV4A.
+func TestSignatureV4AAgainstBrokenServer(t *testing.T) {
+	handler := brokenServer{
+		next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+		mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+			if strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-ECDSA-P256-SHA256 ") || r.URL.Query().Get("X-Amz-Region-Set") != "" {
+				replaceResponse(rec, http.StatusForbidden)
+			}
+		},
+	}
+	selection := testSelection{run: regexp.MustCompile("^SignatureV4A/(HeadBucket|ListBuckets|PutObject/Presigned|CopyObject|GetObject)$")}
+	results := runSignatureSuite(t, handler, signatureV4A, selection)
+	for name, result := range results {
+		expected := TestPass
+		if strings.HasPrefix(name, "SignatureV4A/") {
+			expected = TestFail
+		}
+		if result.Status != expected {
+			t.Errorf("%s: expected %s, got %s: %v", name, expected, result.Status, result.Err)
+		}
+	}
+}
+
+// TestSignatureV4ARegionSet - SigV4A signatures are only accepted if their region set includes the region of the server.
+func TestSignatureV4ARegionSet(t *testing.T) {
+	server := httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+	defer server.Close()
+	testCases := []struct {
+		regionSet  string
+		statusCode int
+	}{
+		{"*", http.StatusOK},
+		{testRegion, http.StatusOK},
+		{"eu-west-1," + testRegion, http.StatusOK},
+		{"us-*", http.StatusOK},
+		{"eu-west-1", http.StatusBadRequest},
+		{"eu-*", http.StatusBadRequest},
+		{"", http.StatusBadRequest},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("GET", server.URL+"/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+		res, err := http.DefaultClient.Do(signv4.SignV4A(*req, testAccessKey, testSecretKey, "", testCase.regionSet))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != testCase.statusCode {
+			t.Errorf("Test %d: region set %q: expected status %d, got %d", i+1, testCase.regionSet, testCase.statusCode, res.StatusCode)
+		}
+	}
+}
+
+// TestRerunWithSignature - only known signature versions are accepted by --signature.
+func TestRerunWithSignature(t *testing.T) {
+	testCases := []struct {
+		signature string
+		rerun     string
+		valid     bool
+	}{
+		{"", "", true},
+		{"v4", "", true},
+		{"v2", "v2", true},
+		{"v4a", "v4a", true},
+		{"v3", "", false},
+	}
+	for i, testCase := range testCases {
+		rerun, err := rerunWithSignature(testCase.signature)
+		if rerun != testCase.rerun || (err == nil) != testCase.valid {
+			t.Errorf("Test %d: expected %q and valid %v, got %q and %v", i+1, testCase.rerun, testCase.valid, rerun, err)
+		}
+	}
+}
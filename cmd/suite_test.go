@@ -0,0 +1,477 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/minio/s3verify/s3mem"
+	"github.com/minio/s3verify/signv4"
+)
+
+// Credentials accepted by the in-memory server.
+const (
+	testAccessKey = "s3verify-access"
+	testSecretKey = "s3verify-secret"
+	testRegion    = "us-east-1"
+)
+
+// newQuietRunContext - a new run that prints nothing to the console.
+func newQuietRunContext(suffix string, seed int64) *RunContext {
+	run := newRunContext(suffix, seed)
+	run.quiet = true
+	return run
+}
+
+// runSuite - run the selected unprepared tests against handler and return their results by name.
+func runSuite(t *testing.T, handler http.Handler, selection testSelection) map[string]TestResult {
+	setGlobals(false)
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make(map[string]TestResult)
+	for _, result := range runTests(*config, newQuietRunContext("test-bkt", 1), unpreparedTests, selection) {
+		results[result.Name] = result
+	}
+	return results
+}
+
+// TestSuiteAgainstMemoryServer - every test passes against a server that behaves like S3.
+func TestSuiteAgainstMemoryServer(t *testing.T) {
+	results := runSuite(t, s3mem.New(testAccessKey, testSecretKey, testRegion), testSelection{extended: true})
+	if len(results) != len(unpreparedTests) {
+		t.Fatalf("Expected %d results, got %d", len(unpreparedTests), len(results))
+	}
+	for _, test := range unpreparedTests {
+		expected := TestPass
+		// The tests of temporary credentials need a session token.
+		if strings.HasPrefix(test.Name, "SessionToken/") {
+			expected = TestSkip
+		}
+		if result := results[test.Name]; result.Status != expected {
+			t.Errorf("%s: expected %s, got %s: %v", test.Name, expected, result.Status, result.Err)
+		}
+	}
+}
+
+// TestConcurrentRuns - runs do not share any state, several of them can be
+// made at the same time against one server.
+func TestConcurrentRuns(t *testing.T) {
+	setGlobals(false)
+	server := httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Skip the presigned GET test which waits for its URL to expire, and the
+	// tests of temporary credentials.
+	selection := testSelection{skip: regexp.MustCompile("^(GetObject/Presigned|SessionToken/.*)$")}
+	runs := []*RunContext{newQuietRunContext("run-one", 1), newQuietRunContext("run-two", 2)}
+	results := make([][]TestResult, len(runs))
+	var wg sync.WaitGroup
+	for i, run := range runs {
+		wg.Add(1)
+		go func(i int, run *RunContext) {
+			defer wg.Done()
+			results[i] = runTests(*config, run, unpreparedTests, selection)
+		}(i, run)
+	}
+	wg.Wait()
+	for i, run := range runs {
+		for _, result := range results[i] {
+			if result.Status != TestPass {
+				t.Errorf("%s: %s: expected %s, got %s: %v", run.suffix, result.Name, TestPass, result.Status, result.Err)
+			}
+		}
+		// Every run only knows about the buckets it created itself.
+		for _, bucket := range run.buckets {
+			if !strings.HasPrefix(bucket.Name, "s3verify-"+run.suffix) {
+				t.Errorf("%s: unexpected bucket %s", run.suffix, bucket.Name)
+			}
+		}
+	}
+}
+
+// TestParallelRun - with --parallel the independent tests run at the same
+// time, and their results are still reported in order.
+func TestParallelRun(t *testing.T) {
+	setGlobals(false)
+	server := httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Skip the presigned GET test which waits for its URL to expire, and the
+	// tests of temporary credentials.
+	selection := testSelection{extended: true, skip: regexp.MustCompile("^(GetObject/Presigned|SessionToken/.*)$")}
+	run := newQuietRunContext("parallel", 1)
+	run.parallel = 8
+	results := runTests(*config, run, unpreparedTests, selection)
+	tests := selection.filter(unpreparedTests)
+	if len(results) != len(tests) {
+		t.Fatalf("Expected %d results, got %d", len(tests), len(results))
+	}
+	for i, result := range results {
+		if result.Name != tests[i].Name {
+			t.Errorf("Result %d: expected %s, got %s", i+1, tests[i].Name, result.Name)
+		}
+		if result.Status != TestPass {
+			t.Errorf("%s: expected %s, got %s: %v", result.Name, TestPass, result.Status, result.Err)
+		}
+	}
+	if len(run.objects) < globalNumTestObjects {
+		t.Errorf("Expected at least %d objects, got %d", globalNumTestObjects, len(run.objects))
+	}
+}
+
+// brokenServer - a server that alters the responses of an otherwise correct
+// server, to check that the suite notices when a server misbehaves.
+type brokenServer struct {
+	next   http.Handler
+	mutate func(r *http.Request, rec *httptest.ResponseRecorder)
+}
+
+// ServeHTTP - serve r with the wrapped server and send back the altered response.
+func (b brokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := httptest.NewRecorder()
+	b.next.ServeHTTP(rec, r)
+	b.mutate(r, rec)
+	for name, values := range rec.HeaderMap {
+		w.Header()[name] = values
+	}
+	// Responses to HEAD requests describe a body that is not sent.
+	if r.Method != "HEAD" {
+		w.Header().Set("Content-Length", strconv.Itoa(rec.Body.Len()))
+	}
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+}
+
+// storingServer - a server that stores the uploads matched by accept, whatever their signature and body,
+// and serves every other request with next.
+type storingServer struct {
+	next   http.Handler
+	accept func(r *http.Request) bool
+}
+
+// ServeHTTP - store an accepted upload with a request signed for next, or serve r with next.
+func (s storingServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "PUT" || !s.accept(r) {
+		s.next.ServeHTTP(w, r)
+		return
+	}
+	io.Copy(ioutil.Discard, r.Body)
+	req, err := http.NewRequest("PUT", "http://"+r.Host+r.URL.Path, bytes.NewReader([]byte("stored")))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	s.next.ServeHTTP(w, signv4.SignV4(*req, testAccessKey, testSecretKey, "", testRegion))
+}
+
+// replaceResponse - replace a response with one of the given status and no body.
+func replaceResponse(rec *httptest.ResponseRecorder, statusCode int) {
+	rec.Code = statusCode
+	rec.Body.Reset()
+	rec.HeaderMap.Del("Content-Type")
+}
+
+// replaceBody - replace old with new in the body of a response.
+func replaceBody(rec *httptest.ResponseRecorder, old, new string) {
+	body := strings.Replace(rec.Body.String(), old, new, -1)
+	rec.Body.Reset()
+	rec.Body.WriteString(body)
+}
+
+// isObjectRequest - check whether r names an object rather than a bucket.
+func isObjectRequest(r *http.Request) bool {
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	return len(parts) == 2 && parts[1] != ""
+}
+
+// hasQuery - check whether the query of r contains key.
+func hasQuery(r *http.Request, key string) bool {
+	_, ok := r.URL.Query()[key]
+	return ok
+}
+
+// brokenVariants - servers that break a single API in a way the named test has to catch.
+var brokenVariants = []struct {
+	test   string
+	mutate func(r *http.Request, rec *httptest.ResponseRecorder)
+}{
+	{"PutBucket", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "PUT" && !isObjectRequest(r) && rec.Code == http.StatusOK {
+			rec.HeaderMap.Set("Location", "/elsewhere")
+		}
+	}},
+	{"PutBucket", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		// Dates must be sent in http.TimeFormat.
+		rec.HeaderMap.Set("Date", time.Now().UTC().Format(time.RFC3339))
+	}},
+	{"PutBucket", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		rec.HeaderMap.Set("X-Unknown-Header", "s3verify")
+	}},
+	{"PutBucket/InvalidNames", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "PUT" && !isObjectRequest(r) && rec.Code == http.StatusBadRequest {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"PutBucketPolicy", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "PUT" && hasQuery(r, "policy") {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"GetBucketPolicy", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && hasQuery(r, "policy") && rec.Code == http.StatusOK {
+			replaceBody(rec, `"Allow"`, `"Deny"`)
+		}
+	}},
+	{"PutObject", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "PUT" && isObjectRequest(r) {
+			replaceResponse(rec, http.StatusCreated)
+		}
+	}},
+	{"HeadBucket", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "HEAD" && !isObjectRequest(r) {
+			replaceResponse(rec, http.StatusNoContent)
+		}
+	}},
+	{"HeadObject", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "HEAD" && isObjectRequest(r) {
+			rec.HeaderMap.Set("Last-Modified", time.Now().UTC().Format(time.RFC3339))
+		}
+	}},
+	{"HeadObject/IfModifiedSince", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "HEAD" && rec.Code == http.StatusNotModified {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"HeadObject/IfMatch", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "HEAD" && rec.Code == http.StatusPreconditionFailed {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"ListBuckets", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && r.URL.Path == "/" {
+			replaceBody(rec, "<Bucket>", "<Unknown>")
+			replaceBody(rec, "</Bucket>", "</Unknown>")
+		}
+	}},
+	{"ListObjectsV1", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && !isObjectRequest(r) && hasQuery(r, "max-keys") {
+			replaceBody(rec, "<IsTruncated>true</IsTruncated>", "<IsTruncated>false</IsTruncated>")
+		}
+	}},
+	{"ListObjectsV2", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && hasQuery(r, "list-type") {
+			// Report every ETag without its quotes.
+			replaceBody(rec, "&#34;", "")
+		}
+	}},
+	{"PutObject/Streaming", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Header.Get("X-Amz-Content-Sha256") == "STREAMING-AWS4-HMAC-SHA256-PAYLOAD" {
+			replaceResponse(rec, http.StatusNotImplemented)
+		}
+	}},
+	{"PutObject/Presigned", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "PUT" && hasQuery(r, "X-Amz-Signature") {
+			replaceResponse(rec, http.StatusNoContent)
+		}
+	}},
+	{"PostObject", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "POST" && !isObjectRequest(r) && rec.Code == http.StatusNotFound {
+			replaceResponse(rec, http.StatusNoContent)
+		}
+	}},
+	{"Multipart/InitiateUpload", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "POST" && hasQuery(r, "uploads") {
+			rec.Code = http.StatusCreated
+		}
+	}},
+	{"Multipart/UploadPart", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "PUT" && hasQuery(r, "partNumber") {
+			rec.Body.WriteString("<Part/>")
+		}
+	}},
+	{"Multipart/ListParts", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && hasQuery(r, "uploadId") {
+			replaceBody(rec, "<PartNumber>1</PartNumber>", "<PartNumber>2</PartNumber>")
+		}
+	}},
+	{"Multipart/ListUploads", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && hasQuery(r, "uploads") {
+			replaceBody(rec, "<UploadId>", "<UploadID>")
+			replaceBody(rec, "</UploadId>", "</UploadID>")
+		}
+	}},
+	{"Multipart/CompleteUpload", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "POST" && hasQuery(r, "uploadId") {
+			replaceBody(rec, "<Bucket>", "<Location>")
+			replaceBody(rec, "</Bucket>", "</Location>")
+		}
+	}},
+	{"Multipart/AbortUpload", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "DELETE" && hasQuery(r, "uploadId") {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"CopyObject", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Header.Get("X-Amz-Copy-Source") != "" && rec.Code == http.StatusNotFound {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"CopyObject/IfMatch", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Header.Get("X-Amz-Copy-Source") != "" && rec.Code == http.StatusPreconditionFailed {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"CopyObject/IfModifiedSince", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Header.Get("X-Amz-Copy-Source") != "" && rec.Code == http.StatusPreconditionFailed {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"GetObject", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		// Ignore the response-* overrides.
+		if r.Method == "GET" && hasQuery(r, "response-content-language") {
+			rec.HeaderMap.Del("Content-Language")
+		}
+	}},
+	{"GetObject/Presigned/Tampered", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		// Serve presigned requests whatever their signature.
+		if hasQuery(r, "X-Amz-Signature") && strings.Contains(rec.Body.String(), "<Code>SignatureDoesNotMatch</Code>") {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"GetObject/Presigned/Expiry", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if hasQuery(r, "X-Amz-Expires") && strings.Contains(rec.Body.String(), "Request has expired") {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"GetObject/Presigned/ResponseHeaders", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		// Ignore the response-* overrides of presigned URLs only.
+		if r.Method == "GET" && hasQuery(r, "X-Amz-Signature") && hasQuery(r, "response-content-disposition") {
+			rec.HeaderMap.Del("Content-Disposition")
+		}
+	}},
+	{"GetObject/IfNoneMatch", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && rec.Code == http.StatusNotModified {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"GetObject/IfUnModifiedSince", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && rec.Code == http.StatusPreconditionFailed {
+			replaceResponse(rec, http.StatusOK)
+		}
+	}},
+	{"GetObject/Range", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "GET" && rec.Code == http.StatusPartialContent {
+			rec.Code = http.StatusOK
+			rec.HeaderMap.Del("Content-Range")
+		}
+	}},
+	{"RemoveBucket/NotEmpty", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "DELETE" && rec.Code == http.StatusConflict {
+			replaceResponse(rec, http.StatusNoContent)
+		}
+	}},
+	{"RemoveObject/DNE", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "DELETE" && isObjectRequest(r) {
+			replaceResponse(rec, http.StatusNotFound)
+		}
+	}},
+	{"RemoveBucket/DNE", func(r *http.Request, rec *httptest.ResponseRecorder) {
+		if r.Method == "DELETE" && !isObjectRequest(r) && rec.Code == http.StatusNotFound {
+			replaceResponse(rec, http.StatusNoContent)
+		}
+	}},
+}
+
+// TestSuiteAgainstBrokenServers - tests fail against servers that do not behave like S3.
+func TestSuiteAgainstBrokenServers(t *testing.T) {
+	for i, variant := range brokenVariants {
+		handler := brokenServer{
+			next:   s3mem.New(testAccessKey, testSecretKey, testRegion),
+			mutate: variant.mutate,
+		}
+		// Only run the broken test and the tests it depends on.
+		selection := testSelection{run: regexp.MustCompile("^" + regexp.QuoteMeta(variant.test) + "$")}
+		results := runSuite(t, handler, selection)
+		if result := results[variant.test]; result.Status != TestFail {
+			t.Errorf("Broken variant %d: %s: expected %s, got %s", i, variant.test, TestFail, result.Status)
+		}
+	}
+}
+
+// TestPreparedConditionalCopies - in a prepared environment the conditional copies compare against the
+// metadata HeadObject stored for the object uploaded by PutObject.
+func TestPreparedConditionalCopies(t *testing.T) {
+	setGlobals(false)
+	server := httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+	defer server.Close()
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, server.URL, testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := newQuietRunContext("prepared", 1)
+	// The environment --reuse would have prepared.
+	preparedBucket := BucketInfo{Name: "s3verify-prepared-environment"}
+	preparedObject := &ObjectInfo{Key: "s3verify/prepared/object", Body: []byte("s3verify prepared data")}
+	bucketReq, err := newPutBucketReq(config.Region, preparedBucket.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectReq, err := newPutObjectReq(preparedBucket.Name, preparedObject.Key, preparedObject.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, req := range []Request{bucketReq, objectReq} {
+		res, err := config.execRequest("PUT", req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		closeResponse(res)
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("Unable to prepare the environment: %s", res.Status)
+		}
+	}
+	run.preparedBuckets = []BucketInfo{preparedBucket}
+	run.preparedObjects = []*ObjectInfo{preparedObject}
+
+	selection := testSelection{extended: true, run: regexp.MustCompile("^CopyObject/(IfMatch|IfNoneMatch|IfUnModifiedSince)$")}
+	for _, result := range runTests(*config, run, preparedTests, selection) {
+		if result.Status != TestPass {
+			t.Errorf("%s: expected %s, got %s: %v", result.Name, TestPass, result.Status, result.Err)
+		}
+	}
+}
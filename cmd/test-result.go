@@ -0,0 +1,192 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TestStatus - the outcome of a single test.
+type TestStatus string
+
+// All possible test outcomes.
+const (
+	TestPass  TestStatus = "PASS"  // The server behaved as expected.
+	TestFail  TestStatus = "FAIL"  // The server did not behave as expected.
+	TestSkip  TestStatus = "SKIP"  // The test was not run.
+	TestXFail TestStatus = "XFAIL" // The test failed but the failure was expected.
+)
+
+// ServerResponse - identifies a response sent by the server so that failures can be traced in its logs.
+type ServerResponse struct {
+	StatusCode int    // HTTP status code, 0 if no response was received.
+	Code       string // S3 error code, empty for successful responses.
+	RequestID  string // RequestId of the error response or the x-amz-request-id header.
+	HostID     string // HostId of the error response or the x-amz-id-2 header.
+}
+
+// TestResult - the outcome of a test and how it was reached.
+type TestResult struct {
+	Name     string         // Name of the test, see APItest.
+	Message  string         // Progress message shown while the test was running.
+	Status   TestStatus     // Outcome of the test.
+	Err      error          // Why the test did not pass, nil for passed tests.
+	Duration time.Duration  // How long the test took to run.
+	Requests int            // Number of HTTP requests sent by the test, including retries.
+	Response ServerResponse // Last response received before the test finished.
+	Checks   []TestResult   // Results of the separate scenarios verified by the test, if any.
+
+	Divergences []Divergence // Differences with the reference server, if --reference-url was used.
+}
+
+// newTestResult - create the result of a test that failed with err or passed if err is nil.
+func newTestResult(message string, err error) TestResult {
+	if err != nil {
+		return TestResult{
+			Message: message,
+			Status:  TestFail,
+			Err:     err,
+		}
+	}
+	return TestResult{
+		Message: message,
+		Status:  TestPass,
+	}
+}
+
+// addCheck - record the outcome of one scenario verified by a test.
+// A failed scenario fails the whole test.
+func (r *TestResult) addCheck(config ServerConfig, name string, err error) {
+	check := newTestResult(r.Message, err)
+	check.Name = name
+	if stats := config.stats; stats != nil {
+		stats.mu.Lock()
+		defer stats.mu.Unlock()
+		// Only account for what happened since the previous scenario.
+		check.Duration = time.Since(stats.checkStart)
+		check.Requests = stats.requests - stats.checkRequests
+		check.Response = stats.lastResponse
+		stats.checkStart = time.Now()
+		stats.checkRequests = stats.requests
+		stats.lastResponse = ServerResponse{}
+	}
+	r.Checks = append(r.Checks, check)
+	if err != nil && r.Status != TestFail {
+		// Report the first scenario that failed as the reason for the test failing.
+		r.Status = TestFail
+		r.Err = fmt.Errorf("%s: %v", name, err)
+	}
+}
+
+// requestStats - counters kept by ServerConfig.execRequest for the test being run.
+// A test may send requests from several goroutines at once, see --parallel.
+type requestStats struct {
+	mu sync.Mutex
+
+	requests     int            // Number of HTTP requests sent, including retries.
+	lastResponse ServerResponse // Last response received.
+	divergences  []Divergence   // Differences with the reference server.
+
+	checkStart    time.Time // When the current scenario of the test started.
+	checkRequests int       // Number of requests sent before the current scenario started.
+}
+
+// countRequest - count a request about to be sent.
+func (s *requestStats) countRequest() {
+	s.mu.Lock()
+	s.requests++
+	s.mu.Unlock()
+}
+
+// addDivergences - remember differences found with the reference server.
+func (s *requestStats) addDivergences(divergences []Divergence) {
+	s.mu.Lock()
+	s.divergences = append(s.divergences, divergences...)
+	s.mu.Unlock()
+}
+
+// recordResponse - remember the last response received, errResponse is empty for successful responses.
+func (s *requestStats) recordResponse(resp *http.Response, errResponse ErrorResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastResponse = ServerResponse{
+		StatusCode: resp.StatusCode,
+		Code:       errResponse.Code,
+		RequestID:  errResponse.RequestID,
+		HostID:     errResponse.HostID,
+	}
+	// Not every server sends these back in the error response body.
+	if s.lastResponse.RequestID == "" {
+		s.lastResponse.RequestID = resp.Header.Get("x-amz-request-id")
+	}
+	if s.lastResponse.HostID == "" {
+		s.lastResponse.HostID = resp.Header.Get("x-amz-id-2")
+	}
+}
+
+// runTest - run a single test and record how long it took and how many requests it sent.
+func runTest(config ServerConfig, run *RunContext, test APItest, curTest int) TestResult {
+	start := time.Now()
+	stats := &requestStats{
+		checkStart: start,
+	}
+	config.stats = stats
+	result := test.Test(config, run, curTest)
+	result.Name = test.Name
+	result.Duration = time.Since(start)
+	result.Requests = stats.requests
+	result.Response = stats.lastResponse
+	result.Divergences = stats.divergences
+	if result.Status == TestFail && len(result.Checks) > 0 {
+		// Point at the response of the scenario that failed the test.
+		for _, check := range result.Checks {
+			if check.Status == TestFail {
+				result.Response = check.Response
+				break
+			}
+		}
+	}
+	return result
+}
+
+// printResult - print the outcome of a test to the console.
+func printResult(result TestResult) {
+	switch result.Status {
+	case TestSkip, TestXFail:
+		printStatusMessage(result.Message, result.Status, result.Err.Error())
+	case TestFail:
+		// Show every scenario that failed, not only the first one.
+		var reasons []string
+		for _, check := range result.Checks {
+			if check.Status == TestFail {
+				reasons = append(reasons, check.Name+": "+check.Err.Error())
+			}
+		}
+		if len(reasons) == 0 {
+			reasons = append(reasons, result.Err.Error())
+		}
+		printMessage(result.Message, errors.New(strings.Join(reasons, "\n")))
+	default:
+		printMessage(result.Message, nil)
+	}
+	printDivergences(result.Divergences)
+}
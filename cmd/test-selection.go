@@ -0,0 +1,125 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/minio/cli"
+)
+
+// testSelection - the subset of tests the user asked to run.
+type testSelection struct {
+	extended bool           // Run extended tests even if they were not explicitly matched by --run.
+	run      *regexp.Regexp // Only run tests whose name matches, nil matches every test.
+	skip     *regexp.Regexp // Never run tests whose name matches, nil matches no test.
+}
+
+// newTestSelection - create a new test selection from the --run, --skip and --extended flags.
+func newTestSelection(ctx *cli.Context) (testSelection, error) {
+	return newTestSelectionFor(ctx.GlobalString("run"), ctx.GlobalString("skip"), ctx.GlobalBool("extended"))
+}
+
+// newTestSelectionFor - create a new test selection from the patterns of --run and --skip, empty patterns are ignored.
+func newTestSelectionFor(runPattern, skipPattern string, extended bool) (testSelection, error) {
+	selection := testSelection{
+		extended: extended,
+	}
+	if runPattern != "" {
+		run, err := regexp.Compile(runPattern)
+		if err != nil {
+			return testSelection{}, fmt.Errorf("Invalid --run pattern %q: %v", runPattern, err)
+		}
+		selection.run = run
+	}
+	if skipPattern != "" {
+		skip, err := regexp.Compile(skipPattern)
+		if err != nil {
+			return testSelection{}, fmt.Errorf("Invalid --skip pattern %q: %v", skipPattern, err)
+		}
+		selection.skip = skip
+	}
+	return selection, nil
+}
+
+// matches - check whether a test was asked for by the user.
+func (s testSelection) matches(test APItest) bool {
+	if s.skip != nil && s.skip.MatchString(test.Name) {
+		return false
+	}
+	if s.run != nil {
+		// Tests explicitly asked for are run even if they are extended.
+		return s.run.MatchString(test.Name)
+	}
+	// Only run extended tests if explicitly asked for.
+	return !test.Extended || s.extended
+}
+
+// filter - return the selected tests in the order they must be run.
+// Tests that a selected test depends on are always kept, even if they
+// were not asked for or were excluded by --skip.
+func (s testSelection) filter(tests []APItest) []APItest {
+	selected := make([]bool, len(tests))
+	needed := make(map[string]bool)
+	// Dependencies always appear earlier in the list, so walking
+	// backwards visits a test only after everything depending on it.
+	for i := len(tests) - 1; i >= 0; i-- {
+		test := tests[i]
+		if !s.matches(test) && !needed[test.Name] {
+			continue
+		}
+		selected[i] = true
+		for _, dep := range test.Depends {
+			needed[dep] = true
+		}
+	}
+	filtered := []APItest{}
+	for i, test := range tests {
+		if selected[i] {
+			filtered = append(filtered, test)
+		}
+	}
+	return filtered
+}
+
+// checkDependencies - verify that every dependency names a test listed before the test depending on it.
+func checkDependencies(tests []APItest) error {
+	seen := make(map[string]bool)
+	for _, test := range tests {
+		for _, dep := range test.Depends {
+			if !seen[dep] {
+				return fmt.Errorf("Test %s depends on %s which is not run before it. %s", test.Name, dep, reportIssue)
+			}
+		}
+		if seen[test.Name] {
+			return fmt.Errorf("Test name %s is used more than once. %s", test.Name, reportIssue)
+		}
+		seen[test.Name] = true
+	}
+	return nil
+}
+
+// firstFailedDependency - return the name of the first dependency of test that did not pass, if any.
+func firstFailedDependency(test APItest, passed map[string]bool) string {
+	for _, dep := range test.Depends {
+		if !passed[dep] {
+			return dep
+		}
+	}
+	return ""
+}
@@ -16,18 +16,10 @@
 
 package cmd
 
-// Tests must be run in the following order:
-// PutBucket,
-// PutObject,
-// ListBuckets,
-// ListObjects,
-// Multipart,
-// HeadBucket,
-// HeadObject,
-// CopyObject,
-// GetObject,
-// RemoveObject,
-// RemoveBucket,
+// Tests are run in the order they are listed below. Tests that use buckets,
+// objects or uploads created by another test list that test in Depends, which
+// must appear earlier in the same list. If a dependency fails or is skipped
+// the dependent test is skipped as well.
 
 // Tests are sorted into the following lists:
 // preparedTests    -- tests that will use materials set up by the --prepare flag.
@@ -37,245 +29,418 @@ package cmd
 var preparedTests = []APItest{
 	// Tests for PutBucket API.
 	APItest{
+		Name:     "PutBucket",
 		Test:     mainPutBucket,
 		Extended: false, // PutBucket is not an extended API.
-		Critical: false, // Because -- has been used this bucket is not necessary for future tests.
 	},
 	APItest{
+		Name:     "PutBucket/InvalidNames",
 		Test:     mainPutBucketInvalid,
 		Extended: false, // PutBucket is not an extended API.
-		Critical: false, // This test is not used for future tests.
 	},
 
 	// Tests for PutBucketPolicy API.
 	APItest{
+		Name:     "PutBucketPolicy",
 		Test:     mainPutBucketPolicy,
-		Extended: false, // PutBucketPolicy is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // PutBucketPolicy is not an extended API.
+		Depends:  []string{"PutBucket"}, // Sets policies on the buckets made by PutBucket.
 	},
 
 	// Tests for GetBucketPolicy API.
 	APItest{
+		Name:     "GetBucketPolicy",
 		Test:     mainGetBucketPolicy,
-		Extended: false, // GetBucketPolicy is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                       // GetBucketPolicy is not an extended API.
+		Depends:  []string{"PutBucketPolicy"}, // Reads back the policies set by PutBucketPolicy.
+		Parallel: true,
 	},
 
-	// Tests for PutObject API.
+	// Tests for HeadBucket API.
 	APItest{
-		Test:     mainPutObjectPrepared,
-		Extended: false, // PutObject is not an extended API.
-		Critical: false, // Because -- has been used this object is not necessary for future tests.
+		Name:     "HeadBucket",
+		Test:     mainHeadBucket,
+		Extended: false,                 // HeadBucket is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uses the buckets made by PutBucket.
+		Parallel: true,
 	},
 
-	// Tests for HeadBucket API.
+	// Tests for PutObject API.
 	APItest{
-		Test:     mainHeadBucket,
-		Extended: false, // HeadBucket is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Name:     "PutObject",
+		Test:     mainPutObjectPrepared,
+		Extended: false,                 // PutObject is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
 	},
 
 	// Tests for HeadObject API if an environment was prepared.
 	APItest{
+		Name:     "HeadObject",
 		Test:     mainHeadObjectPrepared,
-		Extended: false, // HeadObject is not an extended API.
-		Critical: true,  // This test affects future tests and must pass.
+		Extended: false,                 // HeadObject is not an extended API.
+		Depends:  []string{"PutObject"}, // Stores the metadata of the object uploaded by PutObject as well.
 	},
 	APItest{
+		Name:     "HeadObject/IfModifiedSince",
 		Test:     mainHeadObjectIfModifiedSince,
-		Extended: true,  // HeadObject with if-modified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // HeadObject with if-modified-since header is an extended API.
+		Depends:  []string{"PutObject"}, // Uses the object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "HeadObject/IfUnModifiedSince",
 		Test:     mainHeadObjectIfUnModifiedSince,
-		Extended: true,  // HeadObject with if-unmodified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // HeadObject with if-unmodified-since header is an extended API.
+		Depends:  []string{"PutObject"}, // Uses the object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "HeadObject/IfMatch",
 		Test:     mainHeadObjectIfMatch,
-		Extended: true,  // HeadObject with if-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // HeadObject with if-match header is an extended API.
+		Depends:  []string{"PutObject"}, // Uses the object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "HeadObject/IfNoneMatch",
 		Test:     mainHeadObjectIfNoneMatch,
-		Extended: true,  // HeadObject with if-none-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // HeadObject with if-none-match header is an extended API.
+		Depends:  []string{"PutObject"}, // Uses the object uploaded by PutObject.
+		Parallel: true,
 	},
 
 	// Tests for ListBuckets API.
 	APItest{
+		Name:     "ListBuckets",
 		Test:     mainListBuckets,
-		Extended: false, // ListBuckets is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // ListBuckets is not an extended API.
+		Depends:  []string{"PutBucket"}, // Lists the buckets made by PutBucket.
+		Parallel: true,
+	},
+
+	// Tests for temporary credentials.
+	APItest{
+		Name:     "SessionToken/Missing",
+		Test:     mainSessionTokenMissing,
+		Extended: false,                 // Only run with temporary credentials.
+		Depends:  []string{"PutBucket"}, // Uploads to the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "SessionToken/Tampered",
+		Test:     mainSessionTokenTampered,
+		Extended: false,                 // Only run with temporary credentials.
+		Depends:  []string{"PutBucket"}, // Uploads to the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "SessionToken/Expired",
+		Test:     mainSessionTokenExpired,
+		Extended: false,                 // Only run with --expired-aws-profile.
+		Depends:  []string{"PutBucket"}, // Uploads to the buckets made by PutBucket.
+	},
+
+	// Tests for requests with a bad signature.
+	APItest{
+		Name:     "BadSignature/WrongSecret",
+		Test:     mainBadSignatureWrongSecret,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/UnknownAccessKey",
+		Test:     mainBadSignatureUnknownAccessKey,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/WrongRegion",
+		Test:     mainBadSignatureWrongRegion,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/WrongService",
+		Test:     mainBadSignatureWrongService,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/ModifiedHeader",
+		Test:     mainBadSignatureModifiedHeader,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/UnsignedHeader",
+		Test:     mainBadSignatureUnsignedHeader,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/MalformedAuthorization",
+		Test:     mainBadSignatureMalformedAuthorization,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/SkewedDate",
+		Test:     mainBadSignatureSkewedDate,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
 	},
 
 	// Tests for ListObjects API.
 	APItest{
+		Name:     "ListObjectsV1",
 		Test:     mainListObjectsV1Prepared,
 		Extended: false, // ListObjects is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "ListObjectsV2",
 		Test:     mainListObjectsV2Prepared,
 		Extended: false, // ListObjects is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Parallel: true,
 	},
 
 	// Tests for PutObject streaming API.
 	APItest{
+		Name:     "PutObject/Streaming",
 		Test:     mainPutObjectStream,
-		Extended: false, // PutObject streaming v4 is not an extended API.
-		Critical: false, // Because -- has been used this object is not necessary for future tests.
+		Extended: false,                 // PutObject streaming v4 is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "PutObject/Streaming/Corrupted",
+		Test:     mainPutObjectStreamCorrupted,
+		Extended: false,                 // Rejecting corrupted streams is not an extended API.
+		Depends:  []string{"PutBucket"}, // Sends its uploads to the buckets made by PutBucket.
 	},
 
 	APItest{
+		Name:     "PutObject/Presigned",
 		Test:     mainPresignedPutObject,
-		Extended: false, // PutObject presigned is not an extended API.
-		Critical: false, // This object is not needed for future tests.
+		Extended: false,                 // PutObject presigned is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "PutObject/TrailingChecksum",
+		Test:     mainPutObjectTrailingChecksum,
+		Extended: true,                  // Trailing checksums are an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "PutObject/WrongTrailingChecksum",
+		Test:     mainPutObjectWrongTrailingChecksum,
+		Extended: true,                  // Trailing checksums are an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
 	},
 
 	// Tests for PostObject API.
 	APItest{
+		Name:     "PostObject",
 		Test:     mainPostObject,
-		Extended: false, // PostObject is not an extended API.
-		Critical: true,  // This test does affect other tests.
+		Extended: false,                 // PostObject is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
 	},
 	// Tests for Multipart API.
 	APItest{
+		Name:     "Multipart/InitiateUpload",
 		Test:     mainInitiateMultipartUpload,
-		Extended: false, // Initiate Multipart test must be run even without extended flags being set.
-		Critical: true,  // Initiate Multipart test must pass before other tests can be run.
+		Extended: false,                 // Initiate Multipart test must be run even without extended flags being set.
+		Depends:  []string{"PutBucket"}, // Starts uploads in the buckets made by PutBucket.
 	},
 	APItest{
+		Name:     "Multipart/UploadPart",
 		Test:     mainUploadPart,
-		Extended: false, // Upload Part test must be run even without extended flag being set.
-		Critical: true,  // Upload Part test must pass before other tests can be run.
+		Extended: false,                                // Upload Part test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/InitiateUpload"}, // Uploads parts to the uploads started by InitiateUpload.
 	},
 	APItest{
+		Name:     "Multipart/ReuploadPart",
 		Test:     mainReuploadPart,
-		Extended: false, // Upload Part test must be run even without extended flag being set.
-		Critical: true,  // Upload Part test must pass before other tests can be run.
+		Extended: false,                            // Upload Part test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/UploadPart"}, // Replaces a part uploaded by UploadPart.
 	},
 	APItest{
+		Name:     "Multipart/ListParts",
 		Test:     mainListParts,
-		Extended: false, // List Part test must be run even without extended flag being set.
-		Critical: false, // List Part test can fail without affecting other tests.
+		Extended: false,                              // List Part test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/ReuploadPart"}, // Lists the parts as left by ReuploadPart.
 	},
 	APItest{
+		Name:     "Multipart/ListUploads",
 		Test:     mainListMultipartUploads,
-		Extended: false, // List Multipart Uploads test must be run without extended flag being set.
-		Critical: false, // List Multipart Uploads test can fail without affecting other tests.
+		Extended: false,                                // List Multipart Uploads test must be run without extended flag being set.
+		Depends:  []string{"Multipart/InitiateUpload"}, // Lists the uploads started by InitiateUpload.
 	},
 	APItest{
+		Name:     "Multipart/CompleteUpload",
 		Test:     mainCompleteMultipartUpload,
-		Extended: false, // Complete Multipart test must be run even without extended flag being set.
-		Critical: true,  // Complete Multipart test can fail without affecting other tests.
+		Extended: false,                              // Complete Multipart test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/ReuploadPart"}, // Completes the upload with the parts left by ReuploadPart.
 	},
 	APItest{
+		Name:     "Multipart/AbortUpload",
 		Test:     mainAbortMultipartUpload,
-		Extended: false, // Abort Multipart test must be run even without extended flag being set.
-		Critical: false, // Abort Multipart test can fail without affecting other tests.
+		Extended: false,                                // Abort Multipart test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/InitiateUpload"}, // Aborts an upload started by InitiateUpload.
+	},
+	APItest{
+		Name:     "Multipart/UploadPart/TrailingChecksum",
+		Test:     mainUploadPartTrailingChecksum,
+		Extended: true,                  // Trailing checksums are an extended API.
+		Depends:  []string{"PutBucket"}, // Starts its own upload in the buckets made by PutBucket.
 	},
 
 	// Tests for CopyObject API.
 	APItest{
+		Name:     "CopyObject",
 		Test:     mainCopyObject,
-		Extended: false, // CopyObject is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // CopyObject is not an extended API.
+		Depends:  []string{"PutObject"}, // Copies an object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "CopyObject/IfModifiedSince",
 		Test:     mainCopyObjectIfModifiedSince,
-		Extended: true,  // CopyObject with if-modified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // CopyObject with if-modified-since header is an extended API.
+		Depends:  []string{"PutObject"}, // Copies an object uploaded by PutObject, only needs its key.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "CopyObject/IfUnModifiedSince",
 		Test:     mainCopyObjectIfUnModifiedSince,
-		Extended: true,  // CopyObject with if-unmodified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // CopyObject with if-unmodified-since header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "CopyObject/IfMatch",
 		Test:     mainCopyObjectIfMatch,
-		Extended: true,  // CopyObject with if-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // CopyObject with if-match header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "CopyObject/IfNoneMatch",
 		Test:     mainCopyObjectIfNoneMatch,
-		Extended: true,  // CopyObject with if-none-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // CopyObject with if-none-match header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 
 	// Tests for GetObject API.
 	APItest{
+		Name:     "GetObject",
 		Test:     mainGetObject,
-		Extended: false, // GetObject is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // GetObject is not an extended API.
+		Depends:  []string{"PutObject"}, // Downloads an object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/Multipart",
 		Test:     mainGetObjectMultipart,
-		Extended: false, // GetObject is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                                // GetObject is not an extended API.
+		Depends:  []string{"Multipart/CompleteUpload"}, // Downloads the object made by CompleteUpload.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/Presigned",
 		Test:     mainGetObjectPresigned,
-		Extended: false, // GetObject Presigned is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // GetObject Presigned is not an extended API.
+		Depends:  []string{"PutObject"}, // Downloads an object uploaded by PutObject.
+		Parallel: true,
+	},
+	APItest{
+		Name:     "GetObject/Presigned/Tampered",
+		Test:     mainGetObjectPresignedTampered,
+		Extended: false,                 // Rejecting tampered presigned URLs is not an extended API.
+		Depends:  []string{"PutObject"}, // Presigns URLs for an object uploaded by PutObject.
+	},
+	APItest{
+		Name:     "GetObject/Presigned/Expiry",
+		Test:     mainGetObjectPresignedExpiry,
+		Extended: true,                  // Waits for a presigned URL to expire.
+		Depends:  []string{"PutObject"}, // Downloads an object uploaded by PutObject.
+		Parallel: true,
+	},
+	APItest{
+		Name:     "GetObject/Presigned/ResponseHeaders",
+		Test:     mainGetObjectPresignedResponseHeaders,
+		Extended: false,                 // GetObject Presigned is not an extended API.
+		Depends:  []string{"PutObject"}, // Downloads an object uploaded by PutObject.
+		Parallel: true,
 	},
 
 	APItest{
+		Name:     "GetObject/IfModifiedSince",
 		Test:     mainGetObjectIfModifiedSince,
-		Extended: true,  // GetObject with if-modified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // GetObject with if-modified-since header is an extended API.
+		Depends:  []string{"PutObject"}, // Uses the object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/IfUnModifiedSince",
 		Test:     mainGetObjectIfUnModifiedSince,
-		Extended: true,  // GetObject with if-unmodified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // GetObject with if-unmodified-since header is an extended API.
+		Depends:  []string{"PutObject"}, // Uses the object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/IfMatch",
 		Test:     mainGetObjectIfMatch,
-		Extended: true,  // GetObject with if-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // GetObject with if-match header is an extended API.
+		Depends:  []string{"PutObject"}, // Uses the object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/IfNoneMatch",
 		Test:     mainGetObjectIfNoneMatch,
-		Extended: true,  // GetObject with if-none-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // GetObject with if-none-match header is an extended API.
+		Depends:  []string{"PutObject"}, // Uses the object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/Range",
 		Test:     mainGetObjectRange,
-		Extended: true,  // GetObject with range header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // GetObject with range header is an extended API.
+		Depends:  []string{"PutObject"}, // Uses the object uploaded by PutObject.
+		Parallel: true,
 	},
 
 	// Test for RemoveBucket API. (needs to be before remove object)
 	APItest{
+		Name:     "RemoveBucket/NotEmpty",
 		Test:     mainRemoveBucketNotEmpty,
-		Extended: false, // RemoveBucket is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // RemoveBucket is not an extended API.
+		Depends:  []string{"PutObject"}, // Needs a bucket holding the objects uploaded by PutObject.
 	},
 
 	// Test for RemoveObject API.
 	APItest{
+		Name:     "RemoveObject",
 		Test:     mainRemoveObjectExists,
-		Extended: false, // RemoveObject is not an extended API.
-		Critical: true,  // This test does affect future tests.
+		Extended: false,                 // RemoveObject is not an extended API.
+		Depends:  []string{"PutBucket"}, // Removes every object left in the buckets made by PutBucket.
 	},
 	APItest{
+		Name:     "RemoveObject/DNE",
 		Test:     mainRemoveObjectDNE,
-		Extended: false, // RemoveObject is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // RemoveObject is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uses the buckets made by PutBucket.
 	},
 
 	// Tests for RemoveBucket API.
 	APItest{
+		Name:     "RemoveBucket",
 		Test:     mainRemoveBucketExists,
-		Extended: false, // RemoveBucket is not an extended API.
-		Critical: true,  // Removing this bucket is necessary for a good test.
+		Extended: false,                    // RemoveBucket is not an extended API.
+		Depends:  []string{"RemoveObject"}, // Buckets must be emptied by RemoveObject first.
 	},
 	APItest{
+		Name:     "RemoveBucket/DNE",
 		Test:     mainRemoveBucketDNE,
 		Extended: false, // RemoveBucket is not an extended API.
-		Critical: false, // This test does not affect future tests.
 	},
 }
 
@@ -283,245 +448,420 @@ var preparedTests = []APItest{
 var unpreparedTests = []APItest{
 	// Tests for PutBucket API.
 	APItest{
+		Name:     "PutBucket",
 		Test:     mainPutBucket,
 		Extended: false, // PutBucket is not an extended API.
-		Critical: true,  // This test does affect future tests.
 	},
 	APItest{
+		Name:     "PutBucket/InvalidNames",
 		Test:     mainPutBucketInvalid,
 		Extended: false, // PutBucket is not an extended API.
-		Critical: false, // This test does not affect future tests.
 	},
 
 	// Tests for PutBucketPolicy API.
 	APItest{
+		Name:     "PutBucketPolicy",
 		Test:     mainPutBucketPolicy,
-		Extended: false, // PutBucketPolicy is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // PutBucketPolicy is not an extended API.
+		Depends:  []string{"PutBucket"}, // Sets policies on the buckets made by PutBucket.
 	},
 
 	// Tests for GetBucketPolicy API.
 	APItest{
+		Name:     "GetBucketPolicy",
 		Test:     mainGetBucketPolicy,
-		Extended: false, // GetBucketPolicy is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                       // GetBucketPolicy is not an extended API.
+		Depends:  []string{"PutBucketPolicy"}, // Reads back the policies set by PutBucketPolicy.
+		Parallel: true,
 	},
 
-	// Tests for PutObject API.
+	// Tests for HeadBucket API.
 	APItest{
-		Test:     mainPutObjectUnPrepared,
-		Extended: false, // PutObject is not an extended API.
-		Critical: true,  // These objects are necessary for future tests.
+		Name:     "HeadBucket",
+		Test:     mainHeadBucket,
+		Extended: false,                 // HeadBucket is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uses the buckets made by PutBucket.
+		Parallel: true,
 	},
 
-	// Tests for HeadBucket API.
+	// Tests for PutObject API.
 	APItest{
-		Test:     mainHeadBucket,
-		Extended: false, // HeadBucket is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Name:     "PutObject",
+		Test:     mainPutObjectUnPrepared,
+		Extended: false,                 // PutObject is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
 	},
 
 	// Tests for HeadObject API.
 	APItest{
+		Name:     "HeadObject",
 		Test:     mainHeadObjectUnPrepared,
-		Extended: false, // HeadObject is not an extended API.
-		Critical: true,  // This test affects future tests and must pass.
+		Extended: false,                 // HeadObject is not an extended API.
+		Depends:  []string{"PutObject"}, // Stores the metadata of the objects uploaded by PutObject.
 	},
 	APItest{
+		Name:     "HeadObject/IfModifiedSince",
 		Test:     mainHeadObjectIfModifiedSince,
-		Extended: true,  // HeadObject with if-modified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // HeadObject with if-modified-since header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "HeadObject/IfUnModifiedSince",
 		Test:     mainHeadObjectIfUnModifiedSince,
-		Extended: true,  // HeadObject with if-unmodified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // HeadObject with if-unmodified-since header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "HeadObject/IfMatch",
 		Test:     mainHeadObjectIfMatch,
-		Extended: true,  // HeadObject with if-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // HeadObject with if-match header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "HeadObject/IfNoneMatch",
 		Test:     mainHeadObjectIfNoneMatch,
-		Extended: true,  // HeadObject with if-none-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // HeadObject with if-none-match header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 
 	// Tests for ListBuckets API.
 	APItest{
+		Name:     "ListBuckets",
 		Test:     mainListBuckets,
-		Extended: false, // ListBuckets is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // ListBuckets is not an extended API.
+		Depends:  []string{"PutBucket"}, // Lists the buckets made by PutBucket.
+		Parallel: true,
+	},
+
+	// Tests for temporary credentials.
+	APItest{
+		Name:     "SessionToken/Missing",
+		Test:     mainSessionTokenMissing,
+		Extended: false,                 // Only run with temporary credentials.
+		Depends:  []string{"PutBucket"}, // Uploads to the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "SessionToken/Tampered",
+		Test:     mainSessionTokenTampered,
+		Extended: false,                 // Only run with temporary credentials.
+		Depends:  []string{"PutBucket"}, // Uploads to the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "SessionToken/Expired",
+		Test:     mainSessionTokenExpired,
+		Extended: false,                 // Only run with --expired-aws-profile.
+		Depends:  []string{"PutBucket"}, // Uploads to the buckets made by PutBucket.
+	},
+
+	// Tests for requests with a bad signature.
+	APItest{
+		Name:     "BadSignature/WrongSecret",
+		Test:     mainBadSignatureWrongSecret,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/UnknownAccessKey",
+		Test:     mainBadSignatureUnknownAccessKey,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/WrongRegion",
+		Test:     mainBadSignatureWrongRegion,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/WrongService",
+		Test:     mainBadSignatureWrongService,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/ModifiedHeader",
+		Test:     mainBadSignatureModifiedHeader,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/UnsignedHeader",
+		Test:     mainBadSignatureUnsignedHeader,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/MalformedAuthorization",
+		Test:     mainBadSignatureMalformedAuthorization,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
+	},
+	APItest{
+		Name:     "BadSignature/SkewedDate",
+		Test:     mainBadSignatureSkewedDate,
+		Extended: false, // Rejecting bad signatures is not an extended API.
+		Parallel: true,  // Creates nothing.
 	},
 
 	// Tests for ListObjects API.
 	APItest{
+		Name:     "ListObjectsV1",
 		Test:     mainListObjectsV1UnPrepared,
-		Extended: false, // ListObjects is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // ListObjects is not an extended API.
+		Depends:  []string{"PutObject"}, // Lists the objects uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "ListObjectsV2",
 		Test:     mainListObjectsV2UnPrepared,
-		Extended: false, // ListObjects is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // ListObjects is not an extended API.
+		Depends:  []string{"PutObject"}, // Lists the objects uploaded by PutObject.
+		Parallel: true,
 	},
 
 	// Tests for PutObject Streaming API.
 	APItest{
+		Name:     "PutObject/Streaming",
 		Test:     mainPutObjectStream,
-		Extended: false, // PutObject Streaming V4 is not an extended API.
-		Critical: true,  // These objects are necessary for future tests.
+		Extended: false,                 // PutObject Streaming V4 is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "PutObject/Streaming/Corrupted",
+		Test:     mainPutObjectStreamCorrupted,
+		Extended: false,                 // Rejecting corrupted streams is not an extended API.
+		Depends:  []string{"PutBucket"}, // Sends its uploads to the buckets made by PutBucket.
 	},
 
 	APItest{
+		Name:     "PutObject/Presigned",
 		Test:     mainPresignedPutObject,
-		Extended: false, // PutObject presigned is not an extended API.
-		Critical: true,  // This object is necessary for future tests.
+		Extended: false,                 // PutObject presigned is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "PutObject/TrailingChecksum",
+		Test:     mainPutObjectTrailingChecksum,
+		Extended: true,                  // Trailing checksums are an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
+	},
+	APItest{
+		Name:     "PutObject/WrongTrailingChecksum",
+		Test:     mainPutObjectWrongTrailingChecksum,
+		Extended: true,                  // Trailing checksums are an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
 	},
 
 	// Tests for PostObject API.
 	APItest{
+		Name:     "PostObject",
 		Test:     mainPostObject,
-		Extended: false, // PostObject is not an extended API.
-		Critical: true,  // This test does affect other tests.
+		Extended: false,                 // PostObject is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uploads into the buckets made by PutBucket.
 	},
 
 	// Tests for Multipart API.
 	APItest{
+		Name:     "Multipart/InitiateUpload",
 		Test:     mainInitiateMultipartUpload,
-		Extended: false, // Initiate Multipart test must be run even without extended flags being set.
-		Critical: true,  // Initiate Multipart test must pass before other tests can be run.
+		Extended: false,                 // Initiate Multipart test must be run even without extended flags being set.
+		Depends:  []string{"PutBucket"}, // Starts uploads in the buckets made by PutBucket.
 	},
 	APItest{
+		Name:     "Multipart/UploadPart",
 		Test:     mainUploadPart,
-		Extended: false, // Upload Part test must be run even without extended flag being set.
-		Critical: true,  // Upload Part test must pass before other tests can be run.
+		Extended: false,                                // Upload Part test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/InitiateUpload"}, // Uploads parts to the uploads started by InitiateUpload.
 	},
 	APItest{
+		Name:     "Multipart/ReuploadPart",
 		Test:     mainReuploadPart,
-		Extended: false, // Upload Part test must be run even without extended flag being set.
-		Critical: true,  // Upload Part test must pass before other tests can be run.
+		Extended: false,                            // Upload Part test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/UploadPart"}, // Replaces a part uploaded by UploadPart.
 	},
 	APItest{
+		Name:     "Multipart/ListParts",
 		Test:     mainListParts,
-		Extended: false, // List Part test must be run even without extended flag being set.
-		Critical: false, // List Part test can fail without affecting other tests.
+		Extended: false,                              // List Part test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/ReuploadPart"}, // Lists the parts as left by ReuploadPart.
 	},
 	APItest{
+		Name:     "Multipart/ListUploads",
 		Test:     mainListMultipartUploads,
-		Extended: false, // List Multipart Uploads test must be run without extended flag being set.
-		Critical: false, // List Multipart Uploads test can fail without affecting other tests.
+		Extended: false,                                // List Multipart Uploads test must be run without extended flag being set.
+		Depends:  []string{"Multipart/InitiateUpload"}, // Lists the uploads started by InitiateUpload.
 	},
 	APItest{
+		Name:     "Multipart/CompleteUpload",
 		Test:     mainCompleteMultipartUpload,
-		Extended: false, // Complete Multipart test must be run even without extended flag being set.
-		Critical: true,  // Complete Multipart test can fail without affecting other tests.
+		Extended: false,                              // Complete Multipart test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/ReuploadPart"}, // Completes the upload with the parts left by ReuploadPart.
 	},
 	APItest{
+		Name:     "Multipart/AbortUpload",
 		Test:     mainAbortMultipartUpload,
-		Extended: false, // Abort Multipart test must be run even without extended flag being set.
-		Critical: false, // Abort Multipart test can fail without affecting other tests.
+		Extended: false,                                // Abort Multipart test must be run even without extended flag being set.
+		Depends:  []string{"Multipart/InitiateUpload"}, // Aborts an upload started by InitiateUpload.
+	},
+	APItest{
+		Name:     "Multipart/UploadPart/TrailingChecksum",
+		Test:     mainUploadPartTrailingChecksum,
+		Extended: true,                  // Trailing checksums are an extended API.
+		Depends:  []string{"PutBucket"}, // Starts its own upload in the buckets made by PutBucket.
 	},
 
 	// Tests for CopyObject API.
 	APItest{
+		Name:     "CopyObject",
 		Test:     mainCopyObject,
-		Extended: false, // CopyObject is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // CopyObject is not an extended API.
+		Depends:  []string{"PutObject"}, // Copies an object uploaded by PutObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "CopyObject/IfModifiedSince",
 		Test:     mainCopyObjectIfModifiedSince,
-		Extended: true,  // CopyObject with if-modified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                  // CopyObject with if-modified-since header is an extended API.
+		Depends:  []string{"PutObject"}, // Copies an object uploaded by PutObject, only needs its key.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "CopyObject/IfUnModifiedSince",
 		Test:     mainCopyObjectIfUnModifiedSince,
-		Extended: true,  // CopyObject with if-unmodified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // CopyObject with if-unmodified-since header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "CopyObject/IfMatch",
 		Test:     mainCopyObjectIfMatch,
-		Extended: true,  // CopyObject with if-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // CopyObject with if-match header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "CopyObject/IfNoneMatch",
 		Test:     mainCopyObjectIfNoneMatch,
-		Extended: true,  // CopyObject with if-none-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // CopyObject with if-none-match header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 
 	// Tests for GetObject API.
 	APItest{
+		Name:     "GetObject",
 		Test:     mainGetObject,
-		Extended: false, // GetObject is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // GetObject is not an extended API.
+		Depends:  []string{"PutObject"}, // Downloads an object uploaded by PutObject.
+		Parallel: true,
 	},
 	// Tests for GetObject API.
 	APItest{
+		Name:     "GetObject/Multipart",
 		Test:     mainGetObjectMultipart,
-		Extended: false, // GetObject is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                                // GetObject is not an extended API.
+		Depends:  []string{"Multipart/CompleteUpload"}, // Downloads the object made by CompleteUpload.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/Presigned",
 		Test:     mainGetObjectPresigned,
-		Extended: false, // GetObject Presigned is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // GetObject Presigned is not an extended API.
+		Depends:  []string{"PutObject"}, // Downloads an object uploaded by PutObject.
+		Parallel: true,
+	},
+	APItest{
+		Name:     "GetObject/Presigned/Tampered",
+		Test:     mainGetObjectPresignedTampered,
+		Extended: false,                 // Rejecting tampered presigned URLs is not an extended API.
+		Depends:  []string{"PutObject"}, // Presigns URLs for an object uploaded by PutObject.
 	},
 	APItest{
+		Name:     "GetObject/Presigned/Expiry",
+		Test:     mainGetObjectPresignedExpiry,
+		Extended: true,                  // Waits for a presigned URL to expire.
+		Depends:  []string{"PutObject"}, // Downloads an object uploaded by PutObject.
+		Parallel: true,
+	},
+	APItest{
+		Name:     "GetObject/Presigned/ResponseHeaders",
+		Test:     mainGetObjectPresignedResponseHeaders,
+		Extended: false,                 // GetObject Presigned is not an extended API.
+		Depends:  []string{"PutObject"}, // Downloads an object uploaded by PutObject.
+		Parallel: true,
+	},
+	APItest{
+		Name:     "GetObject/IfModifiedSince",
 		Test:     mainGetObjectIfModifiedSince,
-		Extended: true,  // GetObject with if-modified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // GetObject with if-modified-since header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/IfUnModifiedSince",
 		Test:     mainGetObjectIfUnModifiedSince,
-		Extended: true,  // GetObject with if-unmodified-since header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // GetObject with if-unmodified-since header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/IfMatch",
 		Test:     mainGetObjectIfMatch,
-		Extended: true,  // GetObject with if-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // GetObject with if-match header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/IfNoneMatch",
 		Test:     mainGetObjectIfNoneMatch,
-		Extended: true,  // GetObject with if-none-match header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // GetObject with if-none-match header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the metadata stored by HeadObject.
+		Parallel: true,
 	},
 	APItest{
+		Name:     "GetObject/Range",
 		Test:     mainGetObjectRange,
-		Extended: true,  // GetObject with range header is an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: true,                   // GetObject with range header is an extended API.
+		Depends:  []string{"HeadObject"}, // Needs the object sizes stored by HeadObject.
+		Parallel: true,
 	},
 
 	// Test for RemoveBucket API. (needs to be before remove object)
 	APItest{
+		Name:     "RemoveBucket/NotEmpty",
 		Test:     mainRemoveBucketNotEmpty,
-		Extended: false, // RemoveBucket is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // RemoveBucket is not an extended API.
+		Depends:  []string{"PutObject"}, // Needs a bucket holding the objects uploaded by PutObject.
 	},
 
 	// Test for RemoveObject API.
 	APItest{
+		Name:     "RemoveObject",
 		Test:     mainRemoveObjectExists,
-		Extended: false, // Remove Object test must be run.
-		Critical: true,  // Remove Object test must pass for future tests.
+		Extended: false,                 // Remove Object test must be run.
+		Depends:  []string{"PutBucket"}, // Removes every object left in the buckets made by PutBucket.
 	},
 	APItest{
+		Name:     "RemoveObject/DNE",
 		Test:     mainRemoveObjectDNE,
-		Extended: false, // RemoveObject is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                 // RemoveObject is not an extended API.
+		Depends:  []string{"PutBucket"}, // Uses the buckets made by PutBucket.
 	},
 
 	// Tests for RemoveBucket API.
 	APItest{
+		Name:     "RemoveBucket",
 		Test:     mainRemoveBucketExists,
-		Extended: false, // RemoveBucket is not an extended API.
-		Critical: false, // This test does not affect future tests.
+		Extended: false,                    // RemoveBucket is not an extended API.
+		Depends:  []string{"RemoveObject"}, // Buckets must be emptied by RemoveObject first.
 	},
 	APItest{
+		Name:     "RemoveBucket/DNE",
 		Test:     mainRemoveBucketDNE,
 		Extended: false, // RemoveBucket is not an extended API.
-		Critical: false, // This test does not affect future tests.
 	},
 }
@@ -0,0 +1,341 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Payload hashes of streaming uploads whose chunks are followed by trailing headers, see
+// http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming-trailers.html.
+const (
+	streamingUnsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	streamingSignedPayloadTrailer   = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+)
+
+// checksumHashes - the hashes of the additional checksums, by the name used in x-amz-checksum-algorithm.
+var checksumHashes = map[string]func() hash.Hash{
+	"CRC32":  func() hash.Hash { return crc32.NewIEEE() },
+	"CRC32C": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+}
+
+// checksumOf - the base64 encoded checksum of data, as sent in the x-amz-checksum-* headers.
+func checksumOf(algorithm string, data []byte) string {
+	hash := checksumHashes[algorithm]()
+	hash.Write(data)
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
+
+// checksumHeader - the header carrying the checksum of algorithm, in lower case as sent in trailers.
+func checksumHeader(algorithm string) string {
+	return "x-amz-checksum-" + strings.ToLower(algorithm)
+}
+
+// calculateChunkHeaderLength - calculates the length of the line in front of a chunk of an aws-chunked body.
+func calculateChunkHeaderLength(chunkDataSize int64, signed bool) int64 {
+	length := int64(len(fmt.Sprintf("%x", chunkDataSize))) + 2 // CRLF
+	if signed {
+		length += 17 + // ";chunk-signature="
+			64 // e.g. "f2ca1bb6c7e907d06dafe4687e579fce76b37e4e93b7605022da52e6ccc26fd2"
+	}
+	return length
+}
+
+// calculateTrailerStreamContentLength - calculates the length of an aws-chunked body followed by trailer.
+func calculateTrailerStreamContentLength(dataLen, chunkSize int64, signed bool, trailer http.Header) int64 {
+	streamLen := int64(0)
+	for remaining := dataLen; remaining > 0; remaining -= chunkSize {
+		chunkDataSize := chunkSize
+		if remaining < chunkSize {
+			chunkDataSize = remaining
+		}
+		streamLen += calculateChunkHeaderLength(chunkDataSize, signed) + chunkDataSize + 2 // CRLF
+	}
+	// The final chunk is directly followed by the trailing headers.
+	streamLen += calculateChunkHeaderLength(0, signed)
+	for name, values := range trailer {
+		streamLen += int64(len(name)+1+len(strings.Join(values, ","))) + 2 // name:value CRLF
+	}
+	if signed {
+		streamLen += 24 + // "x-amz-trailer-signature:"
+			64 + // e.g. "f2ca1bb6c7e907d06dafe4687e579fce76b37e4e93b7605022da52e6ccc26fd2"
+			2 // CRLF
+	}
+	return streamLen + 2 // CRLF
+}
+
+// newTrailingChecksumReq - Create a new HTTP streaming request uploading data followed by
+// the checksum of algorithm in a trailing header, in signed or unsigned chunks.
+func newTrailingChecksumReq(bucketName, objectName string, queryValues url.Values, data []byte, signed bool, algorithm, checksum string) Request {
+	trailer := http.Header{}
+	trailer.Set(checksumHeader(algorithm), checksum)
+	var trailingChecksumReq = Request{
+		customHeader:   http.Header{},
+		streamingSign:  true,
+		chunkSize:      64 * 1024,
+		trailer:        trailer,
+		unsignedChunks: !signed,
+	}
+
+	// Set the bucketName, objectName and query values.
+	trailingChecksumReq.bucketName = bucketName
+	trailingChecksumReq.objectName = objectName
+	trailingChecksumReq.queryValues = queryValues
+
+	dataLen := int64(len(data))
+	contentLength := calculateTrailerStreamContentLength(dataLen, trailingChecksumReq.chunkSize, signed, trailer)
+
+	hashedPayload := streamingUnsignedPayloadTrailer
+	if signed {
+		hashedPayload = streamingSignedPayloadTrailer
+	}
+	trailingChecksumReq.customHeader.Set("User-Agent", appUserAgent)
+	trailingChecksumReq.customHeader.Set("X-Amz-Content-Sha256", hashedPayload)
+	trailingChecksumReq.customHeader.Set("Content-Encoding", "aws-chunked")
+	trailingChecksumReq.customHeader.Set("X-Amz-Decoded-Content-Length", strconv.FormatInt(dataLen, 10))
+	trailingChecksumReq.customHeader.Set("X-Amz-Trailer", checksumHeader(algorithm))
+	trailingChecksumReq.customHeader.Set("Content-Length", strconv.FormatInt(contentLength, 10))
+
+	// Set the body to the data held in data.
+	trailingChecksumReq.contentBody = bytes.NewReader(data)
+	trailingChecksumReq.contentLength = contentLength
+
+	return trailingChecksumReq
+}
+
+// trailingChecksumCheck - an upload with a trailing checksum, sent in signed or unsigned chunks.
+type trailingChecksumCheck struct {
+	name      string
+	signed    bool
+	algorithm string
+}
+
+// Uploads sent with each algorithm at least once, in signed as well as in unsigned chunks.
+var trailingChecksumChecks = []trailingChecksumCheck{
+	{"Unsigned/CRC32", false, "CRC32"},
+	{"Unsigned/SHA256", false, "SHA256"},
+	{"Signed/CRC32C", true, "CRC32C"},
+	{"Signed/SHA1", true, "SHA1"},
+}
+
+// Uploads whose trailing checksum does not match their data.
+var wrongTrailingChecksumChecks = []trailingChecksumCheck{
+	{"Unsigned", false, "CRC32"},
+	{"Signed", true, "SHA256"},
+}
+
+// verifyChecksumHeader - verify that the checksum of algorithm was returned in header.
+func verifyChecksumHeader(header http.Header, algorithm, checksum string) error {
+	if received := header.Get(checksumHeader(algorithm)); received != checksum {
+		return fmt.Errorf("Unexpected %s Header Received: wanted %s, got %s", checksumHeader(algorithm), checksum, received)
+	}
+	return nil
+}
+
+// putObjectTrailingChecksum - upload object with the checksum of algorithm sent in a trailing header,
+// the checksum sent is the one of the data if checksum is empty.
+func putObjectTrailingChecksum(config ServerConfig, bucketName string, object *ObjectInfo, signed bool, algorithm, checksum string) (*http.Response, error) {
+	if checksum == "" {
+		checksum = checksumOf(algorithm, object.Body)
+	}
+	req := newTrailingChecksumReq(bucketName, object.Key, nil, object.Body, signed, algorithm, checksum)
+	return config.execRequest("PUT", req)
+}
+
+// headObjectChecksum - send a HEAD request for an object asking for its additional checksum.
+func headObjectChecksum(config ServerConfig, bucketName, objectName string) (*http.Response, error) {
+	req, err := newHeadObjectReq(bucketName, objectName)
+	if err != nil {
+		return nil, err
+	}
+	req.customHeader.Set("X-Amz-Checksum-Mode", "ENABLED")
+	return config.execRequest("HEAD", req)
+}
+
+// trailingChecksumVerify - verify that object was stored along with the checksum it was uploaded with.
+// A stored object is added to the run, so that RemoveObject removes it even if its checksum is wrong.
+func trailingChecksumVerify(config ServerConfig, run *RunContext, bucketName string, object *ObjectInfo, check trailingChecksumCheck) error {
+	checksum := checksumOf(check.algorithm, object.Body)
+	res, err := putObjectTrailingChecksum(config, bucketName, object, check.signed, check.algorithm, "")
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+	if err := putObjectVerify(res, http.StatusOK); err != nil {
+		return err
+	}
+	run.addObjects(object)
+	if err := verifyChecksumHeader(res.Header, check.algorithm, checksum); err != nil {
+		return err
+	}
+	headRes, err := headObjectChecksum(config, bucketName, object.Key)
+	if err != nil {
+		return err
+	}
+	defer closeResponse(headRes)
+	if err := verifyStatusHeadObject(headRes.StatusCode, http.StatusOK); err != nil {
+		return err
+	}
+	return verifyChecksumHeader(headRes.Header, check.algorithm, checksum)
+}
+
+// wrongTrailingChecksumVerify - verify that object is rejected, and not stored, if its trailing checksum does not match.
+func wrongTrailingChecksumVerify(config ServerConfig, run *RunContext, bucketName string, object *ObjectInfo, check trailingChecksumCheck) error {
+	res, err := putObjectTrailingChecksum(config, bucketName, object, check.signed, check.algorithm, checksumOf(check.algorithm, []byte("s3verify")))
+	if err != nil {
+		return err
+	}
+	defer closeResponse(res)
+	rejectedErr := verifyErrorCode(res, http.StatusBadRequest, "BadDigest")
+	// Nothing of the rejected data may have been stored.
+	if err := verifyObjectNotStored(config, run, bucketName, object); err != nil {
+		return err
+	}
+	return rejectedErr
+}
+
+// Test PUT object requests whose checksum is sent in a trailing header after signed and unsigned chunks.
+func mainPutObjectTrailingChecksum(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PutObject (Trailing Checksum):", curTest, run.totalNumTest)
+	result := newTestResult(message, nil)
+	bucket := run.buckets[0]
+	for _, check := range trailingChecksumChecks {
+		// Spin scanBar
+		run.scanBar(message)
+		object := &ObjectInfo{
+			Key:  "s3verify/put/object/trailing/" + strings.ToLower(check.name),
+			Body: []byte(randString(60, run.newRandSource(), "")),
+		}
+		result.addCheck(config, check.name, trailingChecksumVerify(config, run, bucket.Name, object, check))
+	}
+	return result
+}
+
+// Test that PUT object requests whose trailing checksum does not match their data are rejected.
+func mainPutObjectWrongTrailingChecksum(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] PutObject (Wrong Trailing Checksum):", curTest, run.totalNumTest)
+	result := newTestResult(message, nil)
+	bucket := run.buckets[0]
+	for _, check := range wrongTrailingChecksumChecks {
+		// Spin scanBar
+		run.scanBar(message)
+		object := &ObjectInfo{
+			Key:  "s3verify/put/object/trailing/wrong/" + strings.ToLower(check.name),
+			Body: []byte(randString(60, run.newRandSource(), "")),
+		}
+		result.addCheck(config, check.name, wrongTrailingChecksumVerify(config, run, bucket.Name, object, check))
+	}
+	return result
+}
+
+// uploadPartTrailingChecksum - upload a part with the checksum of algorithm sent in a trailing header,
+// the checksum sent is the one of partData if checksum is empty.
+func uploadPartTrailingChecksum(config ServerConfig, bucketName, objectName, uploadID string, partNumber int, partData []byte, signed bool, algorithm, checksum string) (*http.Response, error) {
+	if checksum == "" {
+		checksum = checksumOf(algorithm, partData)
+	}
+	urlValues := make(url.Values)
+	urlValues.Set("partNumber", strconv.Itoa(partNumber))
+	urlValues.Set("uploadId", uploadID)
+	req := newTrailingChecksumReq(bucketName, objectName, urlValues, partData, signed, algorithm, checksum)
+	return config.execRequest("PUT", req)
+}
+
+// Test UploadPart requests whose checksum is sent in a trailing header, to an upload
+// initiated with the checksum algorithm of its parts.
+func mainUploadPartTrailingChecksum(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] Multipart (Upload Part Trailing Checksum):", curTest, run.totalNumTest)
+	bucket := run.buckets[0]
+	objectName := "s3verify/multipart/trailing"
+	algorithm := "CRC32"
+	// Spin scanBar
+	run.scanBar(message)
+	// Initiate an upload of parts with CRC32 checksums.
+	initiateReq, err := newInitiateMultipartUploadReq(bucket.Name, objectName)
+	if err != nil {
+		return newTestResult(message, err)
+	}
+	initiateReq.customHeader.Set("X-Amz-Checksum-Algorithm", algorithm)
+	res, err := config.execRequest("POST", initiateReq)
+	if err != nil {
+		return newTestResult(message, err)
+	}
+	defer closeResponse(res)
+	if err := verifyStatusInitiateMultipartUpload(res.StatusCode, http.StatusOK); err != nil {
+		return newTestResult(message, err)
+	}
+	uploadID, err := verifyBodyInitiateMultipartUpload(res.Body)
+	if err != nil {
+		return newTestResult(message, err)
+	}
+	if received := res.Header.Get("X-Amz-Checksum-Algorithm"); received != algorithm {
+		return newTestResult(message, fmt.Errorf("Unexpected X-Amz-Checksum-Algorithm Header Received: wanted %s, got %s", algorithm, received))
+	}
+	result := newTestResult(message, nil)
+	for i, signed := range []bool{true, false} {
+		// Spin scanBar
+		run.scanBar(message)
+		name := "Unsigned"
+		if signed {
+			name = "Signed"
+		}
+		partData := []byte(randString(60, run.newRandSource(), ""))
+		partRes, err := uploadPartTrailingChecksum(config, bucket.Name, objectName, uploadID, i+1, partData, signed, algorithm, "")
+		if err == nil {
+			err = verifyStatusUploadPart(partRes.StatusCode, http.StatusOK)
+			if err == nil {
+				err = verifyChecksumHeader(partRes.Header, algorithm, checksumOf(algorithm, partData))
+			}
+			closeResponse(partRes)
+		}
+		result.addCheck(config, name, err)
+	}
+	// Spin scanBar
+	run.scanBar(message)
+	// A part whose checksum does not match its data is rejected.
+	partRes, err := uploadPartTrailingChecksum(config, bucket.Name, objectName, uploadID, 3, []byte(randString(60, run.newRandSource(), "")), true, algorithm, checksumOf(algorithm, []byte("s3verify")))
+	if err == nil {
+		err = verifyErrorCode(partRes, http.StatusBadRequest, "BadDigest")
+		closeResponse(partRes)
+	}
+	result.addCheck(config, "Wrong", err)
+	// Spin scanBar
+	run.scanBar(message)
+	// Abort the upload, it is not completed.
+	abortReq, err := newAbortMultipartUploadReq(bucket.Name, objectName, uploadID)
+	if err == nil {
+		var abortRes *http.Response
+		if abortRes, err = config.execRequest("DELETE", abortReq); err == nil {
+			err = abortMultipartUploadVerify(abortRes, http.StatusNoContent, ErrorResponse{})
+			closeResponse(abortRes)
+		}
+	}
+	result.addCheck(config, "Abort", err)
+	return result
+}
@@ -0,0 +1,122 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/minio/s3verify/s3mem"
+)
+
+// TestTrailingChecksumAgainstBrokenServers - the trailing checksum tests fail against servers
+// that do not verify or do not store the checksums.
+func TestTrailingChecksumAgainstBrokenServers(t *testing.T) {
+	handlers := map[string]brokenServer{
+		// The server accepts uploads whose checksum does not match.
+		"accepts": {
+			next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+			mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+				if strings.Contains(rec.Body.String(), "<Code>BadDigest</Code>") {
+					replaceResponse(rec, http.StatusOK)
+				}
+			},
+		},
+		// The server does not return the checksums.
+		"forgets": {
+			next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+			mutate: func(r *http.Request, rec *httptest.ResponseRecorder) {
+				for name := range rec.HeaderMap {
+					if strings.HasPrefix(strings.ToLower(name), "x-amz-checksum-") {
+						rec.HeaderMap.Del(name)
+					}
+				}
+			},
+		},
+	}
+	expectedFailures := map[string]map[string]bool{
+		"accepts": {"PutObject/WrongTrailingChecksum": true, "Multipart/UploadPart/TrailingChecksum": true},
+		"forgets": {"PutObject/TrailingChecksum": true, "Multipart/UploadPart/TrailingChecksum": true},
+	}
+	for name, handler := range handlers {
+		results := runSuite(t, handler, testSelection{run: regexp.MustCompile("TrailingChecksum$")})
+		for _, test := range unpreparedTests {
+			if !strings.HasSuffix(test.Name, "TrailingChecksum") {
+				continue
+			}
+			expected := TestPass
+			if expectedFailures[name][test.Name] {
+				expected = TestFail
+			}
+			if result := results[test.Name]; result.Status != expected {
+				t.Errorf("%s: %s: expected %s, got %s: %v", name, test.Name, expected, result.Status, result.Err)
+			}
+		}
+	}
+}
+
+// TestTrailingChecksumCleanup - objects stored by a broken server, with a wrong trailing checksum or
+// without checksum, are removed along with the other objects, so that the buckets of the run can still be removed.
+func TestTrailingChecksumCleanup(t *testing.T) {
+	handler := storingServer{
+		next: s3mem.New(testAccessKey, testSecretKey, testRegion),
+		accept: func(r *http.Request) bool {
+			return strings.Contains(r.URL.Path, "/put/object/trailing/")
+		},
+	}
+	results := runSuite(t, handler, testSelection{run: regexp.MustCompile("^(PutObject/(Wrong)?TrailingChecksum|RemoveBucket)$")})
+	expected := map[string]TestStatus{
+		"PutObject/TrailingChecksum":      TestFail,
+		"PutObject/WrongTrailingChecksum": TestFail,
+		"RemoveObject":                    TestPass,
+		"RemoveBucket":                    TestPass,
+	}
+	for name, status := range expected {
+		if result := results[name]; result.Status != status {
+			t.Errorf("%s: expected %s, got %s: %v", name, status, result.Status, result.Err)
+		}
+	}
+}
+
+// TestCalculateTrailerStreamContentLength - the Content-Length sent matches the length of the encoded body.
+func TestCalculateTrailerStreamContentLength(t *testing.T) {
+	config, err := newServerConfigFor(testAccessKey, testSecretKey, "http://localhost:9000", testRegion, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 1, 60, 64 * 1024, 64*1024 + 1, 3 * 64 * 1024} {
+		for _, signed := range []bool{true, false} {
+			data := []byte(strings.Repeat("a", size))
+			customReq := newTrailingChecksumReq("bucket", "object", nil, data, signed, "SHA256", checksumOf("SHA256", data))
+			req, err := config.newRequest("PUT", customReq)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int64(len(body)) != customReq.contentLength {
+				t.Errorf("Size %d, signed %v: expected a body of %d bytes, got %d", size, signed, customReq.contentLength, len(body))
+			}
+		}
+	}
+}
@@ -18,7 +18,6 @@ package cmd
 
 import (
 	"bytes"
-	crand "crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
@@ -31,19 +30,6 @@ import (
 	"strings"
 )
 
-// Store parts to be listed. Parts are grouped by object in sub-lists
-var objectParts = [2][]objectPart{}
-
-// Complete multipart upload.
-var complMultipartUploads = []*completeMultipartUpload{
-	&completeMultipartUpload{
-	// To be filled out by the test.
-	},
-	&completeMultipartUpload{
-	// To be filled out by the test.
-	},
-}
-
 // newUploadPartReq - Create a new HTTP request for an upload part request.
 func newUploadPartReq(bucketName, objectName, uploadID string, partNumber int, partData []byte) (Request, error) {
 	// Create a new request for uploading a part.
@@ -123,119 +109,111 @@ func verifyHeaderUploadPart(header http.Header) error {
 }
 
 // mainUploadPart - upload part test.
-func mainUploadPart(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] Multipart (Upload-Part):", curTest, globalTotalNumTest)
+func mainUploadPart(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] Multipart (Upload-Part):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// All multipart objects created by s3verify will be stored in s3verify buckets.
-	bucketName := s3verifyBuckets[0].Name
+	bucketName := run.buckets[0].Name
 	// TODO: upload more than one part for at least one object.
-	for i, object := range multipartObjects { // Upload 1 5MB or smaller part per object.
+	for i, object := range run.multipartObjects { // Upload 1 5MB or smaller part per object.
 		// Spin scanBar
-		scanBar(message)
+		run.scanBar(message)
 		part := objectPart{}
 		// Create some random data at most 5MB to upload via multipart operations.
-		objectData := make([]byte, rand.Intn(1<<20)+4*1024*1024)
+		random := rand.New(run.newRandSource())
+		objectData := make([]byte, random.Intn(1<<20)+4*1024*1024)
 		part.PartNumber = 1
 		part.Data = objectData
 		part.Size = int64(len(objectData))
-		_, err := io.ReadFull(crand.Reader, objectData)
+		_, err := io.ReadFull(random, objectData)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Create a new multipart upload part request.
 		req, err := newUploadPartReq(bucketName, object.Key, object.UploadID, 1, objectData)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Execute the request.
 		res, err := config.execRequest("PUT", req)
 		if err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		defer closeResponse(res)
 		// Verify the response.
 		if err := uploadPartVerify(res, http.StatusOK); err != nil {
-			printMessage(message, err)
-			return false
+			return newTestResult(message, err)
 		}
 		// Update the ETag of the part.
 		part.ETag = strings.TrimPrefix(res.Header.Get("ETag"), "\"")
 		part.ETag = strings.TrimSuffix(part.ETag, "\"")
 		// Store the parts to be listed in the list-multipart-uploads test.
-		objectParts[i] = append(objectParts[i], part)
+		run.objectParts[i] = append(run.objectParts[i], part)
 		// Test cleared store the uploaded parts to be completed/aborted.
 		var complPart completePart
 		complPart.ETag = part.ETag
 		complPart.PartNumber = part.PartNumber
 		// Save the completed part into the complMultiPartUpload struct.
-		complMultipartUploads[i].Parts = append(complMultipartUploads[i].Parts, complPart)
+		run.complMultipartUploads[i].Parts = append(run.complMultipartUploads[i].Parts, complPart)
 	}
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
 
 // mainReuploadPart - reupload the first part of a multipart upload operation
 // initiated in previous tests
-func mainReuploadPart(config ServerConfig, curTest int) bool {
-	message := fmt.Sprintf("[%02d/%d] Multipart (Reupload-Part):", curTest, globalTotalNumTest)
+func mainReuploadPart(config ServerConfig, run *RunContext, curTest int) TestResult {
+	message := fmt.Sprintf("[%02d/%d] Multipart (Reupload-Part):", curTest, run.totalNumTest)
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// All multipart objects created by s3verify will be stored in s3verify buckets.
-	bucketName := s3verifyBuckets[0].Name
-	object := multipartObjects[0]
+	bucketName := run.buckets[0].Name
+	object := run.multipartObjects[0]
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	part := objectPart{}
 	// Create some random data at most 5MB to upload via multipart operations.
-	objectData := make([]byte, rand.Intn(1<<20)+4*1024*1024)
+	random := rand.New(run.newRandSource())
+	objectData := make([]byte, random.Intn(1<<20)+4*1024*1024)
 	part.PartNumber = 1
 	part.Size = int64(len(objectData))
 	part.Data = objectData
-	_, err := io.ReadFull(crand.Reader, objectData)
+	_, err := io.ReadFull(random, objectData)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Create a new multipart upload part request.
 	req, err := newUploadPartReq(bucketName, object.Key, object.UploadID, 1, objectData)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	// Execute the request.
 	res, err := config.execRequest("PUT", req)
 	if err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 	defer closeResponse(res)
 	// Verify the response.
 	if err := uploadPartVerify(res, http.StatusOK); err != nil {
-		printMessage(message, err)
-		return false
+		return newTestResult(message, err)
 	}
 
-	// At this point, we need to update data in global objectParts
-	// and complMultipartUploads to make further tests work as expected
+	// At this point, we need to update data in run.objectParts
+	// and run.complMultipartUploads to make further tests work as expected
 	var complPart completePart
 	part.ETag = strings.TrimPrefix(res.Header.Get("ETag"), "\"")
 	part.ETag = strings.TrimSuffix(part.ETag, "\"")
 	complPart.ETag = part.ETag
 	complPart.PartNumber = part.PartNumber
 
-	complMultipartUploads[0].Parts[0] = complPart
-	objectParts[0][0] = part
+	run.complMultipartUploads[0].Parts[0] = complPart
+	run.objectParts[0][0] = part
 
 	// Spin scanBar
-	scanBar(message)
+	run.scanBar(message)
 	// Test passed.
-	printMessage(message, nil)
-	return true
+	return newTestResult(message, nil)
 }
@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"crypto/md5"
+	"crypto/tls"
 	"crypto/sha256"
 	"encoding/json"
 	"encoding/xml"
@@ -68,6 +69,7 @@ var validResponseHeaders = map[string]struct{}{
 	"connection":          struct{}{},
 	"content-disposition": struct{}{},
 	"content-language":    struct{}{},
+	"content-range":       struct{}{},
 	"date":                struct{}{},
 	"etag":                struct{}{},
 	"expires":             struct{}{},
@@ -80,6 +82,12 @@ var validResponseHeaders = map[string]struct{}{
 	"x-amz-request-id":    struct{}{},
 	"x-amz-version-id":    struct{}{},
 	"x-amz-bucket-region": struct{}{},
+	// Additional checksums, see http://docs.aws.amazon.com/AmazonS3/latest/userguide/checking-object-integrity.html
+	"x-amz-checksum-algorithm": struct{}{},
+	"x-amz-checksum-crc32":     struct{}{},
+	"x-amz-checksum-crc32c":    struct{}{},
+	"x-amz-checksum-sha1":      struct{}{},
+	"x-amz-checksum-sha256":    struct{}{},
 }
 
 // printMessage - Print test pass/fail messages with errors.
@@ -95,24 +103,39 @@ func printMessage(message string, err error) {
 	}
 }
 
+// printStatusMessage - Print the message of a test that was skipped or failed as expected along with the reason why.
+func printStatusMessage(message string, status TestStatus, reason string) {
+	// Erase the old progress line.
+	console.Eraseline()
+	message += strings.Repeat(" ", messageWidth-len([]rune(message))) + "[" + string(status) + "]\n" + reason
+	console.Println(message)
+}
+
 // verifyHostReachable - Execute a simple get request against the provided endpoint to make sure its reachable.
-func verifyHostReachable(endpoint, region string) error {
-	targetURL, err := makeTargetURL(endpoint, "", "", region, nil)
+// Returns the Server header sent back which usually identifies the server software and its version.
+// A nil tlsConfig uses the default TLS settings.
+func verifyHostReachable(endpoint, region string, tlsConfig *tls.Config) (string, error) {
+	targetURL, err := makeTargetURL(endpoint, "", "", region, "", nil)
 	if err != nil {
-		return err
+		return "", err
 	}
 	client := &http.Client{
 		// Only give server 3 seconds to complete the request.
 		Timeout: 3000 * time.Millisecond,
 	}
+	if tlsConfig != nil {
+		client.Transport = &http.Transport{TLSClientConfig: tlsConfig}
+	}
 	req := &http.Request{
 		Method: "GET",
 		URL:    targetURL,
 	}
-	if _, err := client.Do(req); err != nil {
-		return err
+	res, err := client.Do(req)
+	if err != nil {
+		return "", err
 	}
-	return nil
+	defer closeResponse(res)
+	return res.Header.Get("Server"), nil
 }
 
 // xmlDecoder provide decoded value in xml.
@@ -176,18 +199,22 @@ func isAmazonEndpoint(endpointURL *url.URL) bool {
 	return false
 }
 
-// Generate a new URL from the user provided endpoint.
-func makeTargetURL(endpoint, bucketName, objectName, region string, queryValues url.Values) (*url.URL, error) {
+// Generate a new URL from the user provided endpoint, with the bucket addressed in the lookup style.
+func makeTargetURL(endpoint, bucketName, objectName, region, lookup string, queryValues url.Values) (*url.URL, error) {
 	targetURL, err := url.Parse(endpoint)
 	if err != nil {
 		return nil, err
 	}
+	virtualHost := isVirtualHostStyle(lookup, targetURL, bucketName)
 	if isAmazonEndpoint(targetURL) { // Change host to reflect the region.
 		targetURL.Host = getS3Endpoint(region)
 	}
 	targetURL.Path = "/"
-	if bucketName != "" {
-		targetURL.Path = "/" + bucketName + "/" + objectName // Use path style requests only.
+	if virtualHost {
+		targetURL.Host = bucketName + "." + targetURL.Host
+		targetURL.Path = "/" + objectName
+	} else if bucketName != "" {
+		targetURL.Path = "/" + bucketName + "/" + objectName
 	}
 	if len(queryValues) > 0 { // If there are query values include them.
 		targetURL.RawQuery = queryValues.Encode()
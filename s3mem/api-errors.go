@@ -0,0 +1,347 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+)
+
+// apiErrorCode - one of the errors returned by the server.
+type apiErrorCode int
+
+// Errors returned by the server, see
+// http://docs.aws.amazon.com/AmazonS3/latest/API/ErrorResponses.html.
+const (
+	errNone apiErrorCode = iota
+	errAccessDenied
+	errAuthorizationHeaderMalformed
+	errAuthorizationQueryParametersError
+	errBadChecksum
+	errBadDigest
+	errBucketAlreadyOwnedByYou
+	errBucketNotEmpty
+	errChecksumTypeMismatch
+	errEntityTooSmall
+	errExpiredPresignRequest
+	errExpiredToken
+	errIncompleteBody
+	errInvalidAccessKeyID
+	errInvalidArgument
+	errInvalidBucketName
+	errInvalidChecksum
+	errInvalidDigest
+	errInvalidPart
+	errInvalidPartOrder
+	errInvalidRange
+	errInvalidToken
+	errMalformedPOSTRequest
+	errMalformedPolicy
+	errMalformedTrailer
+	errMalformedXML
+	errMethodNotAllowed
+	errMissingContentLength
+	errMissingContentSHA256
+	errMissingFields
+	errMissingDateHeader
+	errNoSuchBucket
+	errNoSuchBucketPolicy
+	errNoSuchKey
+	errNoSuchUpload
+	errNotImplemented
+	errNotModified
+	errPolicyConditionFailed
+	errPolicyExpired
+	errPreconditionFailed
+	errPresignExpiresTooLarge
+	errRequestTimeTooSkewed
+	errSignatureDoesNotMatch
+	errUnsignedHeaders
+	errContentSHA256Mismatch
+)
+
+// apiError - the code, message and status of an error response.
+type apiError struct {
+	Code           string
+	Description    string
+	HTTPStatusCode int
+}
+
+// apiErrors - the error response sent for every apiErrorCode.
+var apiErrors = map[apiErrorCode]apiError{
+	errAccessDenied: {
+		Code:           "AccessDenied",
+		Description:    "Access Denied",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	errAuthorizationHeaderMalformed: {
+		Code:           "AuthorizationHeaderMalformed",
+		Description:    "The authorization header is malformed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errAuthorizationQueryParametersError: {
+		Code:           "AuthorizationQueryParametersError",
+		Description:    "Query-string authentication version 4 requires the X-Amz-Algorithm, X-Amz-Credential, X-Amz-Signature, X-Amz-Date, X-Amz-SignedHeaders, and X-Amz-Expires parameters.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errBadChecksum: {
+		Code:           "BadDigest",
+		Description:    "The checksum you specified did not match the calculated checksum.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errBadDigest: {
+		Code:           "BadDigest",
+		Description:    "The Content-MD5 you specified did not match what we received.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errBucketAlreadyOwnedByYou: {
+		Code:           "BucketAlreadyOwnedByYou",
+		Description:    "Your previous request to create the named bucket succeeded and you already own it.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	errBucketNotEmpty: {
+		Code:           "BucketNotEmpty",
+		Description:    "The bucket you tried to delete is not empty",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	errChecksumTypeMismatch: {
+		Code:           "InvalidRequest",
+		Description:    "Checksum Type mismatch occurred, the checksum type of the part does not match the checksum algorithm of the upload.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errEntityTooSmall: {
+		Code:           "EntityTooSmall",
+		Description:    "Your proposed upload is smaller than the minimum allowed object size.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errExpiredPresignRequest: {
+		Code:           "AccessDenied",
+		Description:    "Request has expired",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	errExpiredToken: {
+		Code:           "ExpiredToken",
+		Description:    "The provided token has expired.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errIncompleteBody: {
+		Code:           "IncompleteBody",
+		Description:    "You did not provide the number of bytes specified by the Content-Length HTTP header",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errInvalidAccessKeyID: {
+		Code:           "InvalidAccessKeyId",
+		Description:    "The AWS Access Key Id you provided does not exist in our records.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	errInvalidArgument: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid Argument",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errInvalidBucketName: {
+		Code:           "InvalidBucketName",
+		Description:    "The specified bucket is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errInvalidChecksum: {
+		Code:           "InvalidRequest",
+		Description:    "Value for x-amz-checksum header is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errInvalidDigest: {
+		Code:           "InvalidDigest",
+		Description:    "The Content-MD5 you specified is not valid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errInvalidPart: {
+		Code:           "InvalidPart",
+		Description:    "One or more of the specified parts could not be found. The part may not have been uploaded, or the specified entity tag may not match the part's entity tag.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errInvalidPartOrder: {
+		Code:           "InvalidPartOrder",
+		Description:    "The list of parts was not in ascending order. Parts must be ordered by part number.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errInvalidRange: {
+		Code:           "InvalidRange",
+		Description:    "The requested range is not satisfiable",
+		HTTPStatusCode: http.StatusRequestedRangeNotSatisfiable,
+	},
+	errInvalidToken: {
+		Code:           "InvalidToken",
+		Description:    "The provided token is malformed or otherwise invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errMalformedPOSTRequest: {
+		Code:           "MalformedPOSTRequest",
+		Description:    "The body of your POST request is not well-formed multipart/form-data.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errMalformedPolicy: {
+		Code:           "MalformedPolicy",
+		Description:    "Policies must be valid JSON and the first byte must be '{'",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errMalformedTrailer: {
+		Code:           "MalformedTrailerError",
+		Description:    "The request contained trailing data that was not well-formed or did not conform to our published schema.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errMalformedXML: {
+		Code:           "MalformedXML",
+		Description:    "The XML you provided was not well-formed or did not validate against our published schema.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errMethodNotAllowed: {
+		Code:           "MethodNotAllowed",
+		Description:    "The specified method is not allowed against this resource.",
+		HTTPStatusCode: http.StatusMethodNotAllowed,
+	},
+	errMissingContentLength: {
+		Code:           "MissingContentLength",
+		Description:    "You must provide the Content-Length HTTP header.",
+		HTTPStatusCode: http.StatusLengthRequired,
+	},
+	errMissingContentSHA256: {
+		Code:           "InvalidRequest",
+		Description:    "Missing required header for this request: x-amz-content-sha256",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errMissingFields: {
+		Code:           "InvalidArgument",
+		Description:    "Bucket POST must contain a field named 'key'.  If it is specified, please check the order of the fields.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errMissingDateHeader: {
+		Code:           "AccessDenied",
+		Description:    "AWS authentication requires a valid Date or x-amz-date header",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	errNoSuchBucket: {
+		Code:           "NoSuchBucket",
+		Description:    "The specified bucket does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	errNoSuchBucketPolicy: {
+		Code:           "NoSuchBucketPolicy",
+		Description:    "The bucket policy does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	errNoSuchKey: {
+		Code:           "NoSuchKey",
+		Description:    "The specified key does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	errNoSuchUpload: {
+		Code:           "NoSuchUpload",
+		Description:    "The specified upload does not exist. The upload ID may be invalid, or the upload may have been aborted or completed.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	errNotImplemented: {
+		Code:           "NotImplemented",
+		Description:    "A header you provided implies functionality that is not implemented.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	errNotModified: {
+		Code:           "NotModified",
+		Description:    "Not Modified",
+		HTTPStatusCode: http.StatusNotModified,
+	},
+	errPolicyConditionFailed: {
+		Code:           "AccessDenied",
+		Description:    "Invalid according to Policy: Policy Condition failed",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	errPolicyExpired: {
+		Code:           "AccessDenied",
+		Description:    "Invalid according to Policy: Policy expired.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	errPreconditionFailed: {
+		Code:           "PreconditionFailed",
+		Description:    "At least one of the pre-conditions you specified did not hold",
+		HTTPStatusCode: http.StatusPreconditionFailed,
+	},
+	errPresignExpiresTooLarge: {
+		Code:           "AuthorizationQueryParametersError",
+		Description:    "X-Amz-Expires must be less than a week (in seconds) that is; 604800 seconds",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	errRequestTimeTooSkewed: {
+		Code:           "RequestTimeTooSkewed",
+		Description:    "The difference between the request time and the server's time is too large.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	errSignatureDoesNotMatch: {
+		Code:           "SignatureDoesNotMatch",
+		Description:    "The request signature we calculated does not match the signature you provided. Check your key and signing method.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	errUnsignedHeaders: {
+		Code:           "AccessDenied",
+		Description:    "There were headers present in the request which were not signed",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	errContentSHA256Mismatch: {
+		Code:           "XAmzContentSHA256Mismatch",
+		Description:    "The provided 'x-amz-content-sha256' header does not match what was computed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+}
+
+// errorResponse - the XML body of an error response.
+type errorResponse struct {
+	XMLName    xml.Name `xml:"Error"`
+	Code       string
+	Message    string
+	BucketName string `xml:",omitempty"`
+	Key        string `xml:",omitempty"`
+	Resource   string
+	RequestID  string `xml:"RequestId"`
+	HostID     string `xml:"HostId"`
+}
+
+// writeErrorResponse - write the error response for errCode to w.
+func writeErrorResponse(w http.ResponseWriter, r *http.Request, errCode apiErrorCode, bucketName, objectName string) {
+	apiErr := apiErrors[errCode]
+	// Conditional requests that did not modify anything have no body.
+	if apiErr.HTTPStatusCode == http.StatusNotModified {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	body, err := xml.Marshal(errorResponse{
+		Code:       apiErr.Code,
+		Message:    apiErr.Description,
+		BucketName: bucketName,
+		Key:        objectName,
+		Resource:   r.URL.Path,
+		RequestID:  w.Header().Get("X-Amz-Request-Id"),
+		HostID:     w.Header().Get("X-Amz-Id-2"),
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	body = append([]byte(xml.Header), body...)
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(apiErr.HTTPStatusCode)
+	w.Write(body)
+}
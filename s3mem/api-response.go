@@ -0,0 +1,210 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Namespace of all S3 XML documents.
+const s3Namespace = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+// Format of the timestamps in XML responses.
+const timeFormatAMZ = "2006-01-02T15:04:05.000Z"
+
+// owner - the owner of every bucket and object on the server.
+type owner struct {
+	ID          string
+	DisplayName string
+}
+
+// serverOwner - the single owner of everything stored.
+var serverOwner = owner{ID: serverName, DisplayName: serverName}
+
+// listAllMyBucketsResult - the response of ListBuckets.
+type listAllMyBucketsResult struct {
+	XMLName xml.Name `xml:"ListAllMyBucketsResult"`
+	Xmlns   string   `xml:"xmlns,attr"`
+	Owner   owner
+	Buckets []bucketInfo `xml:"Buckets>Bucket"`
+}
+
+// bucketInfo - a bucket listed by ListBuckets.
+type bucketInfo struct {
+	Name         string
+	CreationDate string
+}
+
+// objectInfo - an object listed by ListObjects.
+type objectInfo struct {
+	Key          string
+	LastModified string
+	ETag         string
+	Size         int
+	Owner        *owner `xml:",omitempty"`
+	StorageClass string
+}
+
+// commonPrefix - a prefix rolled up by a delimiter.
+type commonPrefix struct {
+	Prefix string
+}
+
+// listBucketResult - the response of ListObjects.
+type listBucketResult struct {
+	XMLName        xml.Name `xml:"ListBucketResult"`
+	Xmlns          string   `xml:"xmlns,attr"`
+	Name           string
+	Prefix         string
+	Marker         string
+	NextMarker     string `xml:",omitempty"`
+	MaxKeys        int
+	Delimiter      string `xml:",omitempty"`
+	IsTruncated    bool
+	Contents       []objectInfo
+	CommonPrefixes []commonPrefix
+}
+
+// listBucketV2Result - the response of ListObjects V2.
+type listBucketV2Result struct {
+	XMLName               xml.Name `xml:"ListBucketResult"`
+	Xmlns                 string   `xml:"xmlns,attr"`
+	Name                  string
+	Prefix                string
+	StartAfter            string `xml:",omitempty"`
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
+	KeyCount              int
+	MaxKeys               int
+	Delimiter             string `xml:",omitempty"`
+	IsTruncated           bool
+	Contents              []objectInfo
+	CommonPrefixes        []commonPrefix
+}
+
+// copyObjectResult - the response of CopyObject.
+type copyObjectResult struct {
+	XMLName      xml.Name `xml:"CopyObjectResult"`
+	Xmlns        string   `xml:"xmlns,attr"`
+	LastModified string
+	ETag         string
+}
+
+// initiateMultipartUploadResult - the response of InitiateMultipartUpload.
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Bucket   string
+	Key      string
+	UploadID string `xml:"UploadId"`
+}
+
+// partInfo - a part listed by ListParts.
+type partInfo struct {
+	PartNumber   int
+	LastModified string
+	ETag         string
+	Size         int
+}
+
+// listPartsResult - the response of ListParts.
+type listPartsResult struct {
+	XMLName              xml.Name `xml:"ListPartsResult"`
+	Xmlns                string   `xml:"xmlns,attr"`
+	Bucket               string
+	Key                  string
+	UploadID             string `xml:"UploadId"`
+	Initiator            owner
+	Owner                owner
+	StorageClass         string
+	PartNumberMarker     int
+	NextPartNumberMarker int
+	MaxParts             int
+	IsTruncated          bool
+	Parts                []partInfo `xml:"Part"`
+}
+
+// uploadInfo - an upload listed by ListMultipartUploads.
+type uploadInfo struct {
+	Key          string
+	UploadID     string `xml:"UploadId"`
+	Initiator    owner
+	Owner        owner
+	StorageClass string
+	Initiated    string
+}
+
+// listMultipartUploadsResult - the response of ListMultipartUploads.
+type listMultipartUploadsResult struct {
+	XMLName            xml.Name `xml:"ListMultipartUploadsResult"`
+	Xmlns              string   `xml:"xmlns,attr"`
+	Bucket             string
+	KeyMarker          string
+	UploadIDMarker     string `xml:"UploadIdMarker"`
+	NextKeyMarker      string
+	NextUploadIDMarker string `xml:"NextUploadIdMarker"`
+	Prefix             string
+	MaxUploads         int
+	IsTruncated        bool
+	Uploads            []uploadInfo `xml:"Upload"`
+}
+
+// completeMultipartUploadResult - the response of CompleteMultipartUpload.
+type completeMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Location string
+	Bucket   string
+	Key      string
+	ETag     string
+}
+
+// completeMultipartUpload - the request body of CompleteMultipartUpload.
+type completeMultipartUpload struct {
+	Parts []struct {
+		PartNumber int
+		ETag       string
+	} `xml:"Part"`
+}
+
+// formatTime - format t the way XML responses do.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormatAMZ)
+}
+
+// writeXMLResponse - encode v and write it to w with a 200 OK status.
+func writeXMLResponse(w http.ResponseWriter, v interface{}) {
+	body, err := xml.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	body = append([]byte(xml.Header), body...)
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// writeEmptyResponse - write a response without a body.
+func writeEmptyResponse(w http.ResponseWriter, statusCode int) {
+	w.Header().Set("Content-Length", "0")
+	w.WriteHeader(statusCode)
+}
@@ -0,0 +1,318 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Maximum number of keys returned by a single listing.
+const maxObjectList = 1000
+
+// listBuckets - list every bucket on the server.
+func (s *Server) listBuckets(w http.ResponseWriter, r *http.Request) {
+	names := []string{}
+	for name := range s.buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	result := listAllMyBucketsResult{Xmlns: s3Namespace, Owner: serverOwner}
+	for _, name := range names {
+		result.Buckets = append(result.Buckets, bucketInfo{
+			Name:         name,
+			CreationDate: formatTime(s.buckets[name].created),
+		})
+	}
+	writeXMLResponse(w, result)
+}
+
+// putBucket - create a bucket.
+func (s *Server) putBucket(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if !isValidBucketName(bucketName) {
+		writeErrorResponse(w, r, errInvalidBucketName, bucketName, "")
+		return
+	}
+	if _, ok := s.buckets[bucketName]; ok {
+		writeErrorResponse(w, r, errBucketAlreadyOwnedByYou, bucketName, "")
+		return
+	}
+	s.buckets[bucketName] = &bucket{
+		name:    bucketName,
+		created: time.Now().UTC(),
+		objects: make(map[string]*object),
+		uploads: make(map[string]*upload),
+	}
+	w.Header().Set("Location", "/"+bucketName)
+	writeEmptyResponse(w, http.StatusOK)
+}
+
+// headBucket - check whether a bucket exists.
+func (s *Server) headBucket(w http.ResponseWriter, r *http.Request, bucketName string) {
+	if _, ok := s.buckets[bucketName]; !ok {
+		// Responses to HEAD requests carry no body.
+		writeEmptyResponse(w, http.StatusNotFound)
+		return
+	}
+	writeEmptyResponse(w, http.StatusOK)
+}
+
+// removeBucket - remove an empty bucket.
+func (s *Server) removeBucket(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, "")
+		return
+	}
+	if len(b.objects) > 0 || len(b.uploads) > 0 {
+		writeErrorResponse(w, r, errBucketNotEmpty, bucketName, "")
+		return
+	}
+	delete(s.buckets, bucketName)
+	writeEmptyResponse(w, http.StatusNoContent)
+}
+
+// putBucketPolicy - set the policy of a bucket.
+func (s *Server) putBucketPolicy(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, "")
+		return
+	}
+	policy, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, r, errIncompleteBody, bucketName, "")
+		return
+	}
+	if _, errCode := parseBucketPolicy(policy); errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, "")
+		return
+	}
+	b.policy = policy
+	writeEmptyResponse(w, http.StatusNoContent)
+}
+
+// getBucketPolicy - return the policy of a bucket as it was set.
+func (s *Server) getBucketPolicy(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, "")
+		return
+	}
+	if b.policy == nil {
+		writeErrorResponse(w, r, errNoSuchBucketPolicy, bucketName, "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(b.policy)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(b.policy)
+}
+
+// deleteBucketPolicy - remove the policy of a bucket.
+func (s *Server) deleteBucketPolicy(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, "")
+		return
+	}
+	b.policy = nil
+	writeEmptyResponse(w, http.StatusNoContent)
+}
+
+// listObjectsV1 - list the objects of a bucket.
+func (s *Server) listObjectsV1(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, "")
+		return
+	}
+	query := r.URL.Query()
+	maxKeys, errCode := parseMaxKeys(query.Get("max-keys"))
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, "")
+		return
+	}
+	listing := b.list(query.Get("prefix"), query.Get("delimiter"), query.Get("marker"), maxKeys)
+	result := listBucketResult{
+		Xmlns:          s3Namespace,
+		Name:           bucketName,
+		Prefix:         query.Get("prefix"),
+		Marker:         query.Get("marker"),
+		MaxKeys:        maxKeys,
+		Delimiter:      query.Get("delimiter"),
+		IsTruncated:    listing.isTruncated,
+		Contents:       listing.contents,
+		CommonPrefixes: listing.prefixes,
+	}
+	// NextMarker is only returned when a delimiter was given.
+	if listing.isTruncated && result.Delimiter != "" {
+		result.NextMarker = listing.lastKey
+	}
+	writeXMLResponse(w, result)
+}
+
+// listObjectsV2 - list the objects of a bucket with the V2 API.
+func (s *Server) listObjectsV2(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, "")
+		return
+	}
+	query := r.URL.Query()
+	maxKeys, errCode := parseMaxKeys(query.Get("max-keys"))
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, "")
+		return
+	}
+	marker := query.Get("start-after")
+	if token := query.Get("continuation-token"); token != "" {
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			writeErrorResponse(w, r, errInvalidArgument, bucketName, "")
+			return
+		}
+		marker = string(decoded)
+	}
+	listing := b.list(query.Get("prefix"), query.Get("delimiter"), marker, maxKeys)
+	result := listBucketV2Result{
+		Xmlns:             s3Namespace,
+		Name:              bucketName,
+		Prefix:            query.Get("prefix"),
+		StartAfter:        query.Get("start-after"),
+		ContinuationToken: query.Get("continuation-token"),
+		KeyCount:          len(listing.contents) + len(listing.prefixes),
+		MaxKeys:           maxKeys,
+		Delimiter:         query.Get("delimiter"),
+		IsTruncated:       listing.isTruncated,
+		Contents:          listing.contents,
+		CommonPrefixes:    listing.prefixes,
+	}
+	if listing.isTruncated {
+		result.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(listing.lastKey))
+	}
+	// V2 only returns the owner when it is asked for.
+	if query.Get("fetch-owner") != "true" {
+		for i := range result.Contents {
+			result.Contents[i].Owner = nil
+		}
+	}
+	writeXMLResponse(w, result)
+}
+
+// objectListing - one page of the keys of a bucket.
+type objectListing struct {
+	contents    []objectInfo
+	prefixes    []commonPrefix
+	isTruncated bool
+	lastKey     string // The last key or prefix returned, where the next page starts after.
+}
+
+// list - list up to maxKeys keys and common prefixes after marker that start with prefix.
+func (b *bucket) list(prefix, delimiter, marker string, maxKeys int) objectListing {
+	keys := []string{}
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) && key > marker {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	listing := objectListing{}
+	seenPrefixes := make(map[string]bool)
+	for _, key := range keys {
+		// Keys rolled up into a prefix that was already returned are skipped.
+		rolledUp := ""
+		if delimiter != "" {
+			if i := strings.Index(key[len(prefix):], delimiter); i >= 0 {
+				rolledUp = key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if rolledUp != "" && (seenPrefixes[rolledUp] || rolledUp <= marker) {
+			continue
+		}
+		if len(listing.contents)+len(listing.prefixes) >= maxKeys {
+			listing.isTruncated = true
+			break
+		}
+		if rolledUp != "" {
+			seenPrefixes[rolledUp] = true
+			listing.prefixes = append(listing.prefixes, commonPrefix{Prefix: rolledUp})
+			listing.lastKey = rolledUp
+			continue
+		}
+		obj := b.objects[key]
+		listing.contents = append(listing.contents, objectInfo{
+			Key:          key,
+			LastModified: formatTime(obj.lastModified),
+			ETag:         obj.etag,
+			Size:         len(obj.data),
+			Owner:        &serverOwner,
+			StorageClass: "STANDARD",
+		})
+		listing.lastKey = key
+	}
+	return listing
+}
+
+// parseMaxKeys - parse the max-keys parameter of a listing, capped at maxObjectList.
+func parseMaxKeys(value string) (int, apiErrorCode) {
+	if value == "" {
+		return maxObjectList, errNone
+	}
+	maxKeys, err := strconv.Atoi(value)
+	if err != nil || maxKeys < 0 {
+		return 0, errInvalidArgument
+	}
+	if maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+	return maxKeys, errNone
+}
+
+// isValidBucketName - check a bucket name against the S3 naming rules, see
+// http://docs.aws.amazon.com/AmazonS3/latest/dev/BucketRestrictions.html.
+func isValidBucketName(bucketName string) bool {
+	if len(bucketName) < 3 || len(bucketName) > 63 {
+		return false
+	}
+	// Names can not be formatted as IP addresses.
+	if net.ParseIP(bucketName) != nil {
+		return false
+	}
+	if strings.Contains(bucketName, "..") {
+		return false
+	}
+	for i := 0; i < len(bucketName); i++ {
+		c := bucketName[i]
+		isAlphaNumeric := ('a' <= c && c <= 'z') || ('0' <= c && c <= '9')
+		// Names start and end with a lowercase letter or a digit.
+		if (i == 0 || i == len(bucketName)-1) && !isAlphaNumeric {
+			return false
+		}
+		if !isAlphaNumeric && c != '.' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
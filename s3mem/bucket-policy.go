@@ -0,0 +1,219 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+)
+
+// Resource prefix for all aws resources.
+const awsResourcePrefix = "arn:aws:s3:::"
+
+// stringList - a policy element that may be either a single string or a list of strings.
+type stringList []string
+
+// UnmarshalJSON - accept both "value" and ["value", ...].
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = stringList{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = stringList(list)
+	return nil
+}
+
+// matches - check whether any of the patterns in l matches value.
+func (l stringList) matches(value string) bool {
+	for _, pattern := range l {
+		if matched, err := path.Match(pattern, value); err == nil && matched {
+			return true
+		}
+		// path.Match does not let '*' match '/', resources do.
+		if len(pattern) > 0 && pattern[len(pattern)-1] == '*' && len(value) >= len(pattern)-1 &&
+			value[:len(pattern)-1] == pattern[:len(pattern)-1] {
+			return true
+		}
+	}
+	return false
+}
+
+// policyStatement - a single statement of a bucket policy.
+type policyStatement struct {
+	Action    stringList
+	Condition map[string]map[string]stringList
+	Effect    string
+	Principal json.RawMessage
+	Resource  stringList
+}
+
+// bucketPolicy - the parts of a bucket policy needed to authorize anonymous requests.
+type bucketPolicy struct {
+	Version   string
+	Statement []policyStatement
+}
+
+// parseBucketPolicy - parse and validate a bucket policy document.
+func parseBucketPolicy(data []byte) (bucketPolicy, apiErrorCode) {
+	var policy bucketPolicy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return bucketPolicy{}, errMalformedPolicy
+	}
+	if len(policy.Statement) == 0 {
+		return bucketPolicy{}, errMalformedPolicy
+	}
+	for _, statement := range policy.Statement {
+		if statement.Effect != "Allow" && statement.Effect != "Deny" {
+			return bucketPolicy{}, errMalformedPolicy
+		}
+		if len(statement.Action) == 0 || len(statement.Resource) == 0 {
+			return bucketPolicy{}, errMalformedPolicy
+		}
+	}
+	return policy, errNone
+}
+
+// isAnonymous - check whether the statement applies to everybody.
+func (statement policyStatement) isAnonymous() bool {
+	var principal string
+	if err := json.Unmarshal(statement.Principal, &principal); err == nil {
+		return principal == "*"
+	}
+	var users map[string]stringList
+	if err := json.Unmarshal(statement.Principal, &users); err != nil {
+		return false
+	}
+	for _, user := range users["AWS"] {
+		if user == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionsHold - check the conditions of the statement against r. Only
+// conditions on s3:prefix are understood, statements with any other
+// condition never apply.
+func (statement policyStatement) conditionsHold(r *http.Request) bool {
+	prefix := r.URL.Query().Get("prefix")
+	for operator, keys := range statement.Condition {
+		for key, values := range keys {
+			if key != "s3:prefix" {
+				return false
+			}
+			switch operator {
+			case "StringEquals":
+				if !values.contains(prefix) {
+					return false
+				}
+			case "StringLike":
+				if !values.matches(prefix) {
+					return false
+				}
+			default:
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// contains - check whether value is one of the strings in l.
+func (l stringList) contains(value string) bool {
+	for _, s := range l {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
+
+// anonymousAllowed - check whether the policy of the bucket allows r to be made without credentials.
+func (s *Server) anonymousAllowed(r *http.Request, bucketName, objectName string) bool {
+	b, ok := s.buckets[bucketName]
+	if !ok || b.policy == nil {
+		return false
+	}
+	policy, errCode := parseBucketPolicy(b.policy)
+	if errCode != errNone {
+		return false
+	}
+	action := requestAction(r, objectName)
+	if action == "" {
+		return false
+	}
+	resource := awsResourcePrefix + bucketName
+	if objectName != "" {
+		resource += "/" + objectName
+	}
+	allowed := false
+	for _, statement := range policy.Statement {
+		if !statement.isAnonymous() || !statement.Action.matches(action) ||
+			!statement.Resource.matches(resource) || !statement.conditionsHold(r) {
+			continue
+		}
+		// An explicit deny always wins.
+		if statement.Effect == "Deny" {
+			return false
+		}
+		allowed = true
+	}
+	return allowed
+}
+
+// requestAction - the policy action r needs permission for, empty if it can
+// never be made anonymously.
+func requestAction(r *http.Request, objectName string) string {
+	query := r.URL.Query()
+	_, isUploads := query["uploads"]
+	_, isUploadID := query["uploadId"]
+	_, isLocation := query["location"]
+	_, isPolicy := query["policy"]
+	if objectName == "" {
+		switch {
+		case r.Method == "GET" && isLocation:
+			return "s3:GetBucketLocation"
+		case r.Method == "GET" && isUploads:
+			return "s3:ListBucketMultipartUploads"
+		case isPolicy:
+			// Bucket policies can only be read or changed by the owner.
+			return ""
+		case r.Method == "GET", r.Method == "HEAD":
+			return "s3:ListBucket"
+		}
+		return ""
+	}
+	switch {
+	case r.Method == "GET" && isUploadID:
+		return "s3:ListMultipartUploadParts"
+	case r.Method == "GET", r.Method == "HEAD":
+		return "s3:GetObject"
+	case r.Method == "PUT", r.Method == "POST":
+		return "s3:PutObject"
+	case r.Method == "DELETE" && isUploadID:
+		return "s3:AbortMultipartUpload"
+	case r.Method == "DELETE":
+		return "s3:DeleteObject"
+	}
+	return ""
+}
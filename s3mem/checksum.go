@@ -0,0 +1,89 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"hash/crc32"
+	"net/http"
+	"strings"
+)
+
+// checksumAlgorithms - the hashes of the additional checksums, by the name used in
+// x-amz-checksum-algorithm. The checksum itself is sent in x-amz-checksum-<name>, see
+// http://docs.aws.amazon.com/AmazonS3/latest/userguide/checking-object-integrity.html.
+var checksumAlgorithms = map[string]func() hash.Hash{
+	"CRC32":  func() hash.Hash { return crc32.NewIEEE() },
+	"CRC32C": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+}
+
+// checksum - an additional checksum of an object or a part, as sent in its header.
+type checksum struct {
+	algorithm string // Upper case, empty if no checksum was sent.
+	value     string // Base64 encoded.
+}
+
+// checksumHeader - the header carrying the checksum of algorithm.
+func checksumHeader(algorithm string) string {
+	return "X-Amz-Checksum-" + strings.ToLower(algorithm)
+}
+
+// setHeader - set the checksum header of c, if there is one.
+func (c checksum) setHeader(header http.Header) {
+	if c.algorithm != "" {
+		header.Set(checksumHeader(c.algorithm), c.value)
+	}
+}
+
+// requestChecksum - the additional checksum sent in header, either as a header or as a
+// trailing header of an aws-chunked body. At most one checksum can be sent.
+func requestChecksum(header http.Header) (checksum, apiErrorCode) {
+	var sent checksum
+	for algorithm, newHash := range checksumAlgorithms {
+		value := header.Get(checksumHeader(algorithm))
+		if value == "" {
+			continue
+		}
+		if sent.algorithm != "" {
+			return checksum{}, errInvalidChecksum
+		}
+		if sum, err := base64.StdEncoding.DecodeString(value); err != nil || len(sum) != newHash().Size() {
+			return checksum{}, errInvalidChecksum
+		}
+		sent = checksum{algorithm, value}
+	}
+	return sent, errNone
+}
+
+// verifyChecksum - verify the payload against the additional checksum sent in header, if any.
+func verifyChecksum(header http.Header, payload []byte) apiErrorCode {
+	sent, errCode := requestChecksum(header)
+	if errCode != errNone || sent.algorithm == "" {
+		return errCode
+	}
+	hash := checksumAlgorithms[sent.algorithm]()
+	hash.Write(payload)
+	if base64.StdEncoding.EncodeToString(hash.Sum(nil)) != sent.value {
+		return errBadChecksum
+	}
+	return errNone
+}
@@ -0,0 +1,291 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Multipart upload limits, see
+// http://docs.aws.amazon.com/AmazonS3/latest/dev/qfacts.html.
+const (
+	minPartSize    = 5 * 1024 * 1024
+	maxPartNumber  = 10000
+	maxPartsList   = 1000
+	maxUploadsList = 1000
+)
+
+// initiateMultipartUpload - start a new multipart upload.
+func (s *Server) initiateMultipartUpload(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, objectName)
+		return
+	}
+	checksumAlgorithm := strings.ToUpper(r.Header.Get("X-Amz-Checksum-Algorithm"))
+	if _, ok := checksumAlgorithms[checksumAlgorithm]; checksumAlgorithm != "" && !ok {
+		writeErrorResponse(w, r, errInvalidArgument, bucketName, objectName)
+		return
+	}
+	s.uploadID++
+	uploadID := hex.EncodeToString(sumMD5([]byte(fmt.Sprintf("%s/%s/%d", bucketName, objectName, s.uploadID))))
+	b.uploads[uploadID] = &upload{
+		id:                uploadID,
+		key:               objectName,
+		initiated:         time.Now().UTC(),
+		contentType:       r.Header.Get("Content-Type"),
+		checksumAlgorithm: checksumAlgorithm,
+		parts:             make(map[int]*part),
+	}
+	if checksumAlgorithm != "" {
+		w.Header().Set("X-Amz-Checksum-Algorithm", checksumAlgorithm)
+	}
+	writeXMLResponse(w, initiateMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Bucket:   bucketName,
+		Key:      objectName,
+		UploadID: uploadID,
+	})
+}
+
+// getUpload - look up the multipart upload named by the uploadId parameter of r.
+func (s *Server) getUpload(r *http.Request, bucketName, objectName string) (*bucket, *upload, apiErrorCode) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, nil, errNoSuchBucket
+	}
+	u, ok := b.uploads[r.URL.Query().Get("uploadId")]
+	if !ok || u.key != objectName {
+		return nil, nil, errNoSuchUpload
+	}
+	return b, u, errNone
+}
+
+// uploadPart - store a part of a multipart upload, replacing any part with the same number.
+func (s *Server) uploadPart(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	_, u, errCode := s.getUpload(r, bucketName, objectName)
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, objectName)
+		return
+	}
+	partNumber, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || partNumber < 1 || partNumber > maxPartNumber {
+		writeErrorResponse(w, r, errInvalidArgument, bucketName, objectName)
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, r, errIncompleteBody, bucketName, objectName)
+		return
+	}
+	// The checksum was verified along with the signature, the parts of an upload
+	// all carry a checksum of the algorithm it was initiated with, if any.
+	sent, _ := requestChecksum(r.Header)
+	if sent.algorithm != u.checksumAlgorithm {
+		writeErrorResponse(w, r, errChecksumTypeMismatch, bucketName, objectName)
+		return
+	}
+	p := &part{
+		number:       partNumber,
+		data:         data,
+		etag:         "\"" + hex.EncodeToString(sumMD5(data)) + "\"",
+		lastModified: time.Now().UTC(),
+		checksum:     sent,
+	}
+	u.parts[partNumber] = p
+	w.Header().Set("ETag", p.etag)
+	p.checksum.setHeader(w.Header())
+	writeEmptyResponse(w, http.StatusOK)
+}
+
+// listParts - list the parts uploaded to a multipart upload.
+func (s *Server) listParts(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	_, u, errCode := s.getUpload(r, bucketName, objectName)
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, objectName)
+		return
+	}
+	query := r.URL.Query()
+	maxParts := maxPartsList
+	if value := query.Get("max-parts"); value != "" {
+		var err error
+		if maxParts, err = strconv.Atoi(value); err != nil || maxParts < 0 {
+			writeErrorResponse(w, r, errInvalidArgument, bucketName, objectName)
+			return
+		}
+		if maxParts > maxPartsList {
+			maxParts = maxPartsList
+		}
+	}
+	marker, _ := strconv.Atoi(query.Get("part-number-marker"))
+
+	numbers := []int{}
+	for number := range u.parts {
+		if number > marker {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	result := listPartsResult{
+		Xmlns:            s3Namespace,
+		Bucket:           bucketName,
+		Key:              objectName,
+		UploadID:         u.id,
+		Initiator:        serverOwner,
+		Owner:            serverOwner,
+		StorageClass:     "STANDARD",
+		PartNumberMarker: marker,
+		MaxParts:         maxParts,
+	}
+	for _, number := range numbers {
+		if len(result.Parts) >= maxParts {
+			result.IsTruncated = true
+			break
+		}
+		p := u.parts[number]
+		result.Parts = append(result.Parts, partInfo{
+			PartNumber:   p.number,
+			LastModified: formatTime(p.lastModified),
+			ETag:         p.etag,
+			Size:         len(p.data),
+		})
+		result.NextPartNumberMarker = p.number
+	}
+	writeXMLResponse(w, result)
+}
+
+// listMultipartUploads - list the multipart uploads in progress in a bucket.
+func (s *Server) listMultipartUploads(w http.ResponseWriter, r *http.Request, bucketName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, "")
+		return
+	}
+	query := r.URL.Query()
+	prefix := query.Get("prefix")
+	uploads := []*upload{}
+	for _, u := range b.uploads {
+		if strings.HasPrefix(u.key, prefix) {
+			uploads = append(uploads, u)
+		}
+	}
+	sort.Sort(byKeyAndInitiated(uploads))
+	result := listMultipartUploadsResult{
+		Xmlns:      s3Namespace,
+		Bucket:     bucketName,
+		Prefix:     prefix,
+		MaxUploads: maxUploadsList,
+	}
+	for _, u := range uploads {
+		if len(result.Uploads) >= maxUploadsList {
+			result.IsTruncated = true
+			break
+		}
+		result.Uploads = append(result.Uploads, uploadInfo{
+			Key:          u.key,
+			UploadID:     u.id,
+			Initiator:    serverOwner,
+			Owner:        serverOwner,
+			StorageClass: "STANDARD",
+			Initiated:    formatTime(u.initiated),
+		})
+		result.NextKeyMarker = u.key
+		result.NextUploadIDMarker = u.id
+	}
+	writeXMLResponse(w, result)
+}
+
+// byKeyAndInitiated - sort uploads by key, then by the time they were initiated at.
+type byKeyAndInitiated []*upload
+
+func (u byKeyAndInitiated) Len() int      { return len(u) }
+func (u byKeyAndInitiated) Swap(i, j int) { u[i], u[j] = u[j], u[i] }
+func (u byKeyAndInitiated) Less(i, j int) bool {
+	if u[i].key != u[j].key {
+		return u[i].key < u[j].key
+	}
+	return u[i].initiated.Before(u[j].initiated)
+}
+
+// completeMultipartUpload - assemble the listed parts of a multipart upload into an object.
+func (s *Server) completeMultipartUpload(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	b, u, errCode := s.getUpload(r, bucketName, objectName)
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, objectName)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, r, errIncompleteBody, bucketName, objectName)
+		return
+	}
+	complete := completeMultipartUpload{}
+	if err := xml.Unmarshal(body, &complete); err != nil || len(complete.Parts) == 0 {
+		writeErrorResponse(w, r, errMalformedXML, bucketName, objectName)
+		return
+	}
+	var data, md5Sums []byte
+	for i, completePart := range complete.Parts {
+		if i > 0 && completePart.PartNumber <= complete.Parts[i-1].PartNumber {
+			writeErrorResponse(w, r, errInvalidPartOrder, bucketName, objectName)
+			return
+		}
+		p, ok := u.parts[completePart.PartNumber]
+		if !ok || strings.Trim(completePart.ETag, "\"") != strings.Trim(p.etag, "\"") {
+			writeErrorResponse(w, r, errInvalidPart, bucketName, objectName)
+			return
+		}
+		// Every part but the last has to be at least 5MiB.
+		if i < len(complete.Parts)-1 && len(p.data) < minPartSize {
+			writeErrorResponse(w, r, errEntityTooSmall, bucketName, objectName)
+			return
+		}
+		data = append(data, p.data...)
+		md5Sums = append(md5Sums, sumMD5(p.data)...)
+	}
+	obj := newObject(objectName, data, u.contentType)
+	// The ETag of a multipart object is the MD5 of the MD5s of its parts.
+	obj.etag = fmt.Sprintf("\"%s-%d\"", hex.EncodeToString(sumMD5(md5Sums)), len(complete.Parts))
+	b.objects[objectName] = obj
+	delete(b.uploads, u.id)
+	writeXMLResponse(w, completeMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Location: "/" + bucketName + "/" + objectName,
+		Bucket:   bucketName,
+		Key:      objectName,
+		ETag:     obj.etag,
+	})
+}
+
+// abortMultipartUpload - discard a multipart upload and its parts.
+func (s *Server) abortMultipartUpload(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	b, u, errCode := s.getUpload(r, bucketName, objectName)
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, objectName)
+		return
+	}
+	delete(b.uploads, u.id)
+	writeEmptyResponse(w, http.StatusNoContent)
+}
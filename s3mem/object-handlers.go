@@ -0,0 +1,367 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Content type of objects uploaded without one.
+const defaultContentType = "binary/octet-stream"
+
+// Query parameters of a GET request that override a response header.
+var responseOverrides = map[string]string{
+	"response-content-type":        "Content-Type",
+	"response-content-language":    "Content-Language",
+	"response-expires":             "Expires",
+	"response-cache-control":       "Cache-Control",
+	"response-content-disposition": "Content-Disposition",
+	"response-content-encoding":    "Content-Encoding",
+}
+
+// newObject - create an object holding data, stamped with the current time.
+func newObject(key string, data []byte, contentType string) *object {
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+	return &object{
+		key:  key,
+		data: data,
+		etag: "\"" + hex.EncodeToString(sumMD5(data)) + "\"",
+		// Last-Modified only has a precision of seconds.
+		lastModified: time.Now().UTC().Truncate(time.Second),
+		contentType:  contentType,
+	}
+}
+
+// putObject - store an object.
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, objectName)
+		return
+	}
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, r, errIncompleteBody, bucketName, objectName)
+		return
+	}
+	obj := newObject(objectName, data, r.Header.Get("Content-Type"))
+	// The checksum was verified along with the signature.
+	obj.checksum, _ = requestChecksum(r.Header)
+	b.objects[objectName] = obj
+	w.Header().Set("ETag", obj.etag)
+	obj.checksum.setHeader(w.Header())
+	writeEmptyResponse(w, http.StatusOK)
+}
+
+// getObject - return an object, or the requested range of it.
+func (s *Server) getObject(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	obj, errCode := s.getObjectInfo(r, bucketName, objectName)
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, objectName)
+		return
+	}
+	start, length, isRange, errCode := parseRange(r.Header.Get("Range"), int64(len(obj.data)))
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, objectName)
+		return
+	}
+	setObjectHeaders(w, r, obj)
+	w.Header().Set("Content-Length", strconv.FormatInt(length, 10))
+	if !isRange {
+		w.WriteHeader(http.StatusOK)
+		w.Write(obj.data)
+		return
+	}
+	w.Header().Set("Content-Range", "bytes "+strconv.FormatInt(start, 10)+"-"+
+		strconv.FormatInt(start+length-1, 10)+"/"+strconv.Itoa(len(obj.data)))
+	w.WriteHeader(http.StatusPartialContent)
+	w.Write(obj.data[start : start+length])
+}
+
+// headObject - return the metadata of an object.
+func (s *Server) headObject(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	obj, errCode := s.getObjectInfo(r, bucketName, objectName)
+	if errCode != errNone {
+		// Responses to HEAD requests carry no body.
+		writeEmptyResponse(w, apiErrors[errCode].HTTPStatusCode)
+		return
+	}
+	setObjectHeaders(w, r, obj)
+	w.Header().Set("Content-Length", strconv.Itoa(len(obj.data)))
+	w.WriteHeader(http.StatusOK)
+}
+
+// getObjectInfo - look up the object a GET or HEAD request is for and evaluate its preconditions.
+func (s *Server) getObjectInfo(r *http.Request, bucketName, objectName string) (*object, apiErrorCode) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, errNoSuchBucket
+	}
+	obj, ok := b.objects[objectName]
+	if !ok {
+		return nil, errNoSuchKey
+	}
+	return obj, checkPreconditions(r.Header, obj)
+}
+
+// setObjectHeaders - set the metadata headers of obj, along with the
+// overrides requested in the query.
+func setObjectHeaders(w http.ResponseWriter, r *http.Request, obj *object) {
+	w.Header().Set("ETag", obj.etag)
+	w.Header().Set("Last-Modified", obj.lastModified.Format(http.TimeFormat))
+	w.Header().Set("Content-Type", obj.contentType)
+	w.Header().Set("Accept-Ranges", "bytes")
+	// Additional checksums are only returned if asked for.
+	if strings.EqualFold(r.Header.Get("X-Amz-Checksum-Mode"), "ENABLED") {
+		obj.checksum.setHeader(w.Header())
+	}
+	query := r.URL.Query()
+	for param, header := range responseOverrides {
+		if value := query.Get(param); value != "" {
+			w.Header().Set(header, value)
+		}
+	}
+}
+
+// checkPreconditions - evaluate the conditional headers of a GET or HEAD
+// request, see https://tools.ietf.org/html/rfc7232#section-6.
+func checkPreconditions(header http.Header, obj *object) apiErrorCode {
+	if ifMatch := header.Get("If-Match"); ifMatch != "" {
+		if !etagMatches(ifMatch, obj.etag) {
+			return errPreconditionFailed
+		}
+	} else if t, err := http.ParseTime(header.Get("If-Unmodified-Since")); err == nil && obj.lastModified.After(t) {
+		return errPreconditionFailed
+	}
+	if ifNoneMatch := header.Get("If-None-Match"); ifNoneMatch != "" {
+		if etagMatches(ifNoneMatch, obj.etag) {
+			return errNotModified
+		}
+	} else if t, err := http.ParseTime(header.Get("If-Modified-Since")); err == nil && !obj.lastModified.After(t) {
+		return errNotModified
+	}
+	return errNone
+}
+
+// checkCopyPreconditions - evaluate the x-amz-copy-source-if-* headers of a copy request.
+func checkCopyPreconditions(header http.Header, obj *object) apiErrorCode {
+	if ifMatch := header.Get("X-Amz-Copy-Source-If-Match"); ifMatch != "" && !etagMatches(ifMatch, obj.etag) {
+		return errPreconditionFailed
+	}
+	if ifNoneMatch := header.Get("X-Amz-Copy-Source-If-None-Match"); ifNoneMatch != "" && etagMatches(ifNoneMatch, obj.etag) {
+		return errPreconditionFailed
+	}
+	if t, err := http.ParseTime(header.Get("X-Amz-Copy-Source-If-Unmodified-Since")); err == nil && obj.lastModified.After(t) {
+		return errPreconditionFailed
+	}
+	if t, err := http.ParseTime(header.Get("X-Amz-Copy-Source-If-Modified-Since")); err == nil && !obj.lastModified.After(t) {
+		return errPreconditionFailed
+	}
+	return errNone
+}
+
+// etagMatches - check whether a comma separated list of ETags, or "*", includes etag.
+func etagMatches(list, etag string) bool {
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.Trim(candidate, "\"") == strings.Trim(etag, "\"") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRange - parse a Range header of the form bytes=start-end, bytes=start-
+// or bytes=-suffix. Headers that can not be parsed are ignored, as S3 does.
+func parseRange(rangeHeader string, size int64) (start, length int64, isRange bool, errCode apiErrorCode) {
+	spec := strings.TrimPrefix(rangeHeader, "bytes=")
+	if rangeHeader == "" || spec == rangeHeader || strings.Count(spec, "-") != 1 {
+		return 0, size, false, errNone
+	}
+	bounds := strings.SplitN(spec, "-", 2)
+	if bounds[0] == "" {
+		// The last bytes of the object.
+		suffix, err := strconv.ParseInt(bounds[1], 10, 64)
+		if err != nil {
+			return 0, size, false, errNone
+		}
+		if suffix == 0 {
+			return 0, 0, false, errInvalidRange
+		}
+		if suffix > size {
+			suffix = size
+		}
+		return size - suffix, suffix, true, errNone
+	}
+	first, err := strconv.ParseInt(bounds[0], 10, 64)
+	if err != nil {
+		return 0, size, false, errNone
+	}
+	last := size - 1
+	if bounds[1] != "" {
+		if last, err = strconv.ParseInt(bounds[1], 10, 64); err != nil || last < first {
+			return 0, size, false, errNone
+		}
+	}
+	if first >= size {
+		return 0, 0, false, errInvalidRange
+	}
+	if last >= size {
+		last = size - 1
+	}
+	return first, last - first + 1, true, errNone
+}
+
+// copyObject - copy an object named by the x-amz-copy-source header.
+func (s *Server) copyObject(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	dst, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, objectName)
+		return
+	}
+	copySource, err := url.QueryUnescape(r.Header.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		writeErrorResponse(w, r, errInvalidArgument, bucketName, objectName)
+		return
+	}
+	srcBucketName, srcObjectName := splitPath(strings.TrimPrefix(copySource, "/"))
+	if srcObjectName == "" {
+		writeErrorResponse(w, r, errInvalidArgument, bucketName, objectName)
+		return
+	}
+	src, ok := s.buckets[srcBucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, srcBucketName, srcObjectName)
+		return
+	}
+	srcObj, ok := src.objects[srcObjectName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchKey, srcBucketName, srcObjectName)
+		return
+	}
+	if errCode := checkCopyPreconditions(r.Header, srcObj); errCode != errNone {
+		writeErrorResponse(w, r, errCode, srcBucketName, srcObjectName)
+		return
+	}
+	contentType := srcObj.contentType
+	if r.Header.Get("X-Amz-Metadata-Directive") == "REPLACE" {
+		contentType = r.Header.Get("Content-Type")
+	}
+	obj := newObject(objectName, srcObj.data, contentType)
+	obj.checksum = srcObj.checksum
+	dst.objects[objectName] = obj
+	writeXMLResponse(w, copyObjectResult{
+		Xmlns:        s3Namespace,
+		LastModified: formatTime(obj.lastModified),
+		ETag:         obj.etag,
+	})
+}
+
+// removeObject - remove an object, removing an object that does not exist succeeds.
+func (s *Server) removeObject(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, objectName)
+		return
+	}
+	delete(b.objects, objectName)
+	writeEmptyResponse(w, http.StatusNoContent)
+}
+
+// postObject - store an object uploaded with a browser form signed by a POST policy.
+func (s *Server) postObject(w http.ResponseWriter, r *http.Request, bucketName string) {
+	form, data, errCode := readPostForm(r)
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, "")
+		return
+	}
+	// The bucket is named by the path, not by the form.
+	form["bucket"] = bucketName
+	objectName := form["key"]
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		writeErrorResponse(w, r, errNoSuchBucket, bucketName, objectName)
+		return
+	}
+	if errCode := s.verifyPostSignature(form); errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, objectName)
+		return
+	}
+	policy, errCode := parsePostPolicy(form["policy"])
+	if errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, objectName)
+		return
+	}
+	if errCode := checkPostPolicy(policy, form, int64(len(data))); errCode != errNone {
+		writeErrorResponse(w, r, errCode, bucketName, objectName)
+		return
+	}
+	obj := newObject(objectName, data, form["content-type"])
+	b.objects[objectName] = obj
+	w.Header().Set("ETag", obj.etag)
+	w.Header().Set("Location", "/"+bucketName+"/"+objectName)
+	if form["success_action_status"] == "200" {
+		writeEmptyResponse(w, http.StatusOK)
+		return
+	}
+	writeEmptyResponse(w, http.StatusNoContent)
+}
+
+// readPostForm - read the fields and the file of a POST policy upload. The
+// names of the fields are returned in lower case, fields after the file are
+// ignored.
+func readPostForm(r *http.Request) (map[string]string, []byte, apiErrorCode) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, errMalformedPOSTRequest
+	}
+	form := make(map[string]string)
+	for {
+		formPart, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errMalformedPOSTRequest
+		}
+		value, err := ioutil.ReadAll(formPart)
+		if err != nil {
+			return nil, nil, errMalformedPOSTRequest
+		}
+		name := strings.ToLower(formPart.FormName())
+		if name != "file" {
+			form[name] = string(value)
+			continue
+		}
+		if form["key"] == "" {
+			return nil, nil, errMissingFields
+		}
+		// ${filename} in the key is replaced by the name of the uploaded file.
+		form["key"] = strings.Replace(form["key"], "${filename}", formPart.FileName(), -1)
+		return form, value, errNone
+	}
+	return nil, nil, errMalformedPOSTRequest
+}
@@ -0,0 +1,149 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Format of the expiration of a POST policy.
+const expirationDateFormat = "2006-01-02T15:04:05.999Z"
+
+// Form fields that do not need a condition in the policy.
+var postPolicyExemptFields = map[string]bool{
+	"policy":          true,
+	"x-amz-signature": true,
+	"file":            true,
+	"awsaccesskeyid":  true, // Signature V2.
+	"signature":       true, // Signature V2.
+}
+
+// postPolicyCondition - one condition of a POST policy, such as
+// ["eq", "$key", "value"] or {"bucket": "value"}.
+type postPolicyCondition struct {
+	operator string
+	field    string // Lower case and without the leading '$'.
+	value    string
+	min, max int64 // Only used by content-length-range.
+}
+
+// postPolicy - a decoded POST policy.
+type postPolicy struct {
+	expiration time.Time
+	conditions []postPolicyCondition
+}
+
+// parsePostPolicy - decode a base64 encoded POST policy, see
+// http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html.
+func parsePostPolicy(encodedPolicy string) (postPolicy, apiErrorCode) {
+	data, err := base64.StdEncoding.DecodeString(encodedPolicy)
+	if err != nil {
+		return postPolicy{}, errMalformedPOSTRequest
+	}
+	var raw struct {
+		Expiration string
+		Conditions []json.RawMessage
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return postPolicy{}, errMalformedPOSTRequest
+	}
+	expiration, err := time.Parse(expirationDateFormat, raw.Expiration)
+	if err != nil {
+		return postPolicy{}, errMalformedPOSTRequest
+	}
+	policy := postPolicy{expiration: expiration}
+	for _, rawCondition := range raw.Conditions {
+		conditions, errCode := parsePostPolicyCondition(rawCondition)
+		if errCode != errNone {
+			return postPolicy{}, errCode
+		}
+		policy.conditions = append(policy.conditions, conditions...)
+	}
+	return policy, errNone
+}
+
+// parsePostPolicyCondition - decode a condition given in either the list or the map form.
+func parsePostPolicyCondition(rawCondition json.RawMessage) ([]postPolicyCondition, apiErrorCode) {
+	// {"field": "value"} is short for ["eq", "$field", "value"].
+	var exact map[string]string
+	if err := json.Unmarshal(rawCondition, &exact); err == nil {
+		conditions := []postPolicyCondition{}
+		for field, value := range exact {
+			conditions = append(conditions, postPolicyCondition{operator: "eq", field: strings.ToLower(field), value: value})
+		}
+		return conditions, errNone
+	}
+	var list []interface{}
+	if err := json.Unmarshal(rawCondition, &list); err != nil || len(list) != 3 {
+		return nil, errMalformedPOSTRequest
+	}
+	operator, ok := list[0].(string)
+	if !ok {
+		return nil, errMalformedPOSTRequest
+	}
+	operator = strings.ToLower(operator)
+	if operator == "content-length-range" {
+		min, minOK := list[1].(float64)
+		max, maxOK := list[2].(float64)
+		if !minOK || !maxOK {
+			return nil, errMalformedPOSTRequest
+		}
+		return []postPolicyCondition{{operator: operator, min: int64(min), max: int64(max)}}, errNone
+	}
+	field, fieldOK := list[1].(string)
+	value, valueOK := list[2].(string)
+	if !fieldOK || !valueOK || !strings.HasPrefix(field, "$") || (operator != "eq" && operator != "starts-with") {
+		return nil, errMalformedPOSTRequest
+	}
+	return []postPolicyCondition{{operator: operator, field: strings.ToLower(strings.TrimPrefix(field, "$")), value: value}}, errNone
+}
+
+// checkPostPolicy - check the form fields and the size of the uploaded file against the policy.
+func checkPostPolicy(policy postPolicy, form map[string]string, size int64) apiErrorCode {
+	if time.Now().UTC().After(policy.expiration) {
+		return errPolicyExpired
+	}
+	covered := make(map[string]bool)
+	for _, condition := range policy.conditions {
+		covered[condition.field] = true
+		value := form[condition.field]
+		switch condition.operator {
+		case "eq":
+			if value != condition.value {
+				return errPolicyConditionFailed
+			}
+		case "starts-with":
+			if !strings.HasPrefix(value, condition.value) {
+				return errPolicyConditionFailed
+			}
+		case "content-length-range":
+			if size < condition.min || size > condition.max {
+				return errPolicyConditionFailed
+			}
+		}
+	}
+	// Every field of the form has to be covered by a condition.
+	for field := range form {
+		if !covered[field] && !postPolicyExemptFields[field] && !strings.HasPrefix(field, "x-ignore-") {
+			return errPolicyConditionFailed
+		}
+	}
+	return errNone
+}
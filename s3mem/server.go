@@ -0,0 +1,258 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package s3mem implements a small in-memory S3 server that follows the
+// behavior of AWS S3 as closely as s3verify checks it. It supports buckets,
+// objects, multipart uploads, bucket policies, presigned URLs, POST policy
+// uploads, AWS Signature Version 4, 4A and 2 verification, temporary credentials,
+// streaming uploads with trailing checksums and path style as well as
+// virtual-hosted-style requests, and is used to test s3verify itself with
+// net/http/httptest.
+package s3mem
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// serverName - the value of the Server header sent with every response.
+const serverName = "s3mem"
+
+// Server - an in-memory S3 server, safe for concurrent use.
+type Server struct {
+	accessKey string
+	secretKey string
+	region    string
+	temporary map[string]temporaryCredentials // Temporary credentials by access key.
+	domain    string                          // Host of virtual-hosted-style requests without the bucket, empty if they are not accepted.
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	requestID uint64 // Incremented for every request to generate request IDs.
+	uploadID  uint64 // Incremented for every multipart upload to generate upload IDs.
+}
+
+// temporaryCredentials - credentials that are only valid along with their session token until they expire.
+type temporaryCredentials struct {
+	secretKey    string
+	sessionToken string
+	expires      time.Time
+}
+
+// bucket - a bucket and everything stored in it.
+type bucket struct {
+	name    string
+	created time.Time
+	policy  []byte // Raw bucket policy as uploaded, nil if there is none.
+	objects map[string]*object
+	uploads map[string]*upload
+}
+
+// object - an object with the metadata returned for it.
+type object struct {
+	key          string
+	data         []byte
+	etag         string // Quoted, as sent in the ETag header.
+	lastModified time.Time
+	contentType  string
+	checksum     checksum // Additional checksum sent along with the object, returned if requested.
+}
+
+// upload - a multipart upload in progress.
+type upload struct {
+	id                string
+	key               string
+	initiated         time.Time
+	contentType       string
+	checksumAlgorithm string // Algorithm of the checksums every part is sent with, empty if none.
+	parts             map[int]*part
+}
+
+// part - a part uploaded to a multipart upload.
+type part struct {
+	number       int
+	data         []byte
+	etag         string // Quoted, as sent in the ETag header.
+	lastModified time.Time
+	checksum     checksum
+}
+
+// New - create an empty server accepting requests signed with accessKey and secretKey for region.
+func New(accessKey, secretKey, region string) *Server {
+	return &Server{
+		accessKey: accessKey,
+		secretKey: secretKey,
+		region:    region,
+		temporary: make(map[string]temporaryCredentials),
+		buckets:   make(map[string]*bucket),
+	}
+}
+
+// AddTemporaryCredentials - accept requests signed with accessKey and secretKey as well, as long
+// as they carry sessionToken and are sent before expires, like credentials issued by AWS STS.
+func (s *Server) AddTemporaryCredentials(accessKey, secretKey, sessionToken string, expires time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.temporary[accessKey] = temporaryCredentials{
+		secretKey:    secretKey,
+		sessionToken: sessionToken,
+		expires:      expires,
+	}
+}
+
+// SetDomain - accept virtual-hosted-style requests sent to bucket.domain as well, domain
+// being the host the server is reached at, along with its port if any.
+func (s *Server) SetDomain(domain string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.domain = domain
+}
+
+// ServeHTTP - authenticate and dispatch a single path style or virtual-hosted-style S3 request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requestID++
+	requestID := fmt.Sprintf("%016X", s.requestID)
+	w.Header().Set("Server", serverName)
+	w.Header().Set("X-Amz-Request-Id", requestID)
+	w.Header().Set("X-Amz-Id-2", base64.StdEncoding.EncodeToString([]byte(serverName+"-"+requestID)))
+
+	virtualHostBucket := s.virtualHostBucket(r)
+	bucketName, objectName := splitPath(r.URL.Path)
+	if virtualHostBucket != "" {
+		bucketName, objectName = virtualHostBucket, strings.TrimPrefix(r.URL.Path, "/")
+	}
+	// POST policy uploads carry their credentials inside of the form.
+	if r.Method == "POST" && bucketName != "" && objectName == "" && isMultipartForm(r) {
+		s.postObject(w, r, bucketName)
+		return
+	}
+	payload, apiErr := s.authenticate(r, virtualHostBucket, bucketName, objectName)
+	if apiErr != errNone {
+		writeErrorResponse(w, r, apiErr, bucketName, objectName)
+		return
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(payload))
+
+	switch {
+	case bucketName == "":
+		s.serviceHandler(w, r)
+	case objectName == "":
+		s.bucketHandler(w, r, bucketName)
+	default:
+		s.objectHandler(w, r, bucketName, objectName)
+	}
+}
+
+// serviceHandler - dispatch requests that do not name a bucket.
+func (s *Server) serviceHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeErrorResponse(w, r, errMethodNotAllowed, "", "")
+		return
+	}
+	s.listBuckets(w, r)
+}
+
+// bucketHandler - dispatch requests on a bucket.
+func (s *Server) bucketHandler(w http.ResponseWriter, r *http.Request, bucketName string) {
+	query := r.URL.Query()
+	_, isPolicy := query["policy"]
+	_, isUploads := query["uploads"]
+	switch {
+	case r.Method == "PUT" && isPolicy:
+		s.putBucketPolicy(w, r, bucketName)
+	case r.Method == "PUT":
+		s.putBucket(w, r, bucketName)
+	case r.Method == "GET" && isPolicy:
+		s.getBucketPolicy(w, r, bucketName)
+	case r.Method == "GET" && isUploads:
+		s.listMultipartUploads(w, r, bucketName)
+	case r.Method == "GET" && query.Get("list-type") == "2":
+		s.listObjectsV2(w, r, bucketName)
+	case r.Method == "GET":
+		s.listObjectsV1(w, r, bucketName)
+	case r.Method == "HEAD":
+		s.headBucket(w, r, bucketName)
+	case r.Method == "DELETE" && isPolicy:
+		s.deleteBucketPolicy(w, r, bucketName)
+	case r.Method == "DELETE":
+		s.removeBucket(w, r, bucketName)
+	default:
+		writeErrorResponse(w, r, errMethodNotAllowed, bucketName, "")
+	}
+}
+
+// objectHandler - dispatch requests on an object.
+func (s *Server) objectHandler(w http.ResponseWriter, r *http.Request, bucketName, objectName string) {
+	query := r.URL.Query()
+	_, isUploads := query["uploads"]
+	_, isUploadID := query["uploadId"]
+	switch {
+	case r.Method == "PUT" && isUploadID:
+		s.uploadPart(w, r, bucketName, objectName)
+	case r.Method == "PUT" && r.Header.Get("X-Amz-Copy-Source") != "":
+		s.copyObject(w, r, bucketName, objectName)
+	case r.Method == "PUT":
+		s.putObject(w, r, bucketName, objectName)
+	case r.Method == "GET" && isUploadID:
+		s.listParts(w, r, bucketName, objectName)
+	case r.Method == "GET":
+		s.getObject(w, r, bucketName, objectName)
+	case r.Method == "HEAD":
+		s.headObject(w, r, bucketName, objectName)
+	case r.Method == "POST" && isUploads:
+		s.initiateMultipartUpload(w, r, bucketName, objectName)
+	case r.Method == "POST" && isUploadID:
+		s.completeMultipartUpload(w, r, bucketName, objectName)
+	case r.Method == "DELETE" && isUploadID:
+		s.abortMultipartUpload(w, r, bucketName, objectName)
+	case r.Method == "DELETE":
+		s.removeObject(w, r, bucketName, objectName)
+	default:
+		writeErrorResponse(w, r, errMethodNotAllowed, bucketName, objectName)
+	}
+}
+
+// virtualHostBucket - the bucket r is addressed to by its host, empty for path style requests.
+func (s *Server) virtualHostBucket(r *http.Request) string {
+	if s.domain == "" || !strings.HasSuffix(r.Host, "."+s.domain) {
+		return ""
+	}
+	return strings.TrimSuffix(r.Host, "."+s.domain)
+}
+
+// splitPath - split a path style request path into its bucket and object names.
+func splitPath(path string) (bucketName, objectName string) {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	bucketName = parts[0]
+	if len(parts) > 1 {
+		objectName = parts[1]
+	}
+	return bucketName, objectName
+}
+
+// isMultipartForm - check whether the body of r is multipart/form-data.
+func isMultipartForm(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+}
@@ -0,0 +1,207 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature V2 constants, see
+// http://docs.aws.amazon.com/AmazonS3/latest/dev/RESTAuthentication.html.
+const signV2Algorithm = "AWS"
+
+// Sub-resources of the query that are signed with Signature V2, sorted as they are signed.
+var signV2Resources = []string{
+	"acl", "delete", "lifecycle", "location", "logging", "notification", "partNumber", "policy",
+	"requestPayment", "response-cache-control", "response-content-disposition", "response-content-encoding",
+	"response-content-language", "response-content-type", "response-expires", "torrent", "uploadId",
+	"uploads", "versionId", "versioning", "versions", "website",
+}
+
+// isSignV2Request - check whether r is signed with Signature V2, in the Authorization header or in the query.
+func isSignV2Request(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), signV2Algorithm+" ") || r.URL.Query().Get("AWSAccessKeyId") != ""
+}
+
+// verifySignatureV2 - verify a request signed with Signature V2 and return its payload.
+// virtualHostBucket is the bucket of virtual-hosted-style requests, which is signed in front of the path.
+func (s *Server) verifySignatureV2(r *http.Request, virtualHostBucket string) ([]byte, apiErrorCode) {
+	if r.Header.Get("Authorization") != "" {
+		return s.verifyHeaderSignatureV2(r, virtualHostBucket)
+	}
+	return s.verifyPresignedSignatureV2(r, virtualHostBucket)
+}
+
+// verifyHeaderSignatureV2 - verify a request signed with an Authorization header of the form AWS accessKey:signature.
+func (s *Server) verifyHeaderSignatureV2(r *http.Request, virtualHostBucket string) ([]byte, apiErrorCode) {
+	keySignature := strings.SplitN(strings.TrimPrefix(r.Header.Get("Authorization"), signV2Algorithm+" "), ":", 2)
+	if len(keySignature) != 2 || keySignature[0] == "" || keySignature[1] == "" {
+		return nil, errAuthorizationHeaderMalformed
+	}
+	accessKey, signature := keySignature[0], keySignature[1]
+	secretKey, ok := s.secretKeyOf(accessKey)
+	if !ok {
+		return nil, errInvalidAccessKeyID
+	}
+	if errCode := s.verifySessionToken(accessKey, r.Header.Get("X-Amz-Security-Token")); errCode != errNone {
+		return nil, errCode
+	}
+	// X-Amz-Date takes the place of the Date header, which is then signed as empty.
+	date := r.Header.Get("Date")
+	dateHeader := date
+	if amzDate := r.Header.Get("X-Amz-Date"); amzDate != "" {
+		date, dateHeader = "", amzDate
+	}
+	t, err := http.ParseTime(dateHeader)
+	if err != nil {
+		return nil, errMissingDateHeader
+	}
+	if skew := time.Now().UTC().Sub(t); skew > maxRequestSkew || skew < -maxRequestSkew {
+		return nil, errRequestTimeTooSkewed
+	}
+	expected := sumHMACSHA1([]byte(secretKey), []byte(getStringToSignV2(r, virtualHostBucket, date)))
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, errSignatureDoesNotMatch
+	}
+	return readPayload(r, r.Header.Get("X-Amz-Content-Sha256"))
+}
+
+// verifyPresignedSignatureV2 - verify a request presigned with the AWSAccessKeyId, Expires and Signature query parameters.
+func (s *Server) verifyPresignedSignatureV2(r *http.Request, virtualHostBucket string) ([]byte, apiErrorCode) {
+	query := r.URL.Query()
+	for _, key := range []string{"AWSAccessKeyId", "Expires", "Signature"} {
+		if query.Get(key) == "" {
+			return nil, errAuthorizationQueryParametersError
+		}
+	}
+	accessKey := query.Get("AWSAccessKeyId")
+	secretKey, ok := s.secretKeyOf(accessKey)
+	if !ok {
+		return nil, errInvalidAccessKeyID
+	}
+	if errCode := s.verifySessionToken(accessKey, query.Get("x-amz-security-token")); errCode != errNone {
+		return nil, errCode
+	}
+	expires, err := strconv.ParseInt(query.Get("Expires"), 10, 64)
+	if err != nil {
+		return nil, errAuthorizationQueryParametersError
+	}
+	if time.Now().UTC().Unix() > expires {
+		return nil, errExpiredPresignRequest
+	}
+	expected := sumHMACSHA1([]byte(secretKey), []byte(getStringToSignV2(r, virtualHostBucket, query.Get("Expires"))))
+	if !hmac.Equal([]byte(query.Get("Signature")), []byte(expected)) {
+		return nil, errSignatureDoesNotMatch
+	}
+	return readPayload(r, "")
+}
+
+// verifyPostSignatureV2 - verify the signature of the policy of a POST policy upload signed with Signature V2.
+func (s *Server) verifyPostSignatureV2(form map[string]string) apiErrorCode {
+	accessKey := form["awsaccesskeyid"]
+	secretKey, ok := s.secretKeyOf(accessKey)
+	if !ok {
+		return errInvalidAccessKeyID
+	}
+	if errCode := s.verifySessionToken(accessKey, form["x-amz-security-token"]); errCode != errNone {
+		return errCode
+	}
+	expected := sumHMACSHA1([]byte(secretKey), []byte(form["policy"]))
+	if !hmac.Equal([]byte(form["signature"]), []byte(expected)) {
+		return errSignatureDoesNotMatch
+	}
+	return errNone
+}
+
+// getStringToSignV2 - the string to sign of r, with date being the Date header or the expiry of presigned requests.
+func getStringToSignV2(r *http.Request, virtualHostBucket, date string) string {
+	return strings.Join([]string{
+		r.Method,
+		r.Header.Get("Content-MD5"),
+		r.Header.Get("Content-Type"),
+		date,
+		getCanonicalizedAmzHeaders(r) + getCanonicalizedResource(r, virtualHostBucket),
+	}, "\n")
+}
+
+// getCanonicalizedAmzHeaders - the x-amz- headers of r, and the x-amz- parameters of the query
+// of presigned requests, lower cased and sorted as name:value lines.
+func getCanonicalizedAmzHeaders(r *http.Request) string {
+	values := make(map[string][]string)
+	for header, headerValues := range r.Header {
+		if header = strings.ToLower(header); strings.HasPrefix(header, "x-amz-") {
+			values[header] = append(values[header], headerValues...)
+		}
+	}
+	for key, queryValues := range r.URL.Query() {
+		if key = strings.ToLower(key); strings.HasPrefix(key, "x-amz-") {
+			values[key] = append(values[key], queryValues...)
+		}
+	}
+	names := []string{}
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf bytes.Buffer
+	for _, name := range names {
+		trimmed := make([]string, len(values[name]))
+		for i, value := range values[name] {
+			trimmed[i] = strings.TrimSpace(value)
+		}
+		buf.WriteString(name + ":" + strings.Join(trimmed, ",") + "\n")
+	}
+	return buf.String()
+}
+
+// getCanonicalizedResource - the path of r, after the bucket of virtual-hosted-style requests,
+// followed by the signed sub-resources of its query.
+func getCanonicalizedResource(r *http.Request, virtualHostBucket string) string {
+	resource := uriEncode(r.URL.Path, false)
+	if virtualHostBucket != "" {
+		resource = "/" + virtualHostBucket + resource
+	}
+	query := r.URL.Query()
+	sep := "?"
+	for _, name := range signV2Resources {
+		values, ok := query[name]
+		if !ok {
+			continue
+		}
+		resource += sep + name
+		if len(values) > 0 && values[0] != "" {
+			resource += "=" + values[0]
+		}
+		sep = "&"
+	}
+	return resource
+}
+
+// sumHMACSHA1 - the base64 encoded HMAC-SHA1 of data.
+func sumHMACSHA1(key []byte, data []byte) string {
+	hash := hmac.New(sha1.New, key)
+	hash.Write(data)
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
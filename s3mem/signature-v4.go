@@ -0,0 +1,676 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature V4 constants, see
+// http://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html.
+const (
+	signV4Algorithm   = "AWS4-HMAC-SHA256"
+	iso8601DateFormat = "20060102T150405Z"
+	yyyymmdd          = "20060102"
+	unsignedPayload   = "UNSIGNED-PAYLOAD"
+	streamingPayload  = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
+	emptySHA256       = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+	// Payloads sent in aws-chunked encoding followed by trailing headers, see
+	// http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming-trailers.html.
+	streamingPayloadTrailer         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	streamingUnsignedPayloadTrailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+
+	// Requests signed further away from the server time than this are rejected.
+	maxRequestSkew = 15 * time.Minute
+	// Presigned URLs can not be valid for longer than a week.
+	maxPresignExpiry = 7 * 24 * 60 * 60
+)
+
+// credentialScope - the parsed form of accessKey/date/region/service/aws4_request, or of
+// accessKey/date/service/aws4_request for SigV4A, whose signatures are valid in the
+// regions of regionSet instead.
+type credentialScope struct {
+	algorithm string
+	accessKey string
+	date      string
+	region    string
+	service   string
+	regionSet string
+}
+
+// String - the scope of the credential as used in the string to sign.
+func (c credentialScope) String() string {
+	if c.algorithm == signV4AAlgorithm {
+		return strings.Join([]string{c.date, c.service, "aws4_request"}, "/")
+	}
+	return strings.Join([]string{c.date, c.region, c.service, "aws4_request"}, "/")
+}
+
+// signV4Values - the values of a signed request.
+type signV4Values struct {
+	credential    credentialScope
+	signedHeaders []string
+	signature     string
+}
+
+// authenticate - verify the signature of r and return the payload it signed.
+// Requests without a signature are only allowed if a bucket policy allows them. virtualHostBucket
+// is the bucket of virtual-hosted-style requests, empty for path style requests.
+func (s *Server) authenticate(r *http.Request, virtualHostBucket, bucketName, objectName string) ([]byte, apiErrorCode) {
+	var payload []byte
+	var errCode apiErrorCode
+	switch {
+	case isSignV2Request(r):
+		payload, errCode = s.verifySignatureV2(r, virtualHostBucket)
+	case r.Header.Get("Authorization") != "":
+		payload, errCode = s.verifyHeaderSignature(r)
+	case r.URL.Query().Get("X-Amz-Algorithm") != "":
+		payload, errCode = s.verifyPresignedSignature(r)
+	default:
+		if !s.anonymousAllowed(r, bucketName, objectName) {
+			return nil, errAccessDenied
+		}
+		payload, errCode = s.readAnonymousPayload(r)
+	}
+	if errCode != errNone {
+		return nil, errCode
+	}
+	if errCode := verifyContentMD5(r.Header, payload); errCode != errNone {
+		return nil, errCode
+	}
+	return payload, verifyChecksum(r.Header, payload)
+}
+
+// readAnonymousPayload - read the body of a request without a signature.
+func (s *Server) readAnonymousPayload(r *http.Request) ([]byte, apiErrorCode) {
+	hashedPayload := r.Header.Get("X-Amz-Content-Sha256")
+	if hashedPayload == streamingUnsignedPayloadTrailer {
+		return s.readStreamingPayload(r, credentialScope{}, time.Time{}, "", true)
+	}
+	return readPayload(r, hashedPayload)
+}
+
+// verifyHeaderSignature - verify a request signed with the Authorization header.
+func (s *Server) verifyHeaderSignature(r *http.Request) ([]byte, apiErrorCode) {
+	values, errCode := parseAuthorization(r.Header.Get("Authorization"))
+	if errCode != errNone {
+		return nil, errCode
+	}
+	values.credential.regionSet = r.Header.Get("X-Amz-Region-Set")
+	t, errCode := requestDate(r)
+	if errCode != errNone {
+		return nil, errCode
+	}
+	if errCode := s.verifyScope(values.credential, t); errCode != errNone {
+		return nil, errCode
+	}
+	if errCode := s.verifySessionToken(values.credential.accessKey, r.Header.Get("X-Amz-Security-Token")); errCode != errNone {
+		return nil, errCode
+	}
+	if skew := time.Now().UTC().Sub(t); skew > maxRequestSkew || skew < -maxRequestSkew {
+		return nil, errRequestTimeTooSkewed
+	}
+	hashedPayload := r.Header.Get("X-Amz-Content-Sha256")
+	if hashedPayload == "" {
+		return nil, errMissingContentSHA256
+	}
+	if errCode := verifySignedHeaders(r, values.signedHeaders); errCode != errNone {
+		return nil, errCode
+	}
+	canonicalRequest := getCanonicalRequest(r, values.signedHeaders, hashedPayload)
+	if !s.checkSignature(values.credential, t, getStringToSign(canonicalRequest, t, values.credential), values.signature) {
+		return nil, errSignatureDoesNotMatch
+	}
+	// The chunks of SigV4A streaming uploads are signed with ECDSA as well, they are not supported.
+	if values.credential.algorithm == signV4AAlgorithm && (hashedPayload == streamingPayload || hashedPayload == streamingPayloadTrailer) {
+		return nil, errNotImplemented
+	}
+	switch hashedPayload {
+	case streamingPayload:
+		return s.readStreamingPayload(r, values.credential, t, values.signature, false)
+	case streamingPayloadTrailer:
+		return s.readStreamingPayload(r, values.credential, t, values.signature, true)
+	case streamingUnsignedPayloadTrailer:
+		return s.readStreamingPayload(r, values.credential, t, "", true)
+	}
+	return readPayload(r, hashedPayload)
+}
+
+// verifyPresignedSignature - verify a request presigned with query parameters.
+func (s *Server) verifyPresignedSignature(r *http.Request) ([]byte, apiErrorCode) {
+	query := r.URL.Query()
+	for _, key := range []string{"X-Amz-Credential", "X-Amz-Date", "X-Amz-Expires", "X-Amz-SignedHeaders", "X-Amz-Signature"} {
+		if query.Get(key) == "" {
+			return nil, errAuthorizationQueryParametersError
+		}
+	}
+	algorithm := query.Get("X-Amz-Algorithm")
+	if algorithm != signV4Algorithm && algorithm != signV4AAlgorithm {
+		return nil, errAuthorizationQueryParametersError
+	}
+	credential, errCode := parseCredential(query.Get("X-Amz-Credential"), algorithm)
+	if errCode != errNone {
+		return nil, errCode
+	}
+	credential.regionSet = query.Get("X-Amz-Region-Set")
+	t, err := time.Parse(iso8601DateFormat, query.Get("X-Amz-Date"))
+	if err != nil {
+		return nil, errAuthorizationQueryParametersError
+	}
+	expires, err := strconv.ParseInt(query.Get("X-Amz-Expires"), 10, 64)
+	if err != nil || expires < 0 {
+		return nil, errAuthorizationQueryParametersError
+	}
+	if expires > maxPresignExpiry {
+		return nil, errPresignExpiresTooLarge
+	}
+	if errCode := s.verifyScope(credential, t); errCode != errNone {
+		return nil, errCode
+	}
+	if errCode := s.verifySessionToken(credential.accessKey, query.Get("X-Amz-Security-Token")); errCode != errNone {
+		return nil, errCode
+	}
+	if time.Now().UTC().After(t.Add(time.Duration(expires) * time.Second)) {
+		return nil, errExpiredPresignRequest
+	}
+	signedHeaders := strings.Split(query.Get("X-Amz-SignedHeaders"), ";")
+	if errCode := verifySignedHeaders(r, signedHeaders); errCode != errNone {
+		return nil, errCode
+	}
+	hashedPayload := query.Get("X-Amz-Content-Sha256")
+	if hashedPayload == "" {
+		hashedPayload = unsignedPayload
+	}
+	canonicalRequest := getCanonicalRequest(r, signedHeaders, hashedPayload)
+	if !s.checkSignature(credential, t, getStringToSign(canonicalRequest, t, credential), query.Get("X-Amz-Signature")) {
+		return nil, errSignatureDoesNotMatch
+	}
+	return readPayload(r, hashedPayload)
+}
+
+// verifyPostSignature - verify the signature of the policy of a POST policy upload.
+func (s *Server) verifyPostSignature(form map[string]string) apiErrorCode {
+	if form["awsaccesskeyid"] != "" {
+		return s.verifyPostSignatureV2(form)
+	}
+	if form["x-amz-algorithm"] != signV4Algorithm {
+		return errAccessDenied
+	}
+	credential, errCode := parseCredential(form["x-amz-credential"], signV4Algorithm)
+	if errCode != errNone {
+		return errCode
+	}
+	t, err := time.Parse(iso8601DateFormat, form["x-amz-date"])
+	if err != nil {
+		return errMalformedPOSTRequest
+	}
+	if errCode := s.verifyScope(credential, t); errCode != errNone {
+		return errCode
+	}
+	if errCode := s.verifySessionToken(credential.accessKey, form["x-amz-security-token"]); errCode != errNone {
+		return errCode
+	}
+	signature := s.getSignature(credential, t, form["policy"])
+	if !hmac.Equal([]byte(signature), []byte(form["x-amz-signature"])) {
+		return errSignatureDoesNotMatch
+	}
+	return errNone
+}
+
+// verifyScope - verify that a credential was issued for this server, SigV4A credentials
+// for a region set that includes the region of the server.
+func (s *Server) verifyScope(credential credentialScope, t time.Time) apiErrorCode {
+	if credential.service != "s3" || credential.date != t.Format(yyyymmdd) {
+		return errAuthorizationHeaderMalformed
+	}
+	if credential.algorithm == signV4AAlgorithm && !inRegionSet(s.region, credential.regionSet) {
+		return errAuthorizationHeaderMalformed
+	}
+	if credential.algorithm != signV4AAlgorithm && credential.region != s.region {
+		return errAuthorizationHeaderMalformed
+	}
+	if _, ok := s.secretKeyOf(credential.accessKey); !ok {
+		return errInvalidAccessKeyID
+	}
+	return errNone
+}
+
+// secretKeyOf - the secret key of accessKey, false if the access key is unknown.
+func (s *Server) secretKeyOf(accessKey string) (string, bool) {
+	if accessKey == s.accessKey {
+		return s.secretKey, true
+	}
+	temporary, ok := s.temporary[accessKey]
+	return temporary.secretKey, ok
+}
+
+// verifySessionToken - verify that temporary credentials are used along with their session token
+// before they expire. Long term credentials are not used with a session token.
+func (s *Server) verifySessionToken(accessKey, sessionToken string) apiErrorCode {
+	temporary, ok := s.temporary[accessKey]
+	switch {
+	case !ok && sessionToken != "":
+		return errInvalidToken
+	case !ok:
+		return errNone
+	case sessionToken == "":
+		// Without the token the access key is not known to AWS S3.
+		return errInvalidAccessKeyID
+	case sessionToken != temporary.sessionToken:
+		return errInvalidToken
+	case time.Now().After(temporary.expires):
+		return errExpiredToken
+	}
+	return errNone
+}
+
+// getSignature - sign stringToSign with the key derived for credential.
+func (s *Server) getSignature(credential credentialScope, t time.Time, stringToSign string) string {
+	secretKey, _ := s.secretKeyOf(credential.accessKey)
+	key := sumHMAC([]byte("AWS4"+secretKey), []byte(t.Format(yyyymmdd)))
+	key = sumHMAC(key, []byte(credential.region))
+	key = sumHMAC(key, []byte(credential.service))
+	key = sumHMAC(key, []byte("aws4_request"))
+	return hex.EncodeToString(sumHMAC(key, []byte(stringToSign)))
+}
+
+// checkSignature - check the signature of stringToSign, an HMAC with the key derived for
+// credential or an ECDSA signature with the key of the access key pair for SigV4A.
+func (s *Server) checkSignature(credential credentialScope, t time.Time, stringToSign, signature string) bool {
+	if credential.algorithm == signV4AAlgorithm {
+		secretKey, _ := s.secretKeyOf(credential.accessKey)
+		return verifySignatureV4A(credential.accessKey, secretKey, stringToSign, signature)
+	}
+	return hmac.Equal([]byte(s.getSignature(credential, t, stringToSign)), []byte(signature))
+}
+
+// parseAuthorization - parse an Authorization header of the form
+// AWS4-HMAC-SHA256 Credential=..., SignedHeaders=..., Signature=...
+// or its AWS4-ECDSA-P256-SHA256 form for SigV4A.
+func parseAuthorization(auth string) (signV4Values, apiErrorCode) {
+	algorithm := strings.SplitN(auth, " ", 2)[0]
+	if algorithm != signV4Algorithm && algorithm != signV4AAlgorithm {
+		return signV4Values{}, errAuthorizationHeaderMalformed
+	}
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.TrimPrefix(auth, algorithm+" "), ",") {
+		keyValue := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(keyValue) != 2 {
+			return signV4Values{}, errAuthorizationHeaderMalformed
+		}
+		fields[keyValue[0]] = keyValue[1]
+	}
+	if len(fields) != 3 || fields["SignedHeaders"] == "" || fields["Signature"] == "" {
+		return signV4Values{}, errAuthorizationHeaderMalformed
+	}
+	credential, errCode := parseCredential(fields["Credential"], algorithm)
+	if errCode != errNone {
+		return signV4Values{}, errCode
+	}
+	return signV4Values{
+		credential:    credential,
+		signedHeaders: strings.Split(fields["SignedHeaders"], ";"),
+		signature:     fields["Signature"],
+	}, errNone
+}
+
+// parseCredential - parse a credential of the form accessKey/date/region/service/aws4_request,
+// without region if signed with SigV4A.
+func parseCredential(credential, algorithm string) (credentialScope, apiErrorCode) {
+	parts := strings.Split(credential, "/")
+	if algorithm == signV4AAlgorithm && len(parts) == 4 {
+		// Keep the positions of the region scope.
+		parts = []string{parts[0], parts[1], "", parts[2], parts[3]}
+	}
+	if len(parts) != 5 || parts[4] != "aws4_request" {
+		return credentialScope{}, errAuthorizationHeaderMalformed
+	}
+	if (algorithm == signV4AAlgorithm) != (parts[2] == "") {
+		return credentialScope{}, errAuthorizationHeaderMalformed
+	}
+	return credentialScope{
+		algorithm: algorithm,
+		accessKey: parts[0],
+		date:      parts[1],
+		region:    parts[2],
+		service:   parts[3],
+	}, errNone
+}
+
+// requestDate - the time a request signed with the Authorization header was signed at.
+func requestDate(r *http.Request) (time.Time, apiErrorCode) {
+	if amzDate := r.Header.Get("X-Amz-Date"); amzDate != "" {
+		t, err := time.Parse(iso8601DateFormat, amzDate)
+		if err != nil {
+			return time.Time{}, errMissingDateHeader
+		}
+		return t, errNone
+	}
+	t, err := http.ParseTime(r.Header.Get("Date"))
+	if err != nil {
+		return time.Time{}, errMissingDateHeader
+	}
+	return t.UTC(), errNone
+}
+
+// verifySignedHeaders - verify that host and every x-amz- header sent were signed.
+func verifySignedHeaders(r *http.Request, signedHeaders []string) apiErrorCode {
+	signed := make(map[string]bool)
+	for _, header := range signedHeaders {
+		signed[header] = true
+	}
+	if !signed["host"] {
+		return errUnsignedHeaders
+	}
+	for header := range r.Header {
+		header = strings.ToLower(header)
+		if strings.HasPrefix(header, "x-amz-") && !signed[header] {
+			return errUnsignedHeaders
+		}
+	}
+	return errNone
+}
+
+// getCanonicalRequest - the canonical form of r as it was signed.
+func getCanonicalRequest(r *http.Request, signedHeaders []string, hashedPayload string) string {
+	return strings.Join([]string{
+		r.Method,
+		uriEncode(r.URL.Path, false),
+		getCanonicalQuery(r),
+		getCanonicalHeaders(r, signedHeaders),
+		strings.Join(signedHeaders, ";"),
+		hashedPayload,
+	}, "\n")
+}
+
+// getCanonicalQuery - the sorted, encoded query of r without the presigned signature.
+func getCanonicalQuery(r *http.Request) string {
+	query := r.URL.Query()
+	keys := []string{}
+	for key := range query {
+		if key != "X-Amz-Signature" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	pairs := []string{}
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// getCanonicalHeaders - the signed headers of r as name:value lines, with
+// the values trimmed and sequential spaces folded into one.
+func getCanonicalHeaders(r *http.Request, signedHeaders []string) string {
+	var buf bytes.Buffer
+	for _, header := range signedHeaders {
+		var values []string
+		switch header {
+		case "host":
+			values = []string{r.Host}
+		case "content-length":
+			values = []string{strconv.FormatInt(r.ContentLength, 10)}
+		default:
+			values = r.Header[http.CanonicalHeaderKey(header)]
+		}
+		folded := make([]string, len(values))
+		for i, value := range values {
+			folded[i] = strings.Join(strings.Fields(value), " ")
+		}
+		buf.WriteString(header + ":" + strings.Join(folded, ",") + "\n")
+	}
+	return buf.String()
+}
+
+// getStringToSign - the string to sign for a canonical request.
+func getStringToSign(canonicalRequest string, t time.Time, credential credentialScope) string {
+	return strings.Join([]string{
+		credential.algorithm,
+		t.Format(iso8601DateFormat),
+		credential.String(),
+		hex.EncodeToString(sum256([]byte(canonicalRequest))),
+	}, "\n")
+}
+
+// readPayload - read the body of r and verify it against its signed SHA256 sum.
+func readPayload(r *http.Request, hashedPayload string) ([]byte, apiErrorCode) {
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, errIncompleteBody
+	}
+	if hashedPayload == "" || hashedPayload == unsignedPayload {
+		return payload, errNone
+	}
+	if hex.EncodeToString(sum256(payload)) != hashedPayload {
+		return nil, errContentSHA256Mismatch
+	}
+	return payload, errNone
+}
+
+// readStreamingPayload - decode an aws-chunked body, verifying the signature
+// of every chunk, see http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html.
+// The chunks of STREAMING-UNSIGNED-PAYLOAD-TRAILER bodies carry no signature,
+// seedSignature is empty for them. Bodies with a trailer end with trailing headers,
+// they are added to the headers of r once verified.
+func (s *Server) readStreamingPayload(r *http.Request, credential credentialScope, t time.Time, seedSignature string, hasTrailer bool) ([]byte, apiErrorCode) {
+	decodedLength, err := strconv.ParseInt(r.Header.Get("X-Amz-Decoded-Content-Length"), 10, 64)
+	if err != nil || decodedLength < 0 {
+		return nil, errMissingContentLength
+	}
+	signed := seedSignature != ""
+	reader := bufio.NewReader(r.Body)
+	previousSignature := seedSignature
+	var payload []byte
+	for {
+		// Every chunk starts with hex(size);chunk-signature=signature\r\n,
+		// or with hex(size)\r\n if it is not signed.
+		line, err := reader.ReadString('\n')
+		if err != nil || !strings.HasSuffix(line, "\r\n") {
+			return nil, errIncompleteBody
+		}
+		header := []string{strings.TrimSuffix(line, "\r\n")}
+		if signed {
+			header = strings.SplitN(header[0], ";chunk-signature=", 2)
+			if len(header) != 2 {
+				return nil, errIncompleteBody
+			}
+		}
+		size, err := strconv.ParseInt(header[0], 16, 64)
+		if err != nil || size < 0 || int64(len(payload))+size > decodedLength {
+			return nil, errIncompleteBody
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return nil, errIncompleteBody
+		}
+		// The final chunk is always empty, the trailing headers follow it
+		// directly, every other chunk ends with \r\n.
+		if size > 0 || !hasTrailer {
+			crlf := make([]byte, 2)
+			if _, err := io.ReadFull(reader, crlf); err != nil || string(crlf) != "\r\n" {
+				return nil, errIncompleteBody
+			}
+		}
+		if signed {
+			stringToSign := strings.Join([]string{
+				"AWS4-HMAC-SHA256-PAYLOAD",
+				t.Format(iso8601DateFormat),
+				credential.String(),
+				previousSignature,
+				emptySHA256,
+				hex.EncodeToString(sum256(chunk)),
+			}, "\n")
+			signature := s.getSignature(credential, t, stringToSign)
+			if !hmac.Equal([]byte(signature), []byte(header[1])) {
+				return nil, errSignatureDoesNotMatch
+			}
+			previousSignature = signature
+		}
+		payload = append(payload, chunk...)
+		if size == 0 {
+			break
+		}
+	}
+	if int64(len(payload)) != decodedLength {
+		return nil, errIncompleteBody
+	}
+	if !hasTrailer {
+		return payload, errNone
+	}
+	trailer, errCode := s.readTrailer(r, reader, credential, t, previousSignature)
+	if errCode != errNone {
+		return nil, errCode
+	}
+	for name, values := range trailer {
+		r.Header[name] = values
+	}
+	return payload, errNone
+}
+
+// readTrailer - read the trailing headers of an aws-chunked body, one name:value\r\n line
+// per header ended by an empty line. The headers must be the ones named by the X-Amz-Trailer
+// header of r. Unless the chunks were not signed, the trailing headers are signed as well,
+// chained to the signature of the final chunk.
+func (s *Server) readTrailer(r *http.Request, reader *bufio.Reader, credential credentialScope, t time.Time, previousSignature string) (http.Header, apiErrorCode) {
+	declared := make(map[string]bool)
+	for _, name := range strings.Split(r.Header.Get("X-Amz-Trailer"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			declared[http.CanonicalHeaderKey(name)] = true
+		}
+	}
+	trailer := make(http.Header)
+	var canonicalTrailer, trailerSignature string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil || !strings.HasSuffix(line, "\r\n") {
+			return nil, errIncompleteBody
+		}
+		line = strings.TrimSuffix(line, "\r\n")
+		if line == "" {
+			break
+		}
+		nameValue := strings.SplitN(line, ":", 2)
+		if len(nameValue) != 2 {
+			return nil, errMalformedTrailer
+		}
+		name, value := strings.ToLower(strings.TrimSpace(nameValue[0])), strings.TrimSpace(nameValue[1])
+		if name == "x-amz-trailer-signature" {
+			trailerSignature = value
+			continue
+		}
+		if !declared[http.CanonicalHeaderKey(name)] {
+			return nil, errMalformedTrailer
+		}
+		trailer.Set(name, value)
+		canonicalTrailer += name + ":" + value + "\n"
+	}
+	if len(trailer) != len(declared) {
+		return nil, errMalformedTrailer
+	}
+	if previousSignature == "" {
+		return trailer, errNone
+	}
+	if trailerSignature == "" {
+		return nil, errMalformedTrailer
+	}
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256-TRAILER",
+		t.Format(iso8601DateFormat),
+		credential.String(),
+		previousSignature,
+		hex.EncodeToString(sum256([]byte(canonicalTrailer))),
+	}, "\n")
+	if !hmac.Equal([]byte(s.getSignature(credential, t, stringToSign)), []byte(trailerSignature)) {
+		return nil, errSignatureDoesNotMatch
+	}
+	return trailer, errNone
+}
+
+// verifyContentMD5 - verify the payload against the Content-MD5 header, if one was sent.
+func verifyContentMD5(header http.Header, payload []byte) apiErrorCode {
+	if _, ok := header["Content-Md5"]; !ok {
+		return errNone
+	}
+	md5Sum, err := base64.StdEncoding.DecodeString(header.Get("Content-Md5"))
+	if err != nil || len(md5Sum) != md5.Size {
+		return errInvalidDigest
+	}
+	if !bytes.Equal(md5Sum, sumMD5(payload)) {
+		return errBadDigest
+	}
+	return errNone
+}
+
+// uriEncode - encode s the way AWS does in canonical requests, every byte
+// but the unreserved characters is percent encoded. Slashes are kept when
+// encoding paths.
+func uriEncode(s string, encodeSlash bool) string {
+	var buf bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			buf.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			buf.WriteByte(c)
+		default:
+			buf.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return buf.String()
+}
+
+// sum256 - the SHA256 sum of data.
+func sum256(data []byte) []byte {
+	hash := sha256.New()
+	hash.Write(data)
+	return hash.Sum(nil)
+}
+
+// sumMD5 - the MD5 sum of data.
+func sumMD5(data []byte) []byte {
+	hash := md5.New()
+	hash.Write(data)
+	return hash.Sum(nil)
+}
+
+// sumHMAC - the HMAC-SHA256 of data with key.
+func sumHMAC(key []byte, data []byte) []byte {
+	hash := hmac.New(sha256.New, key)
+	hash.Write(data)
+	return hash.Sum(nil)
+}
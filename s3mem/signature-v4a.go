@@ -0,0 +1,94 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package s3mem
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/asn1"
+	"encoding/hex"
+	"math/big"
+	"strings"
+)
+
+// SigV4A signs with an ECDSA P-256 key derived from the secret key, for
+// every region of the X-Amz-Region-Set header rather than a single one.
+const signV4AAlgorithm = "AWS4-ECDSA-P256-SHA256"
+
+// ecdsaSignature - the ASN.1 form of an ECDSA signature.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// deriveKeyV4A - the public ECDSA P-256 key of an access key pair, derived the way the
+// AWS SDKs derive the private key: the first HMAC-SHA256 candidate of the counter mode
+// KDF of NIST SP 800-108 smaller than the order of the curve minus two, plus one.
+func deriveKeyV4A(accessKey, secretKey string) *ecdsa.PublicKey {
+	curve := elliptic.P256()
+	nMinusTwo := new(big.Int).Sub(curve.Params().N, big.NewInt(2))
+	for counter := 1; counter <= 0xFF; counter++ {
+		fixedInput := []byte{0, 0, 0, 1}
+		fixedInput = append(fixedInput, signV4AAlgorithm...)
+		fixedInput = append(fixedInput, 0)
+		fixedInput = append(fixedInput, accessKey...)
+		fixedInput = append(fixedInput, byte(counter), 0, 0, 1, 0)
+		candidate := new(big.Int).SetBytes(sumHMAC([]byte("AWS4A"+secretKey), fixedInput))
+		if candidate.Cmp(nMinusTwo) >= 0 {
+			continue
+		}
+		key := &ecdsa.PublicKey{Curve: curve}
+		key.X, key.Y = curve.ScalarBaseMult(candidate.Add(candidate, big.NewInt(1)).Bytes())
+		return key
+	}
+	return nil
+}
+
+// verifySignatureV4A - verify the hexadecimal ASN.1 ECDSA signature of stringToSign
+// against the key derived from the access key pair.
+func verifySignatureV4A(accessKey, secretKey, stringToSign, signature string) bool {
+	key := deriveKeyV4A(accessKey, secretKey)
+	if key == nil {
+		return false
+	}
+	der, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	var sig ecdsaSignature
+	if rest, err := asn1.Unmarshal(der, &sig); err != nil || len(rest) != 0 {
+		return false
+	}
+	if sig.R == nil || sig.S == nil || sig.R.Sign() <= 0 || sig.S.Sign() <= 0 {
+		return false
+	}
+	return ecdsa.Verify(key, sum256([]byte(stringToSign)), sig.R, sig.S)
+}
+
+// inRegionSet - check whether region is one of the comma separated regions of regionSet,
+// which may end with the * wildcard, such as us-* or * for every region.
+func inRegionSet(region, regionSet string) bool {
+	for _, pattern := range strings.Split(regionSet, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == region {
+			return true
+		}
+		if strings.HasSuffix(pattern, "*") && strings.HasPrefix(region, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
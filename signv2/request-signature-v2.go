@@ -0,0 +1,270 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package signv2 signs requests with AWS Signature Version 2, which
+// legacy S3 clients still use, see
+// http://docs.aws.amazon.com/AmazonS3/latest/dev/RESTAuthentication.html.
+// The bucket of virtual-hosted-style requests is passed along with them, as
+// it is signed as part of the resource even though it is not in the path.
+package signv2
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Signature and API related constants.
+const (
+	signV2Algorithm = "AWS"
+)
+
+// Sub-resources of the query that are part of the canonicalized resource, any
+// other query parameter is left out of the signature. Sorted as they must be signed.
+var resourceList = []string{
+	"acl",
+	"delete",
+	"lifecycle",
+	"location",
+	"logging",
+	"notification",
+	"partNumber",
+	"policy",
+	"requestPayment",
+	"response-cache-control",
+	"response-content-disposition",
+	"response-content-encoding",
+	"response-content-language",
+	"response-content-type",
+	"response-expires",
+	"torrent",
+	"uploadId",
+	"uploads",
+	"versionId",
+	"versioning",
+	"versions",
+	"website",
+}
+
+// sumHMAC calculate the hmac-sha1 of data, base64 encoded.
+func sumHMAC(key []byte, data []byte) string {
+	hash := hmac.New(sha1.New, key)
+	hash.Write(data)
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
+
+// PreSignV2 presign the request, in accordance with
+// http://docs.aws.amazon.com/AmazonS3/latest/dev/RESTAuthentication.html#RESTAuthenticationQueryStringAuth.
+// The session token of temporary credentials is signed as an x-amz-security-token
+// query parameter, it is left out if sessionToken is empty. virtualHostBucket is the bucket
+// addressed by the host name of virtual-hosted-style requests, empty for path style requests.
+func PreSignV2(req http.Request, accessKeyID, secretAccessKey, sessionToken, virtualHostBucket string, expires int64) *http.Request {
+	return preSignV2(req, accessKeyID, secretAccessKey, sessionToken, virtualHostBucket, time.Now().UTC().Unix()+expires)
+}
+
+// preSignV2 presign the request to expire at epochExpires, in seconds since the Unix epoch.
+func preSignV2(req http.Request, accessKeyID, secretAccessKey, sessionToken, virtualHostBucket string, epochExpires int64) *http.Request {
+	// Presign is not needed for anonymous credentials.
+	if accessKeyID == "" || secretAccessKey == "" {
+		return &req
+	}
+	expires := strconv.FormatInt(epochExpires, 10)
+
+	// The session token is signed like a header, but sent in the query.
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	// Get string to sign, with the expiry in place of the date.
+	stringToSign := getStringToSignV2(req, virtualHostBucket, expires)
+
+	// Calculate signature.
+	signature := sumHMAC([]byte(secretAccessKey), []byte(stringToSign))
+
+	// Set URL query.
+	query := req.URL.Query()
+	query.Set("AWSAccessKeyId", accessKeyID)
+	query.Set("Expires", expires)
+	query.Set("Signature", signature)
+	if sessionToken != "" {
+		req.Header.Del("X-Amz-Security-Token")
+		query.Set("x-amz-security-token", sessionToken)
+	}
+	req.URL.RawQuery = strings.Replace(query.Encode(), "+", "%20", -1)
+
+	return &req
+}
+
+// PostPresignSignatureV2 - presigned signature for PostPolicy requests, see
+// http://docs.aws.amazon.com/AmazonS3/latest/dev/HTTPPOSTForms.html. The session
+// token of temporary credentials must be sent in the x-amz-security-token form
+// field and be matched by a condition of the policy.
+func PostPresignSignatureV2(policyBase64, secretAccessKey string) string {
+	return sumHMAC([]byte(secretAccessKey), []byte(policyBase64))
+}
+
+// SignV2 sign the request before Do(), in accordance with
+// http://docs.aws.amazon.com/AmazonS3/latest/dev/RESTAuthentication.html.
+// The session token of temporary credentials is signed as the
+// X-Amz-Security-Token header, it is left out if sessionToken is empty. virtualHostBucket is the
+// bucket addressed by the host name of virtual-hosted-style requests, empty for path style requests.
+func SignV2(req http.Request, accessKeyID, secretAccessKey, sessionToken, virtualHostBucket string) *http.Request {
+	return signV2(req, accessKeyID, secretAccessKey, sessionToken, virtualHostBucket, time.Now().UTC())
+}
+
+// signV2 sign the request as sent at t.
+func signV2(req http.Request, accessKeyID, secretAccessKey, sessionToken, virtualHostBucket string, t time.Time) *http.Request {
+	// Signature calculation is not needed for anonymous credentials.
+	if accessKeyID == "" || secretAccessKey == "" {
+		return &req
+	}
+
+	// Set date, Signature Version 2 signs the Date header instead of X-Amz-Date.
+	req.Header.Set("Date", t.Format(http.TimeFormat))
+
+	// Set x-amz-security-token.
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	// Get string to sign.
+	stringToSign := getStringToSignV2(req, virtualHostBucket, req.Header.Get("Date"))
+
+	// Calculate signature.
+	signature := sumHMAC([]byte(secretAccessKey), []byte(stringToSign))
+
+	// Set authorization header.
+	req.Header.Set("Authorization", signV2Algorithm+" "+accessKeyID+":"+signature)
+
+	return &req
+}
+
+// getStringToSignV2 - the string to sign of the request, of the form
+//
+//	StringToSign = HTTP-Verb + "\n" +
+//		Content-MD5 + "\n" +
+//		Content-Type + "\n" +
+//		Date + "\n" +
+//		CanonicalizedAmzHeaders +
+//		CanonicalizedResource;
+//
+// where Date is the expiry of presigned requests.
+func getStringToSignV2(req http.Request, virtualHostBucket, date string) string {
+	return strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		date,
+		getCanonicalizedAmzHeaders(req) + getCanonicalizedResource(req, virtualHostBucket),
+	}, "\n")
+}
+
+// getCanonicalizedAmzHeaders - the x-amz- headers of the request, lower cased and
+// sorted, one name:value line each, with the values of repeated headers joined by commas.
+func getCanonicalizedAmzHeaders(req http.Request) string {
+	var headers []string
+	vals := make(map[string][]string)
+	for k, vv := range req.Header {
+		if lk := strings.ToLower(k); strings.HasPrefix(lk, "x-amz-") {
+			headers = append(headers, lk)
+			vals[lk] = vv
+		}
+	}
+	sort.Strings(headers)
+
+	var buf bytes.Buffer
+	for _, k := range headers {
+		buf.WriteString(k)
+		buf.WriteByte(':')
+		for idx, v := range vals[k] {
+			if idx > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(strings.TrimSpace(v))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.String()
+}
+
+// getCanonicalizedResource - the path of the request followed by the sub-resources of its query,
+// with the bucket of virtual-hosted-style requests in front of the path.
+func getCanonicalizedResource(req http.Request, virtualHostBucket string) string {
+	var buf bytes.Buffer
+	if virtualHostBucket != "" {
+		buf.WriteString("/" + virtualHostBucket)
+	}
+	buf.WriteString(urlEncodePath(req.URL.Path))
+	query := req.URL.Query()
+	sep := "?"
+	// resourceList is sorted, so the sub-resources are signed in order.
+	for _, resource := range resourceList {
+		values, ok := query[resource]
+		if !ok {
+			continue
+		}
+		buf.WriteString(sep + resource)
+		if len(values) > 0 && values[0] != "" {
+			buf.WriteString("=" + values[0])
+		}
+		sep = "&"
+	}
+	return buf.String()
+}
+
+// urlEncodePath encode the strings from UTF-8 byte representations to HTML hex escape sequences,
+// the same way package signv4 does.
+func urlEncodePath(pathName string) string {
+	// if object matches reserved string, no need to encode them
+	reservedNames := regexp.MustCompile("^[a-zA-Z0-9-_.~/]+$")
+	if reservedNames.MatchString(pathName) {
+		return pathName
+	}
+	var encodedPathname string
+	for _, s := range pathName {
+		if 'A' <= s && s <= 'Z' || 'a' <= s && s <= 'z' || '0' <= s && s <= '9' { // §2.3 Unreserved characters (mark)
+			encodedPathname = encodedPathname + string(s)
+			continue
+		}
+		switch s {
+		case '-', '_', '.', '~', '/': // §2.3 Unreserved characters (mark)
+			encodedPathname = encodedPathname + string(s)
+			continue
+		default:
+			len := utf8.RuneLen(s)
+			if len < 0 {
+				// if utf8 cannot convert return the same string as is
+				return pathName
+			}
+			u := make([]byte, len)
+			utf8.EncodeRune(u, s)
+			for _, r := range u {
+				hex := hex.EncodeToString([]byte{r})
+				encodedPathname = encodedPathname + "%" + strings.ToUpper(hex)
+			}
+		}
+	}
+	return encodedPathname
+}
@@ -34,8 +34,7 @@ const (
 	signV4Algorithm   = "AWS4-HMAC-SHA256"
 	iso8601DateFormat = "20060102T150405Z"
 	yyyymmdd          = "20060102"
-
-//	testDate          = "20160609T205741Z"
+	s3Service         = "s3"
 )
 
 ///
@@ -86,10 +85,10 @@ var ignoredStreamSignV4Headers = map[string]bool{
 }
 
 // getSigningKey hmac seed to calculate final signature.
-func getSigningKey(secret, loc string, t time.Time) []byte {
+func getSigningKey(secret, loc, svc string, t time.Time) []byte {
 	date := sumHMAC([]byte("AWS4"+secret), []byte(t.Format(yyyymmdd)))
 	location := sumHMAC(date, []byte(loc))
-	service := sumHMAC(location, []byte("s3"))
+	service := sumHMAC(location, []byte(svc))
 	signingKey := sumHMAC(service, []byte("aws4_request"))
 	return signingKey
 }
@@ -101,11 +100,11 @@ func getSignature(signingKey []byte, stringToSign string) string {
 
 // getScope generate a string of a specific date, an AWS region, and a
 // service.
-func getScope(location string, t time.Time) string {
+func getScope(location, service string, t time.Time) string {
 	scope := strings.Join([]string{
 		t.Format(yyyymmdd),
 		location,
-		"s3",
+		service,
 		"aws4_request",
 	}, "/")
 	return scope
@@ -113,7 +112,12 @@ func getScope(location string, t time.Time) string {
 
 // GetCredential generate a credential string.
 func GetCredential(accessKeyID, location string, t time.Time) string {
-	scope := getScope(location, t)
+	return getCredential(accessKeyID, location, s3Service, t)
+}
+
+// getCredential generate a credential string for service.
+func getCredential(accessKeyID, location, service string, t time.Time) string {
+	scope := getScope(location, service, t)
 	return accessKeyID + "/" + scope
 }
 
@@ -129,7 +133,9 @@ func getHashedPayload(req http.Request) string {
 }
 
 // getCanonicalHeaders generate a list of request headers for
-// signature.
+// signature. The values of headers sent more than once are comma
+// separated in the order they were added, every value is trimmed
+// and its sequential spaces are folded into one.
 func getCanonicalHeaders(req http.Request, ignoredHeaders map[string]bool) string {
 	var headers []string
 	vals := make(map[string][]string)
@@ -158,7 +164,7 @@ func getCanonicalHeaders(req http.Request, ignoredHeaders map[string]bool) strin
 				if idx > 0 {
 					buf.WriteByte(',')
 				}
-				buf.WriteString(v)
+				buf.WriteString(strings.Join(strings.Fields(v), " "))
 			}
 			buf.WriteByte('\n')
 		}
@@ -191,8 +197,15 @@ func getSignedHeaders(req http.Request, ignoredHeaders map[string]bool) string {
 //  <CanonicalHeaders>\n
 //  <SignedHeaders>\n
 //  <HashedPayload>
+//
+// The query of req is rewritten in canonical form, sorted by name
+// and then by value.
 func getCanonicalRequest(req http.Request, ignoredHeaders map[string]bool) string {
-	req.URL.RawQuery = strings.Replace(req.URL.Query().Encode(), "+", "%20", -1)
+	query := req.URL.Query()
+	for _, values := range query {
+		sort.Strings(values)
+	}
+	req.URL.RawQuery = strings.Replace(query.Encode(), "+", "%20", -1)
 	canonicalRequest := strings.Join([]string{
 		req.Method,
 		urlEncodePath(req.URL.Path),
@@ -205,16 +218,18 @@ func getCanonicalRequest(req http.Request, ignoredHeaders map[string]bool) strin
 }
 
 // getStringToSign a string based on selected query values.
-func getStringToSignV4(t time.Time, location, canonicalRequest string) string {
+func getStringToSignV4(t time.Time, location, service, canonicalRequest string) string {
 	stringToSign := signV4Algorithm + "\n" + t.Format(iso8601DateFormat) + "\n"
-	stringToSign = stringToSign + getScope(location, t) + "\n"
+	stringToSign = stringToSign + getScope(location, service, t) + "\n"
 	stringToSign = stringToSign + hex.EncodeToString(sum256([]byte(canonicalRequest)))
 	return stringToSign
 }
 
 // PreSignV4 presign the request, in accordance with
 // http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html.
-func PreSignV4(req http.Request, accessKeyID, secretAccessKey, location string, expires int64) *http.Request {
+// The session token of temporary credentials is signed as part of the query, it is
+// left out if sessionToken is empty.
+func PreSignV4(req http.Request, accessKeyID, secretAccessKey, sessionToken, location string, expires int64) *http.Request {
 	// Presign is not needed for anonymous credentials.
 	if accessKeyID == "" || secretAccessKey == "" {
 		return &req
@@ -236,16 +251,19 @@ func PreSignV4(req http.Request, accessKeyID, secretAccessKey, location string,
 	query.Set("X-Amz-Expires", strconv.FormatInt(expires, 10))
 	query.Set("X-Amz-SignedHeaders", signedHeaders)
 	query.Set("X-Amz-Credential", credential)
+	if sessionToken != "" {
+		query.Set("X-Amz-Security-Token", sessionToken)
+	}
 	req.URL.RawQuery = query.Encode()
 
 	// Get canonical request.
 	canonicalRequest := getCanonicalRequest(req, ignoredSignV4Headers)
 
 	// Get string to sign from canonical request.
-	stringToSign := getStringToSignV4(t, location, canonicalRequest)
+	stringToSign := getStringToSignV4(t, location, s3Service, canonicalRequest)
 
 	// Gext hmac signing key.
-	signingKey := getSigningKey(secretAccessKey, location, t)
+	signingKey := getSigningKey(secretAccessKey, location, s3Service, t)
 
 	// Calculate signature.
 	signature := getSignature(signingKey, stringToSign)
@@ -257,10 +275,12 @@ func PreSignV4(req http.Request, accessKeyID, secretAccessKey, location string,
 }
 
 // PostPresignSignatureV4 - presigned signature for PostPolicy
-// requests.
+// requests. The session token of temporary credentials is not part of the
+// signature, it must be sent in the x-amz-security-token form field and
+// be matched by a condition of the policy.
 func PostPresignSignatureV4(policyBase64 string, t time.Time, secretAccessKey, location string) string {
 	// Get signining key.
-	signingkey := getSigningKey(secretAccessKey, location, t)
+	signingkey := getSigningKey(secretAccessKey, location, s3Service, t)
 	// Calculate signature.
 	signature := getSignature(signingkey, policyBase64)
 	return signature
@@ -268,32 +288,51 @@ func PostPresignSignatureV4(policyBase64 string, t time.Time, secretAccessKey, l
 
 // SignV4 sign the request before Do(), in accordance with
 // http://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html.
-func SignV4(req http.Request, accessKeyID, secretAccessKey, location string) *http.Request {
+// The session token of temporary credentials is signed as the
+// X-Amz-Security-Token header, it is left out if sessionToken is empty.
+func SignV4(req http.Request, accessKeyID, secretAccessKey, sessionToken, location string) *http.Request {
+	return SignV4At(req, accessKeyID, secretAccessKey, sessionToken, location, time.Now().UTC())
+}
+
+// SignV4At sign the request like SignV4 does, as if it was sent at t. Servers
+// reject requests signed too far away from their own time.
+func SignV4At(req http.Request, accessKeyID, secretAccessKey, sessionToken, location string, t time.Time) *http.Request {
+	return signV4(req, accessKeyID, secretAccessKey, sessionToken, location, s3Service, t, ignoredSignV4Headers)
+}
+
+// signV4 sign the request for service as if it was sent at t, leaving
+// ignoredHeaders out of the signature.
+func signV4(req http.Request, accessKeyID, secretAccessKey, sessionToken, location, service string, t time.Time, ignoredHeaders map[string]bool) *http.Request {
 	// Signature calculation is not needed for anonymous credentials.
 	if accessKeyID == "" || secretAccessKey == "" {
 		return &req
 	}
 
-	// Initial time.
-	t := time.Now().UTC()
+	// Sign in UTC.
+	t = t.UTC()
 
 	// Set x-amz-date.
 	req.Header.Set("X-Amz-Date", t.Format(iso8601DateFormat))
 
+	// Set x-amz-security-token.
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
 	// Get canonical request.
-	canonicalRequest := getCanonicalRequest(req, ignoredSignV4Headers)
+	canonicalRequest := getCanonicalRequest(req, ignoredHeaders)
 
 	// Get string to sign from canonical request.
-	stringToSign := getStringToSignV4(t, location, canonicalRequest)
+	stringToSign := getStringToSignV4(t, location, service, canonicalRequest)
 
 	// Get hmac signing key.
-	signingKey := getSigningKey(secretAccessKey, location, t)
+	signingKey := getSigningKey(secretAccessKey, location, service, t)
 
 	// Get credential string.
-	credential := GetCredential(accessKeyID, location, t)
+	credential := getCredential(accessKeyID, location, service, t)
 
 	// Get all signed headers.
-	signedHeaders := getSignedHeaders(req, ignoredSignV4Headers)
+	signedHeaders := getSignedHeaders(req, ignoredHeaders)
 
 	// Calculate signature.
 	signature := getSignature(signingKey, stringToSign)
@@ -312,9 +351,34 @@ func SignV4(req http.Request, accessKeyID, secretAccessKey, location string) *ht
 	return &req
 }
 
-// generateSignedChunks - generates a data stream with streamed chunk metadata
-func generateSignedChunks(body io.Reader, seed, region, secretKey string, chunkSize int64, currTime time.Time) io.ReadCloser {
+// getTrailerLines - the trailing headers of a chunked payload, one name:value
+// line per header ended by eol, sorted by lower case name.
+func getTrailerLines(trailer http.Header, eol string) string {
+	values := make(map[string]string)
+	var names []string
+	for name, value := range trailer {
+		names = append(names, strings.ToLower(name))
+		values[strings.ToLower(name)] = strings.Join(value, ",")
+	}
+	sort.Strings(names)
+	var lines string
+	for _, name := range names {
+		lines = lines + name + ":" + values[name] + eol
+	}
+	return lines
+}
+
+// generateSignedChunks - generates a data stream with streamed chunk metadata,
+// the trailing headers are signed after the final chunk unless trailer is nil.
+func generateSignedChunks(body io.Reader, seed, region, secretKey string, chunkSize int64, currTime time.Time, trailer http.Header) io.ReadCloser {
 	var stream []byte
+
+	// Get scope.
+	scope := getScope(region, s3Service, currTime)
+
+	// Get hmac signing key.
+	signingKey := getSigningKey(secretKey, region, s3Service, currTime)
+
 	for {
 		buffer := make([]byte, chunkSize)
 		n, err := body.Read(buffer)
@@ -322,14 +386,6 @@ func generateSignedChunks(body io.Reader, seed, region, secretKey string, chunkS
 			return nil
 		}
 
-		// Get scope.
-		scope := strings.Join([]string{
-			currTime.Format(yyyymmdd),
-			region,
-			"s3",
-			"aws4_request",
-		}, "/")
-
 		stringToSign := "AWS4-HMAC-SHA256-PAYLOAD" + "\n"
 		stringToSign = stringToSign + currTime.Format(iso8601DateFormat) + "\n"
 		stringToSign = stringToSign + scope + "\n"
@@ -337,28 +393,93 @@ func generateSignedChunks(body io.Reader, seed, region, secretKey string, chunkS
 		stringToSign = stringToSign + "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" + "\n" // hex(sum256(""))
 		stringToSign = stringToSign + hex.EncodeToString(sum256(buffer[:n]))
 
-		date := sumHMAC([]byte("AWS4"+secretKey), []byte(currTime.Format(yyyymmdd)))
-		regionHMAC := sumHMAC(date, []byte(region))
-		service := sumHMAC(regionHMAC, []byte("s3"))
-		signingKey := sumHMAC(service, []byte("aws4_request"))
-
-		seed = hex.EncodeToString(sumHMAC(signingKey, []byte(stringToSign)))
+		seed = getSignature(signingKey, stringToSign)
 
 		stream = append(stream, []byte(fmt.Sprintf("%x", n)+";chunk-signature="+seed+"\r\n")...)
 		stream = append(stream, buffer[:n]...)
+
+		if n <= 0 {
+			break
+		}
 		stream = append(stream, []byte("\r\n")...)
+	}
+
+	if trailer == nil {
+		stream = append(stream, []byte("\r\n")...)
+		return ioutil.NopCloser(bytes.NewReader(stream))
+	}
+
+	// The trailing headers are chained to the signature of the final chunk.
+	stringToSign := "AWS4-HMAC-SHA256-TRAILER" + "\n"
+	stringToSign = stringToSign + currTime.Format(iso8601DateFormat) + "\n"
+	stringToSign = stringToSign + scope + "\n"
+	stringToSign = stringToSign + seed + "\n"
+	stringToSign = stringToSign + hex.EncodeToString(sum256([]byte(getTrailerLines(trailer, "\n"))))
+
+	stream = append(stream, []byte(getTrailerLines(trailer, "\r\n"))...)
+	stream = append(stream, []byte("x-amz-trailer-signature:"+getSignature(signingKey, stringToSign)+"\r\n\r\n")...)
+
+	return ioutil.NopCloser(bytes.NewReader(stream))
+}
+
+// generateUnsignedChunks - generates a data stream of unsigned chunks followed by
+// the trailing headers, in accordance with the aws-chunked encoding of
+// STREAMING-UNSIGNED-PAYLOAD-TRAILER.
+func generateUnsignedChunks(body io.Reader, chunkSize int64, trailer http.Header) io.ReadCloser {
+	var stream []byte
+	for {
+		buffer := make([]byte, chunkSize)
+		n, err := body.Read(buffer)
+		if err != nil && err != io.EOF {
+			return nil
+		}
+
+		stream = append(stream, []byte(fmt.Sprintf("%x", n)+"\r\n")...)
+		stream = append(stream, buffer[:n]...)
 
 		if n <= 0 {
 			break
 		}
+		stream = append(stream, []byte("\r\n")...)
 	}
 
+	stream = append(stream, []byte(getTrailerLines(trailer, "\r\n")+"\r\n")...)
+
 	return ioutil.NopCloser(bytes.NewReader(stream))
 }
 
 // StreamingSignV4 sign the request before Do(), in accordance with
 // http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
-func StreamingSignV4(req http.Request, accessKeyID, secretAccessKey, location string, chunkSize int64) *http.Request {
+// The session token of temporary credentials is signed as the
+// X-Amz-Security-Token header, it is left out if sessionToken is empty.
+func StreamingSignV4(req http.Request, accessKeyID, secretAccessKey, sessionToken, location string, chunkSize int64) *http.Request {
+	return streamingSignV4(req, accessKeyID, secretAccessKey, sessionToken, location, chunkSize, nil)
+}
+
+// StreamingSignV4Trailer sign the request like StreamingSignV4 does and send
+// trailer after the final chunk, signed along with the chunks, in accordance
+// with STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER. The X-Amz-Trailer header
+// naming the trailing headers is left to the caller.
+func StreamingSignV4Trailer(req http.Request, accessKeyID, secretAccessKey, sessionToken, location string, chunkSize int64, trailer http.Header) *http.Request {
+	return streamingSignV4(req, accessKeyID, secretAccessKey, sessionToken, location, chunkSize, trailer)
+}
+
+// StreamingUnsignedTrailerV4 sign the request headers like SignV4 does and send the
+// body in unsigned chunks followed by trailer, in accordance with
+// STREAMING-UNSIGNED-PAYLOAD-TRAILER. The X-Amz-Trailer header naming the trailing
+// headers is left to the caller.
+func StreamingUnsignedTrailerV4(req http.Request, accessKeyID, secretAccessKey, sessionToken, location string, chunkSize int64, trailer http.Header) *http.Request {
+	signedReq := signV4(req, accessKeyID, secretAccessKey, sessionToken, location, s3Service, time.Now(), ignoredStreamSignV4Headers)
+
+	// The chunks are not signed, the body is encoded for anonymous credentials too.
+	signedReq.Body = generateUnsignedChunks(signedReq.Body, chunkSize, trailer)
+
+	return signedReq
+}
+
+// streamingSignV4 sign the request and its chunks, the trailing headers are
+// sent after the final chunk unless trailer is nil.
+func streamingSignV4(req http.Request, accessKeyID, secretAccessKey, sessionToken, location string, chunkSize int64, trailer http.Header) *http.Request {
 	// Signature calculation is not needed for anonymous credentials.
 	if accessKeyID == "" || secretAccessKey == "" {
 		return &req
@@ -370,14 +491,19 @@ func StreamingSignV4(req http.Request, accessKeyID, secretAccessKey, location st
 	// Set x-amz-date.
 	req.Header.Set("X-Amz-Date", t.Format(iso8601DateFormat))
 
+	// Set x-amz-security-token.
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
 	// Get canonical request.
 	canonicalRequest := getCanonicalRequest(req, ignoredStreamSignV4Headers)
 
 	// Get string to sign from canonical request.
-	stringToSign := getStringToSignV4(t, location, canonicalRequest)
+	stringToSign := getStringToSignV4(t, location, s3Service, canonicalRequest)
 
 	// Get hmac signing key.
-	signingKey := getSigningKey(secretAccessKey, location, t)
+	signingKey := getSigningKey(secretAccessKey, location, s3Service, t)
 
 	// Get credential string.
 	credential := GetCredential(accessKeyID, location, t)
@@ -400,7 +526,7 @@ func StreamingSignV4(req http.Request, accessKeyID, secretAccessKey, location st
 	req.Header.Set("Authorization", auth)
 
 	// Rebuild the request body after signature signing to deliver correct chunk metadata
-	req.Body = generateSignedChunks(req.Body, signature, location, secretAccessKey, chunkSize, t)
+	req.Body = generateSignedChunks(req.Body, signature, location, secretAccessKey, chunkSize, t, trailer)
 
 	return &req
 }
@@ -0,0 +1,544 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signv4
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestRequest - a request to sign.
+func newTestRequest(t *testing.T) http.Request {
+	req, err := http.NewRequest("PUT", "https://s3.amazonaws.com/bucket/object", bytes.NewReader([]byte("data")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+	return *req
+}
+
+// TestSessionToken - the session token of temporary credentials is signed along with the request.
+func TestSessionToken(t *testing.T) {
+	for _, signed := range []*http.Request{
+		SignV4(newTestRequest(t), "access", "secret", "token", "us-east-1"),
+		StreamingSignV4(newTestRequest(t), "access", "secret", "token", "us-east-1", 64*1024),
+		StreamingSignV4Trailer(newTestRequest(t), "access", "secret", "token", "us-east-1", 64*1024, http.Header{}),
+		StreamingUnsignedTrailerV4(newTestRequest(t), "access", "secret", "token", "us-east-1", 64*1024, http.Header{}),
+	} {
+		if token := signed.Header.Get("X-Amz-Security-Token"); token != "token" {
+			t.Errorf("Expected the session token header, got %q", token)
+		}
+		if auth := signed.Header.Get("Authorization"); !strings.Contains(auth, "x-amz-security-token") {
+			t.Errorf("Expected the session token to be signed, got %s", auth)
+		}
+	}
+	presigned := PreSignV4(newTestRequest(t), "access", "secret", "token", "us-east-1", 60)
+	if token := presigned.URL.Query().Get("X-Amz-Security-Token"); token != "token" {
+		t.Errorf("Expected the session token in the query, got %q", token)
+	}
+	// Long term credentials are signed without a session token.
+	signed := SignV4(newTestRequest(t), "access", "secret", "", "us-east-1")
+	if _, ok := signed.Header["X-Amz-Security-Token"]; ok {
+		t.Error("Expected no session token header")
+	}
+	presigned = PreSignV4(newTestRequest(t), "access", "secret", "", "us-east-1", 60)
+	if _, ok := presigned.URL.Query()["X-Amz-Security-Token"]; ok {
+		t.Error("Expected no session token in the query")
+	}
+}
+
+// Credentials, region, service and date the requests of the AWS Signature Version 4
+// test suite are signed with, see
+// http://docs.aws.amazon.com/general/latest/gr/signature-v4-test-suite.html.
+const (
+	suiteAccessKey = "AKIDEXAMPLE"
+	suiteSecretKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+	suiteRegion    = "us-east-1"
+	suiteService   = "service"
+	suiteDate      = "20150830T123600Z"
+)
+
+// The requests of the test suite sign their payload without an X-Amz-Content-Sha256 header,
+// the header only carries the payload checksum to getCanonicalRequest.
+var suiteIgnoredHeaders = map[string]bool{
+	"Authorization":        true,
+	"X-Amz-Content-Sha256": true,
+}
+
+// suiteTestCase - a request of the test suite, its canonical request, string to sign and signature.
+type suiteTestCase struct {
+	name             string
+	method           string
+	url              string
+	headers          []string // Name:value, in the order they are sent.
+	body             string
+	canonicalRequest string
+	stringToSign     string
+	signedHeaders    string
+	signature        string
+}
+
+// Requests of the test suite. The suite also normalizes paths with relative segments
+// or repeated slashes, which S3 never does, those requests are left out.
+var suiteTestCases = []suiteTestCase{
+	{
+		name:   "get-vanilla",
+		method: "GET",
+		url:    "https://example.amazonaws.com/",
+		canonicalRequest: "GET\n" +
+			"/\n" +
+			"\n" +
+			"host:example.amazonaws.com\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"bb579772317eb040ac9ed261061d46c1f17a8133879d6129b6e1c25292927e63",
+		signedHeaders: "host;x-amz-date",
+		signature:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+	},
+	{
+		name:   "get-vanilla-query-order-key-case",
+		method: "GET",
+		url:    "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+		canonicalRequest: "GET\n" +
+			"/\n" +
+			"Param1=value1&Param2=value2\n" +
+			"host:example.amazonaws.com\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"816cd5b414d056048ba4f7c5386d6e0533120fb1fcfa93762cf0fc39e2cf19e0",
+		signedHeaders: "host;x-amz-date",
+		signature:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+	},
+	{
+		name:   "get-vanilla-query-order-value",
+		method: "GET",
+		url:    "https://example.amazonaws.com/?Param1=value2&Param1=Value1",
+		canonicalRequest: "GET\n" +
+			"/\n" +
+			"Param1=Value1&Param1=value2\n" +
+			"host:example.amazonaws.com\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"704b4cef673542d84cdff252633f065e8daeba5f168b77116f8b1bcaf3d38f89",
+		signedHeaders: "host;x-amz-date",
+		signature:     "eedbc4e291e521cf13422ffca22be7d2eb8146eecf653089df300a15b2382bd1",
+	},
+	{
+		name:   "get-vanilla-query-unreserved",
+		method: "GET",
+		url:    "https://example.amazonaws.com/?-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz=-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz",
+		canonicalRequest: "GET\n" +
+			"/\n" +
+			"-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz=-._~0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz\n" +
+			"host:example.amazonaws.com\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"c30d4703d9f799439be92736156d47ccfb2d879ddf56f5befa6d1d6aab979177",
+		signedHeaders: "host;x-amz-date",
+		signature:     "9c3e54bfcdf0b19771a7f523ee5669cdf59bc7cc0884027167c21bb143a40197",
+	},
+	{
+		name:   "get-utf8",
+		method: "GET",
+		url:    "https://example.amazonaws.com/ሴ",
+		canonicalRequest: "GET\n" +
+			"/%E1%88%B4\n" +
+			"\n" +
+			"host:example.amazonaws.com\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"2a0a97d02205e45ce2e994789806b19270cfbbb0921b278ccf58f5249ac42102",
+		signedHeaders: "host;x-amz-date",
+		signature:     "8318018e0b0f223aa2bbf98705b62bb787dc9c0e678f255a891fd03141be5d85",
+	},
+	{
+		name:   "get-space",
+		method: "GET",
+		url:    "https://example.amazonaws.com/example%20space/",
+		canonicalRequest: "GET\n" +
+			"/example%20space/\n" +
+			"\n" +
+			"host:example.amazonaws.com\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"63ee75631ed7234ae61b5f736dfc7754cdccfedbff4b5128a915706ee9390d86",
+		signedHeaders: "host;x-amz-date",
+		signature:     "652487583200325589f1fba4c7e578f72c47cb61beeca81406b39ddec1366741",
+	},
+	{
+		name:    "get-header-key-duplicate",
+		method:  "GET",
+		url:     "https://example.amazonaws.com/",
+		headers: []string{"My-Header1:value2", "My-Header1:value2", "My-Header1:value1"},
+		canonicalRequest: "GET\n" +
+			"/\n" +
+			"\n" +
+			"host:example.amazonaws.com\n" +
+			"my-header1:value2,value2,value1\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;my-header1;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"dc7f04a3abfde8d472b0ab1a418b741b7c67174dad1551b4117b15527fbe966c",
+		signedHeaders: "host;my-header1;x-amz-date",
+		signature:     "c9d5ea9f3f72853aea855b47ea873832890dbdd183b4468f858259531a5138ea",
+	},
+	{
+		name:    "get-header-value-order",
+		method:  "GET",
+		url:     "https://example.amazonaws.com/",
+		headers: []string{"My-Header1:value4", "My-Header1:value1", "My-Header1:value3", "My-Header1:value2"},
+		canonicalRequest: "GET\n" +
+			"/\n" +
+			"\n" +
+			"host:example.amazonaws.com\n" +
+			"my-header1:value4,value1,value3,value2\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;my-header1;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"31ce73cd3f3d9f66977ad3dd957dc47af14df92fcd8509f59b349e9137c58b86",
+		signedHeaders: "host;my-header1;x-amz-date",
+		signature:     "08c7e5a9acfcfeb3ab6b2185e75ce8b1deb5e634ec47601a50643f830c755c01",
+	},
+	{
+		name:    "get-header-value-trim",
+		method:  "GET",
+		url:     "https://example.amazonaws.com/",
+		headers: []string{"My-Header1: value1", "My-Header2: \"a   b   c\""},
+		canonicalRequest: "GET\n" +
+			"/\n" +
+			"\n" +
+			"host:example.amazonaws.com\n" +
+			"my-header1:value1\n" +
+			"my-header2:\"a b c\"\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;my-header1;my-header2;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"a726db9b0df21c14f559d0a978e563112acb1b9e05476f0a6a1c7d68f28605c7",
+		signedHeaders: "host;my-header1;my-header2;x-amz-date",
+		signature:     "acc3ed3afb60bb290fc8d2dd0098b9911fcaa05412b367055dee359757a9c736",
+	},
+	{
+		name:   "post-vanilla",
+		method: "POST",
+		url:    "https://example.amazonaws.com/",
+		canonicalRequest: "POST\n" +
+			"/\n" +
+			"\n" +
+			"host:example.amazonaws.com\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"553f88c9e4d10fc9e109e2aeb65f030801b70c2f6468faca261d401ae622fc87",
+		signedHeaders: "host;x-amz-date",
+		signature:     "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+	},
+	{
+		name:   "post-vanilla-query",
+		method: "POST",
+		url:    "https://example.amazonaws.com/?Param1=value1",
+		canonicalRequest: "POST\n" +
+			"/\n" +
+			"Param1=value1\n" +
+			"host:example.amazonaws.com\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"9d659678c1756bb3113e2ce898845a0a79dbbc57b740555917687f1b3340fbbd",
+		signedHeaders: "host;x-amz-date",
+		signature:     "28038455d6de14eafc1f9222cf5aa6f1a96197d7deb8263271d420d138af7f11",
+	},
+	{
+		name:    "post-header-key-sort",
+		method:  "POST",
+		url:     "https://example.amazonaws.com/",
+		headers: []string{"My-Header1:value1"},
+		canonicalRequest: "POST\n" +
+			"/\n" +
+			"\n" +
+			"host:example.amazonaws.com\n" +
+			"my-header1:value1\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;my-header1;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"9368318c2967cf6de74404b30c65a91e8f6253e0a8659d6d5319f1a812f87d65",
+		signedHeaders: "host;my-header1;x-amz-date",
+		signature:     "c5410059b04c1ee005303aed430f6e6645f61f4dc9e1461ec8f8916fdf18852c",
+	},
+	{
+		name:    "post-header-value-case",
+		method:  "POST",
+		url:     "https://example.amazonaws.com/",
+		headers: []string{"My-Header1:VALUE1"},
+		canonicalRequest: "POST\n" +
+			"/\n" +
+			"\n" +
+			"host:example.amazonaws.com\n" +
+			"my-header1:VALUE1\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"host;my-header1;x-amz-date\n" +
+			"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"d51ced243e649e3de6ef63afbbdcbca03131a21a7103a1583706a64618606a93",
+		signedHeaders: "host;my-header1;x-amz-date",
+		signature:     "cdbc9802e29d2942e5e10b5bccfdd67c5f22c7c4e8ae67b53629efa58b974b7d",
+	},
+	{
+		name:    "post-x-www-form-urlencoded",
+		method:  "POST",
+		url:     "https://example.amazonaws.com/",
+		headers: []string{"Content-Type:application/x-www-form-urlencoded"},
+		body:    "Param1=value1",
+		canonicalRequest: "POST\n" +
+			"/\n" +
+			"\n" +
+			"content-type:application/x-www-form-urlencoded\n" +
+			"host:example.amazonaws.com\n" +
+			"x-amz-date:20150830T123600Z\n" +
+			"\n" +
+			"content-type;host;x-amz-date\n" +
+			"9095672bbd1f56dfc5b65f3e153adc8731a4a654192329106275f4c7b24d0b6e",
+		stringToSign: "AWS4-HMAC-SHA256\n" +
+			"20150830T123600Z\n" +
+			"20150830/us-east-1/service/aws4_request\n" +
+			"42a5e5bb34198acb3e84da4f085bb7927f2bc277ca766e6d19c73c2154021281",
+		signedHeaders: "content-type;host;x-amz-date",
+		signature:     "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+	},
+}
+
+// newSuiteRequest - the request of testCase, dated like every request of the test suite.
+func newSuiteRequest(t *testing.T, testCase suiteTestCase) http.Request {
+	req, err := http.NewRequest(testCase.method, testCase.url, strings.NewReader(testCase.body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, header := range testCase.headers {
+		nameValue := strings.SplitN(header, ":", 2)
+		req.Header.Add(nameValue[0], nameValue[1])
+	}
+	req.Header.Set("X-Amz-Date", suiteDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum256([]byte(testCase.body))))
+	return *req
+}
+
+// suiteTime - the date of the requests of the test suite.
+func suiteTime(t *testing.T) time.Time {
+	date, err := time.Parse(iso8601DateFormat, suiteDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
+
+// TestSuiteCanonicalRequest - the canonical requests of the test suite.
+func TestSuiteCanonicalRequest(t *testing.T) {
+	for _, testCase := range suiteTestCases {
+		canonicalRequest := getCanonicalRequest(newSuiteRequest(t, testCase), suiteIgnoredHeaders)
+		if canonicalRequest != testCase.canonicalRequest {
+			t.Errorf("%s: expected canonical request\n%s\ngot\n%s", testCase.name, testCase.canonicalRequest, canonicalRequest)
+		}
+	}
+}
+
+// TestSuiteStringToSign - the strings to sign of the test suite.
+func TestSuiteStringToSign(t *testing.T) {
+	for _, testCase := range suiteTestCases {
+		stringToSign := getStringToSignV4(suiteTime(t), suiteRegion, suiteService, testCase.canonicalRequest)
+		if stringToSign != testCase.stringToSign {
+			t.Errorf("%s: expected string to sign\n%s\ngot\n%s", testCase.name, testCase.stringToSign, stringToSign)
+		}
+	}
+}
+
+// TestSuiteSignV4 - the Authorization headers of the test suite.
+func TestSuiteSignV4(t *testing.T) {
+	for _, testCase := range suiteTestCases {
+		signed := signV4(newSuiteRequest(t, testCase), suiteAccessKey, suiteSecretKey, "", suiteRegion, suiteService, suiteTime(t), suiteIgnoredHeaders)
+		expected := signV4Algorithm + " Credential=" + suiteAccessKey + "/20150830/" + suiteRegion + "/" + suiteService + "/aws4_request, " +
+			"SignedHeaders=" + testCase.signedHeaders + ", Signature=" + testCase.signature
+		if auth := signed.Header.Get("Authorization"); auth != expected {
+			t.Errorf("%s: expected %s, got %s", testCase.name, expected, auth)
+		}
+	}
+}
+
+// TestSignV4 - SignV4At signs for S3, with an unsigned payload unless X-Amz-Content-Sha256 is set.
+func TestSignV4(t *testing.T) {
+	req := newSuiteRequest(t, suiteTestCases[0])
+	req.Header.Del("X-Amz-Content-Sha256")
+	signed := SignV4At(req, suiteAccessKey, suiteSecretKey, "", suiteRegion, suiteTime(t))
+	expected := signV4Algorithm + " Credential=AKIDEXAMPLE/20150830/us-east-1/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=e466c56105faeb99883599d048aacd655397ca4edbe9d383f9076947bedcd780"
+	if auth := signed.Header.Get("Authorization"); auth != expected {
+		t.Errorf("Expected %s, got %s", expected, auth)
+	}
+	if date := signed.Header.Get("X-Amz-Date"); date != suiteDate {
+		t.Errorf("Expected X-Amz-Date %s, got %s", suiteDate, date)
+	}
+}
+
+// Secret key, region, date and payload of the chunked upload examples of
+// http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html and
+// http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming-trailers.html.
+const (
+	chunkedSecretKey = "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"
+	chunkedRegion    = "us-east-1"
+	chunkedDate      = "20130524T000000Z"
+	chunkedSize      = 64 * 1024
+)
+
+// chunkedPayload - the 66560 bytes uploaded by the chunked upload examples.
+func chunkedPayload() *bytes.Reader {
+	return bytes.NewReader(bytes.Repeat([]byte("a"), 66560))
+}
+
+// chunkedLines - the lines of a chunked payload, leaving out the chunk data.
+func chunkedLines(stream []byte) []string {
+	var lines []string
+	for _, line := range strings.Split(string(stream), "\r\n") {
+		if !strings.HasPrefix(line, "aaaa") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// TestGenerateSignedChunks - the chunks and trailing headers are signed like in the examples of the documentation.
+func TestGenerateSignedChunks(t *testing.T) {
+	date, err := time.Parse(iso8601DateFormat, chunkedDate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trailer := http.Header{}
+	trailer.Set("X-Amz-Checksum-Crc32c", "sOO8/Q==")
+	testCases := []struct {
+		seed     string
+		trailer  http.Header
+		expected []string
+	}{
+		// STREAMING-AWS4-HMAC-SHA256-PAYLOAD
+		{
+			seed: "4f232c4386841ef735655705268965c44a0e4690baa4adea153f7db9fa80a0a9",
+			expected: []string{
+				"10000;chunk-signature=ad80c730a21e5b8d04586a2213dd63b9a0e99e0e2307b0ade35a65485a288648",
+				"400;chunk-signature=0055627c9e194cb4542bae2aa5492e3c1575bbb81b612b7d234b86a503ef5497",
+				"0;chunk-signature=b6c6ea8a5354eaf15b3cb7646744f4275b71ea724fed81ceb9323e279d449df9",
+				"",
+				"",
+			},
+		},
+		// STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER
+		{
+			seed:    "106e2a8a18243abcf37539882f36619c00e2dfc72633413f02d3b74544bfeb8e",
+			trailer: trailer,
+			expected: []string{
+				"10000;chunk-signature=b474d8862b1487a5145d686f57f013e54db672cee1c953b3010fb58501ef5aa2",
+				"400;chunk-signature=1c1344b170168f8e65b41376b44b20fe354e373826ccbbe2c1d40a8cae51e5c7",
+				"0;chunk-signature=2ca2aba2005185cf7159c6277faf83795951dd77a3a99e6e65d5c9f85863f992",
+				"x-amz-checksum-crc32c:sOO8/Q==",
+				"x-amz-trailer-signature:d81f82fc3505edab99d459891051a732e8730629a2e4a59689829ca17fe2e435",
+				"",
+				"",
+			},
+		},
+	}
+	for i, testCase := range testCases {
+		stream, err := ioutil.ReadAll(generateSignedChunks(chunkedPayload(), testCase.seed, chunkedRegion, chunkedSecretKey, chunkedSize, date, testCase.trailer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := chunkedLines(stream); strings.Join(lines, "\n") != strings.Join(testCase.expected, "\n") {
+			t.Errorf("Test %d: expected %q, got %q", i+1, testCase.expected, lines)
+		}
+	}
+}
+
+// TestGenerateUnsignedChunks - the chunks are sent without signatures, followed by the trailing headers.
+func TestGenerateUnsignedChunks(t *testing.T) {
+	trailer := http.Header{}
+	trailer.Set("X-Amz-Checksum-Crc32", "DUoRhQ==")
+	stream, err := ioutil.ReadAll(generateUnsignedChunks(bytes.NewReader([]byte("hello world")), 5, trailer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "5\r\nhello\r\n5\r\n worl\r\n1\r\nd\r\n0\r\nx-amz-checksum-crc32:DUoRhQ==\r\n\r\n"
+	if string(stream) != expected {
+		t.Errorf("Expected %q, got %q", expected, stream)
+	}
+}
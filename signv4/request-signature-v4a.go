@@ -0,0 +1,214 @@
+/*
+ * Minio S3Verify Library for Amazon S3 Compatible Cloud Storage (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signv4
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SigV4A, the asymmetric variant of Signature Version 4 used by multi-region
+// access points, signs with an ECDSA P-256 key derived from the secret key.
+// Its credential scope names no region, the signature is valid in every
+// region of the X-Amz-Region-Set header instead.
+const signV4AAlgorithm = "AWS4-ECDSA-P256-SHA256"
+
+// The order of P-256 minus two, the derived private keys are candidates below it plus one.
+var nMinusTwoP256 = new(big.Int).Sub(elliptic.P256().Params().N, big.NewInt(2))
+
+// ecdsaSignature - the ASN.1 form of an ECDSA signature.
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// deriveKeyV4A derive the ECDSA P-256 key of an access key pair. Candidates
+// are generated with the KDF in counter mode of NIST SP 800-108, keyed by
+// "AWS4A" and the secret key, until one is smaller than the order of P-256
+// minus two.
+func deriveKeyV4A(accessKeyID, secretAccessKey string) (*ecdsa.PrivateKey, error) {
+	curve := elliptic.P256()
+	inputKey := []byte("AWS4A" + secretAccessKey)
+	for counter := 1; counter <= 0xFF; counter++ {
+		// A single block of the KDF yields the 256 bits needed.
+		fixedInput := []byte{0, 0, 0, 1}
+		fixedInput = append(fixedInput, signV4AAlgorithm...)
+		fixedInput = append(fixedInput, 0)
+		fixedInput = append(fixedInput, accessKeyID...)
+		fixedInput = append(fixedInput, byte(counter))
+		fixedInput = append(fixedInput, 0, 0, 1, 0) // 256 bits.
+		candidate := new(big.Int).SetBytes(sumHMAC(inputKey, fixedInput))
+		if candidate.Cmp(nMinusTwoP256) >= 0 {
+			continue
+		}
+		key := &ecdsa.PrivateKey{D: candidate.Add(candidate, big.NewInt(1))}
+		key.PublicKey.Curve = curve
+		key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(key.D.Bytes())
+		return key, nil
+	}
+	return nil, errors.New("No SigV4A key can be derived from the access key pair.")
+}
+
+// getScopeV4A generate the credential scope of SigV4A, a date and a
+// service without a region.
+func getScopeV4A(service string, t time.Time) string {
+	return strings.Join([]string{
+		t.Format(yyyymmdd),
+		service,
+		"aws4_request",
+	}, "/")
+}
+
+// getStringToSignV4A a string based on selected query values.
+func getStringToSignV4A(t time.Time, service, canonicalRequest string) string {
+	stringToSign := signV4AAlgorithm + "\n" + t.Format(iso8601DateFormat) + "\n"
+	stringToSign = stringToSign + getScopeV4A(service, t) + "\n"
+	stringToSign = stringToSign + hex.EncodeToString(sum256([]byte(canonicalRequest)))
+	return stringToSign
+}
+
+// getSignatureV4A sign stringToSign with the key derived from the access
+// key pair, the ASN.1 encoded ECDSA signature in hexadecimal form. ECDSA
+// signatures are randomized, every call returns another signature.
+func getSignatureV4A(accessKeyID, secretAccessKey, stringToSign string) string {
+	key, err := deriveKeyV4A(accessKeyID, secretAccessKey)
+	if err != nil {
+		// The request is sent without a valid signature and rejected.
+		return ""
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, key, sum256([]byte(stringToSign)))
+	if err != nil {
+		return ""
+	}
+	signature, err := asn1.Marshal(ecdsaSignature{r, s})
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(signature)
+}
+
+// SignV4A sign the request before Do() with SigV4A, in accordance with
+// http://docs.aws.amazon.com/general/latest/gr/signature-version-4.html.
+// The signature is valid in the regions of regionSet, a comma separated
+// list of regions that may contain wildcards, such as * for every region.
+// The session token of temporary credentials is signed as the
+// X-Amz-Security-Token header, it is left out if sessionToken is empty.
+func SignV4A(req http.Request, accessKeyID, secretAccessKey, sessionToken, regionSet string) *http.Request {
+	return SignV4AAt(req, accessKeyID, secretAccessKey, sessionToken, regionSet, time.Now().UTC())
+}
+
+// SignV4AAt sign the request like SignV4A does, as if it was sent at t.
+func SignV4AAt(req http.Request, accessKeyID, secretAccessKey, sessionToken, regionSet string, t time.Time) *http.Request {
+	// Signature calculation is not needed for anonymous credentials.
+	if accessKeyID == "" || secretAccessKey == "" {
+		return &req
+	}
+
+	// Sign in UTC.
+	t = t.UTC()
+
+	// Set x-amz-date and x-amz-region-set.
+	req.Header.Set("X-Amz-Date", t.Format(iso8601DateFormat))
+	req.Header.Set("X-Amz-Region-Set", regionSet)
+
+	// Set x-amz-security-token.
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	// Get canonical request.
+	canonicalRequest := getCanonicalRequest(req, ignoredSignV4Headers)
+
+	// Get string to sign from canonical request.
+	stringToSign := getStringToSignV4A(t, s3Service, canonicalRequest)
+
+	// Get credential string.
+	credential := accessKeyID + "/" + getScopeV4A(s3Service, t)
+
+	// Get all signed headers.
+	signedHeaders := getSignedHeaders(req, ignoredSignV4Headers)
+
+	// Calculate signature.
+	signature := getSignatureV4A(accessKeyID, secretAccessKey, stringToSign)
+
+	// Construct the final authorization header.
+	parts := []string{
+		signV4AAlgorithm + " Credential=" + credential,
+		"SignedHeaders=" + signedHeaders,
+		"Signature=" + signature,
+	}
+
+	// Set authorization header.
+	auth := strings.Join(parts, ", ")
+	req.Header.Set("Authorization", auth)
+
+	return &req
+}
+
+// PreSignV4A presign the request with SigV4A, valid in the regions of
+// regionSet for expires seconds. The session token of temporary credentials
+// is signed as part of the query, it is left out if sessionToken is empty.
+func PreSignV4A(req http.Request, accessKeyID, secretAccessKey, sessionToken, regionSet string, expires int64) *http.Request {
+	// Presign is not needed for anonymous credentials.
+	if accessKeyID == "" || secretAccessKey == "" {
+		return &req
+	}
+
+	// Initial time.
+	t := time.Now().UTC()
+
+	// Get credential string.
+	credential := accessKeyID + "/" + getScopeV4A(s3Service, t)
+
+	// Get all signed headers.
+	signedHeaders := getSignedHeaders(req, ignoredSignV4Headers)
+
+	// Set URL query.
+	query := req.URL.Query()
+	query.Set("X-Amz-Algorithm", signV4AAlgorithm)
+	query.Set("X-Amz-Date", t.Format(iso8601DateFormat))
+	query.Set("X-Amz-Expires", strconv.FormatInt(expires, 10))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+	query.Set("X-Amz-Credential", credential)
+	query.Set("X-Amz-Region-Set", regionSet)
+	if sessionToken != "" {
+		query.Set("X-Amz-Security-Token", sessionToken)
+	}
+	req.URL.RawQuery = query.Encode()
+
+	// Get canonical request.
+	canonicalRequest := getCanonicalRequest(req, ignoredSignV4Headers)
+
+	// Get string to sign from canonical request.
+	stringToSign := getStringToSignV4A(t, s3Service, canonicalRequest)
+
+	// Calculate signature.
+	signature := getSignatureV4A(accessKeyID, secretAccessKey, stringToSign)
+
+	// Add signature header to RawQuery.
+	req.URL.RawQuery += "&X-Amz-Signature=" + signature
+
+	return &req
+}
@@ -0,0 +1,113 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package signv4
+
+import (
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDeriveKeyV4A - the key derived matches the one of the AWS SDKs.
+func TestDeriveKeyV4A(t *testing.T) {
+	key, err := deriveKeyV4A("AKISORANDOMAASORANDOM", "q+jcrXGc+0zWN6uzclKVhvMmUsIfRPa4rlRandom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedX := "15D242CEEBF8D8169FD6A8B5A746C41140414C3B07579038DA06AF89190FFFCB"
+	expectedY := "0515242CEDD82E94799482E4C0514B505AFCCF2C0C98D6A553BF539F424C5EC0"
+	if x := fmt.Sprintf("%064X", key.X); x != expectedX {
+		t.Errorf("Expected X %s, got %s", expectedX, x)
+	}
+	if y := fmt.Sprintf("%064X", key.Y); y != expectedY {
+		t.Errorf("Expected Y %s, got %s", expectedY, y)
+	}
+}
+
+// verifySignatureV4A - check the hexadecimal ASN.1 signature of stringToSign against the key of the access key pair.
+func verifySignatureV4A(t *testing.T, stringToSign, signature string) {
+	key, err := deriveKeyV4A("access", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := hex.DecodeString(signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sig ecdsaSignature
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		t.Fatal(err)
+	}
+	if !ecdsa.Verify(&key.PublicKey, sum256([]byte(stringToSign)), sig.R, sig.S) {
+		t.Errorf("Signature %s does not match the string to sign %q", signature, stringToSign)
+	}
+}
+
+// TestSignV4A - SignV4AAt signs the request for the region set with a scope without region.
+func TestSignV4A(t *testing.T) {
+	req := newSuiteRequest(t, suiteTestCases[0])
+	signed := SignV4AAt(req, "access", "secret", "token", "us-east-1,eu-*", suiteTime(t))
+	if regionSet := signed.Header.Get("X-Amz-Region-Set"); regionSet != "us-east-1,eu-*" {
+		t.Errorf("Expected the region set header, got %q", regionSet)
+	}
+	auth := signed.Header.Get("Authorization")
+	prefix := signV4AAlgorithm + " Credential=access/20150830/s3/aws4_request, " +
+		"SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-region-set;x-amz-security-token, Signature="
+	if !strings.HasPrefix(auth, prefix) {
+		t.Fatalf("Expected %s..., got %s", prefix, auth)
+	}
+	canonicalRequest := getCanonicalRequest(*signed, ignoredSignV4Headers)
+	verifySignatureV4A(t, getStringToSignV4A(suiteTime(t), s3Service, canonicalRequest), strings.TrimPrefix(auth, prefix))
+
+	// Anonymous requests are left unsigned.
+	anonymous := SignV4A(newTestRequest(t), "", "", "", "*")
+	if auth := anonymous.Header.Get("Authorization"); auth != "" {
+		t.Errorf("Expected no signature, got %s", auth)
+	}
+}
+
+// TestPreSignV4A - the region set and the SigV4A signature are part of the query.
+func TestPreSignV4A(t *testing.T) {
+	presigned := PreSignV4A(newTestRequest(t), "access", "secret", "", "*", 60)
+	query := presigned.URL.Query()
+	if algorithm := query.Get("X-Amz-Algorithm"); algorithm != signV4AAlgorithm {
+		t.Errorf("Expected algorithm %s, got %s", signV4AAlgorithm, algorithm)
+	}
+	if regionSet := query.Get("X-Amz-Region-Set"); regionSet != "*" {
+		t.Errorf("Expected the region set in the query, got %q", regionSet)
+	}
+	if credential := query.Get("X-Amz-Credential"); strings.Count(credential, "/") != 3 {
+		t.Errorf("Expected a credential without region, got %s", credential)
+	}
+	signature := query.Get("X-Amz-Signature")
+	// The signature is computed over the query without itself.
+	unsigned := *presigned.URL
+	query.Del("X-Amz-Signature")
+	unsigned.RawQuery = query.Encode()
+	req := *presigned
+	req.URL = &unsigned
+	date, err := time.Parse(iso8601DateFormat, query.Get("X-Amz-Date"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	canonicalRequest := getCanonicalRequest(req, ignoredSignV4Headers)
+	verifySignatureV4A(t, getStringToSignV4A(date, s3Service, canonicalRequest), signature)
+}
@@ -0,0 +1,128 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package verify runs the s3verify conformance tests from Go programs,
+// for example from the integration tests of an S3 compatible server:
+//
+//	report, err := verify.Run(context.Background(), verify.Config{
+//		Endpoint: server.URL,
+//		Access:   "access",
+//		Secret:   "secret",
+//	}, verify.Options{Skip: "BucketPolicy$"})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	for _, result := range report.Failures() {
+//		t.Errorf("%s: %v", result.Name, result.Err)
+//	}
+//
+// Nothing is printed to the console unless Options.Progress is set and the
+// process is never exited.
+package verify
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/minio/s3verify/cmd"
+)
+
+// Config - the server to test.
+type Config struct {
+	Endpoint     string       // URL of the server, e.g. "http://localhost:9000".
+	Access       string       // Access key to sign requests with.
+	Secret       string       // Secret key to sign requests with.
+	SessionToken string       // Session token of temporary credentials, empty for long term credentials.
+	Region       string       // Region to sign requests for, derived from Endpoint if empty.
+	HTTPClient   *http.Client // Client to send requests with, a client with short timeouts if nil.
+}
+
+// Options - which tests to run and how.
+type Options struct {
+	Run      string // Only run tests whose name matches this regular expression, see s3verify --run.
+	Skip     string // Never run tests whose name matches this regular expression, see s3verify --skip.
+	Extended bool   // Run the extended tests as well, see s3verify --extended.
+	Suffix   string // Suffix of the buckets created by the run, concurrent runs against one server need different suffixes.
+	Seed     int64  // Seed the names and data of the run are generated from, the current time if zero.
+	Parallel int    // Run up to this many independent tests, and object uploads, at the same time, see s3verify --parallel.
+	Progress bool   // Print the progress and the results of the tests to the console like s3verify does.
+
+	SignatureV2  bool   // Run the signing tests again signed with AWS Signature Version 2, see s3verify --signature.
+	SignatureV4A bool   // Run the core object tests again signed with SigV4A, see s3verify --signature.
+	Lookup       string // Style buckets are addressed with, virtual, path or auto, see s3verify --lookup. Path if empty.
+	Profile      string // JSON file of the known deviations of the server from AWS S3, see s3verify --profile. None if empty.
+}
+
+// Report - the results of a run.
+type Report struct {
+	Endpoint string           // URL of the tested server.
+	Started  time.Time        // When testing started.
+	Duration time.Duration    // How long testing took.
+	Results  []cmd.TestResult // Results of every test in the order they were run.
+}
+
+// Passed - check that no test failed. Tests are only skipped if a test they depend on did not pass, the profile
+// skips them or the run was canceled. Failures the profile expects are reported as cmd.TestXFail.
+func (r Report) Passed() bool {
+	return len(r.Failures()) == 0
+}
+
+// Failures - return the results of the tests that failed.
+func (r Report) Failures() []cmd.TestResult {
+	var failures []cmd.TestResult
+	for _, result := range r.Results {
+		if result.Status == cmd.TestFail {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
+
+// Run - run the selected tests against the server of config. An error is returned if the
+// tests could not be run at all, or if ctx was canceled in which case the report holds the
+// results of the tests run until then.
+func Run(ctx context.Context, config Config, opts Options) (Report, error) {
+	report := Report{
+		Endpoint: config.Endpoint,
+		Started:  time.Now(),
+	}
+	serverConfig := cmd.ServerConfig{
+		Access:       config.Access,
+		Secret:       config.Secret,
+		SessionToken: config.SessionToken,
+		Endpoint:     config.Endpoint,
+		Region:       config.Region,
+		Client:       config.HTTPClient,
+	}
+	results, err := cmd.RunSuite(ctx, serverConfig, cmd.SuiteOptions{
+		Run:      opts.Run,
+		Skip:     opts.Skip,
+		Extended: opts.Extended,
+		Suffix:   opts.Suffix,
+		Seed:     opts.Seed,
+		Parallel: opts.Parallel,
+		Progress: opts.Progress,
+
+		SignatureV2:  opts.SignatureV2,
+		SignatureV4A: opts.SignatureV4A,
+		Lookup:       opts.Lookup,
+		Profile:      opts.Profile,
+	})
+	report.Duration = time.Since(report.Started)
+	report.Results = results
+	return report, err
+}
@@ -0,0 +1,149 @@
+/*
+ * s3verify (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package verify
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/minio/s3verify/cmd"
+	"github.com/minio/s3verify/s3mem"
+)
+
+// Credentials of the in-memory server the tests are run against.
+const (
+	testAccessKey = "verify-access"
+	testSecretKey = "verify-secret"
+	testRegion    = "us-east-1"
+)
+
+// newTestServer - start an in-memory S3 server.
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(s3mem.New(testAccessKey, testSecretKey, testRegion))
+}
+
+// Tests that a selection of tests passes against a conforming server.
+func TestRun(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	report, err := Run(context.Background(), Config{
+		Endpoint: server.URL,
+		Access:   testAccessKey,
+		Secret:   testSecretKey,
+		Region:   testRegion,
+	}, Options{Run: "^ListBuckets$"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ListBuckets lists the buckets made by PutBucket which is run first.
+	if len(report.Results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(report.Results))
+	}
+	for _, result := range report.Results {
+		if result.Status != cmd.TestPass {
+			t.Errorf("%s: expected %s, got %s: %v", result.Name, cmd.TestPass, result.Status, result.Err)
+		}
+	}
+	if !report.Passed() {
+		t.Errorf("Expected the report to pass, failures: %v", report.Failures())
+	}
+}
+
+// Tests that the tests the profile skips are not run.
+func TestRunProfile(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "s3verify-profile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	profile := filepath.Join(dir, "profile.json")
+	if err := ioutil.WriteFile(profile, []byte(`{"name": "mem", "skip": [{"test": "^ListBuckets$", "reason": "Not implemented."}]}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	report, err := Run(context.Background(), Config{
+		Endpoint: server.URL,
+		Access:   testAccessKey,
+		Secret:   testSecretKey,
+	}, Options{Run: "^ListBuckets$", Profile: profile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []cmd.TestStatus{cmd.TestPass, cmd.TestSkip}
+	if len(report.Results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(report.Results))
+	}
+	for i, result := range report.Results {
+		if result.Status != expected[i] {
+			t.Errorf("%s: expected %s, got %s: %v", result.Name, expected[i], result.Status, result.Err)
+		}
+	}
+}
+
+// Tests that the remaining tests are skipped once the run was canceled.
+func TestRunCanceled(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err := Run(ctx, Config{
+		Endpoint: server.URL,
+		Access:   testAccessKey,
+		Secret:   testSecretKey,
+	}, Options{})
+	if err != context.Canceled {
+		t.Fatalf("Expected %v, got %v", context.Canceled, err)
+	}
+	if len(report.Results) == 0 {
+		t.Fatal("Expected the skipped tests to be reported")
+	}
+	for _, result := range report.Results {
+		if result.Status != cmd.TestSkip {
+			t.Errorf("%s: expected %s, got %s", result.Name, cmd.TestSkip, result.Status)
+		}
+	}
+}
+
+// Tests that invalid configurations are reported instead of exiting.
+func TestRunInvalidConfig(t *testing.T) {
+	testCases := []struct {
+		config Config
+		opts   Options
+	}{
+		// Missing endpoint.
+		{Config{Access: testAccessKey, Secret: testSecretKey}, Options{}},
+		// Missing credentials.
+		{Config{Endpoint: "http://localhost:9000"}, Options{}},
+		// Invalid selection.
+		{Config{Endpoint: "http://localhost:9000", Access: testAccessKey, Secret: testSecretKey}, Options{Run: "("}},
+		// Missing profile.
+		{Config{Endpoint: "http://localhost:9000", Access: testAccessKey, Secret: testSecretKey}, Options{Profile: "does-not-exist.json"}},
+	}
+	for i, testCase := range testCases {
+		if _, err := Run(context.Background(), testCase.config, testCase.opts); err == nil {
+			t.Errorf("Test %d: expected an error", i+1)
+		}
+	}
+}